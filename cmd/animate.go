@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/animation"
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	animateOutput      string
+	animateType        string
+	animateDelay       int
+	animateDelays      []int
+	animateLoop        int
+	animateColumns     int
+	animateWidth       int
+	animateHeight      int
+	animateMode        string
+	animateAnchor      string
+	animateBackground  string
+	animateColors      int
+	animateNoDither    bool
+	animatePipelineDir string
+	animateGroup       string
+)
+
+// Animation output types
+const (
+	animationStrip = "strip"
+	animationGIF   = "gif"
+	animationAPNG  = "apng"
+)
+
+// animateCmd represents the animate command
+var animateCmd = &cobra.Command{
+	Use:   "animate [image-file...]",
+	Short: "Combine images into a sprite strip, animated GIF or APNG",
+	Long: `Combine an ordered set of images into a sprite strip, an animated GIF or an
+animated PNG (APNG).
+
+Frames are the files and glob patterns given as arguments, in order, or the
+outputs of a pipeline run with --pipeline-dir, optionally limited to one
+--group. Glob matches are sorted in natural order, so frame_2.png comes
+before frame_10.png.
+
+Every frame gets the same size: by default the largest width and height of
+the images, with smaller images padded at --anchor without scaling. --width
+and --height resize every frame to a fixed size using --mode (fit, fill or
+exact).
+
+Output types (--type, default from the --output extension):
+  strip  Frames side by side, or in a grid of --columns (.png, .webp, ...)
+  gif    Animated GIF with a palette of --colors shared by all frames and
+         Floyd-Steinberg dithering (.gif)
+  apng   Animated PNG with full color and transparency (.apng, or .png
+         with --type apng)
+
+Examples:
+  # Animated GIF at 8 frames per second
+  asset-generator animate "walk/frame_*.png" -o walk.gif --delay 125
+
+  # Sprite sheet of 4 columns for a game engine
+  asset-generator animate "walk/frame_*.png" -o walk_sheet.png --columns 4
+
+  # APNG preview of a pipeline group, holding the last frame longer
+  asset-generator animate --pipeline-dir ./assets --group poses -o poses.png \
+    --type apng --delays 100,100,100,500
+
+  # Small GIF preview with fewer colors and a loop count
+  asset-generator animate frames/*.png -o preview.gif -w 128 --colors 64 --loop 3`,
+	RunE: runAnimate,
+}
+
+func init() {
+	rootCmd.AddCommand(animateCmd)
+
+	animateCmd.Flags().StringVarP(&animateOutput, "output", "o", "animation.gif", "output file path")
+	animateCmd.Flags().StringVar(&animateType, "type", "", "output type: strip, gif, apng (default: from the output extension)")
+	animateCmd.Flags().IntVar(&animateDelay, "delay", 100, "time each frame is shown in milliseconds")
+	animateCmd.Flags().IntSliceVar(&animateDelays, "delays", []int{}, "per-frame delays in milliseconds, one for every frame")
+	animateCmd.Flags().IntVar(&animateLoop, "loop", 0, "number of times the animation plays (0 = forever)")
+	animateCmd.Flags().IntVar(&animateColumns, "columns", 0, "frames per row of a strip (0 = a single row)")
+	animateCmd.Flags().IntVarP(&animateWidth, "width", "w", 0, "frame width in pixels (default: the widest image)")
+	animateCmd.Flags().IntVarP(&animateHeight, "height", "l", 0, "frame height in pixels (default: the tallest image)")
+	animateCmd.Flags().StringVar(&animateMode, "mode", "fit", "how images are fitted to --width and --height: fit, fill, exact")
+	animateCmd.Flags().StringVar(&animateAnchor, "anchor", "center", "where images are aligned when padded or cropped: center, top, bottom-left, ...")
+	animateCmd.Flags().StringVar(&animateBackground, "background", "transparent", "color of the padding around images, as a name or #rrggbb")
+	animateCmd.Flags().IntVar(&animateColors, "colors", 256, "GIF palette size (2-256)")
+	animateCmd.Flags().BoolVar(&animateNoDither, "no-dither", false, "map GIF colors to the nearest palette color without dithering")
+	animateCmd.Flags().StringVar(&animatePipelineDir, "pipeline-dir", "", "animate the outputs recorded in this pipeline output directory")
+	animateCmd.Flags().StringVar(&animateGroup, "group", "", "with --pipeline-dir, animate only this group and its subgroups")
+}
+
+func runAnimate(cmd *cobra.Command, args []string) error {
+	outputType, err := animationType(animateOutput, animateType)
+	if err != nil {
+		return err
+	}
+	opts, err := parseAnimateOptions()
+	if err != nil {
+		return err
+	}
+	if animateColors < 2 || animateColors > 256 {
+		return fmt.Errorf("--colors must be between 2 and 256")
+	}
+
+	paths, _, err := collectImages(args, animatePipelineDir, animateGroup)
+	if err != nil {
+		return err
+	}
+	if len(animateDelays) > 0 && len(animateDelays) != len(paths) {
+		return fmt.Errorf("--delays has %d values for %d frames", len(animateDelays), len(paths))
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Animating %d frames\n", len(paths))
+	}
+	images, err := animation.Load(paths)
+	if err != nil {
+		return err
+	}
+	anim, err := animation.New(images, opts)
+	if err != nil {
+		return fmt.Errorf("failed to assemble frames: %w", err)
+	}
+
+	switch outputType {
+	case animationStrip:
+		err = saveImage(animateOutput, anim.Strip(animateColumns), processor.FormatFromPath(animateOutput))
+	case animationGIF:
+		err = writeOutputFile(animateOutput, func(w io.Writer) error {
+			return anim.EncodeGIF(w, animation.GIFOptions{Colors: animateColors, NoDither: animateNoDither})
+		})
+	case animationAPNG:
+		err = writeOutputFile(animateOutput, anim.EncodeAPNG)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		size := anim.Bounds().Size()
+		fmt.Fprintf(os.Stderr, "✓ Animated %d frames (%dx%d, %s): %s\n", len(anim.Frames), size.X, size.Y, outputType, animateOutput)
+	}
+	return nil
+}
+
+// parseAnimateOptions converts the frame size, delay and loop flags
+func parseAnimateOptions() (animation.Options, error) {
+	opts := animation.Options{
+		Width:     animateWidth,
+		Height:    animateHeight,
+		Delay:     time.Duration(animateDelay) * time.Millisecond,
+		LoopCount: animateLoop,
+	}
+	if animateWidth < 0 || animateHeight < 0 {
+		return opts, fmt.Errorf("frame size cannot be negative")
+	}
+	if animateDelay < 1 {
+		return opts, fmt.Errorf("--delay must be at least 1 millisecond")
+	}
+	if animateLoop < 0 {
+		return opts, fmt.Errorf("--loop cannot be negative")
+	}
+	for _, ms := range animateDelays {
+		if ms < 1 {
+			return opts, fmt.Errorf("--delays must be at least 1 millisecond each")
+		}
+		opts.Delays = append(opts.Delays, time.Duration(ms)*time.Millisecond)
+	}
+
+	var err error
+	if opts.Mode, err = processor.ParseResizeMode(animateMode); err != nil {
+		return opts, err
+	}
+	if opts.Anchor, err = processor.ParseAnchor(animateAnchor); err != nil {
+		return opts, err
+	}
+	bg, err := processor.ParseColor(animateBackground)
+	if err != nil {
+		return opts, err
+	}
+	opts.Background = bg
+	return opts, nil
+}
+
+// animationType returns the output type named by --type, or else the one
+// the output extension stands for
+func animationType(output, outputType string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(output))
+	switch strings.ToLower(outputType) {
+	case "":
+		switch {
+		case ext == ".gif":
+			return animationGIF, nil
+		case ext == ".apng":
+			return animationAPNG, nil
+		case processor.FormatFromPath(output) != "":
+			return animationStrip, nil
+		}
+		return "", fmt.Errorf("cannot tell the output type from '%s' (use a .gif, .apng or image extension, or --type)", output)
+	case animationStrip, "sheet":
+		if processor.FormatFromPath(output) == "" {
+			return "", fmt.Errorf("strip output must have an image extension such as .png, got '%s'", output)
+		}
+		return animationStrip, nil
+	case animationGIF:
+		if ext != ".gif" {
+			return "", fmt.Errorf("GIF output must have a .gif extension, got '%s'", output)
+		}
+		return animationGIF, nil
+	case animationAPNG, "png":
+		if ext != ".apng" && ext != ".png" {
+			return "", fmt.Errorf("APNG output must have a .apng or .png extension, got '%s'", output)
+		}
+		return animationAPNG, nil
+	default:
+		return "", fmt.Errorf("invalid output type '%s' (valid options: strip, gif, apng)", outputType)
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import "testing"
+
+func TestAnimationType(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		outputType string
+		want       string
+		wantErr    bool
+	}{
+		{name: "gif extension", output: "walk.gif", want: animationGIF},
+		{name: "apng extension", output: "walk.APNG", want: animationAPNG},
+		{name: "png extension", output: "walk.png", want: animationStrip},
+		{name: "webp strip", output: "sheet.webp", outputType: "strip", want: animationStrip},
+		{name: "apng as png", output: "walk.png", outputType: "apng", want: animationAPNG},
+		{name: "unknown extension", output: "walk.mp4", wantErr: true},
+		{name: "gif type with png extension", output: "walk.png", outputType: "gif", wantErr: true},
+		{name: "apng type with gif extension", output: "walk.gif", outputType: "apng", wantErr: true},
+		{name: "strip without image extension", output: "walk.apng", outputType: "strip", wantErr: true},
+		{name: "bad type", output: "walk.gif", outputType: "video", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := animationType(tt.output, tt.outputType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("animationType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("animationType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/opd-ai/asset-generator/pkg/atlas"
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	atlasOutput        string
+	atlasData          []string
+	atlasMaxSize       int
+	atlasPadding       int
+	atlasExtrude       int
+	atlasTrim          bool
+	atlasTrimThreshold int
+	atlasPowerOfTwo    bool
+	atlasSquare        bool
+	atlasPipelineDir   string
+	atlasGroup         string
+)
+
+// atlasCmd represents the atlas command
+var atlasCmd = &cobra.Command{
+	Use:   "atlas",
+	Short: "Build sprite sheets and texture atlases",
+	Long: `Build sprite sheets and texture atlases from generated images.
+
+Examples:
+  # Pack all icons into atlas.png with TexturePacker JSON metadata
+  asset-generator atlas pack icons/*.png`,
+}
+
+// atlasPackCmd represents the atlas pack command
+var atlasPackCmd = &cobra.Command{
+	Use:   "pack [image-file...]",
+	Short: "Pack images into a texture atlas",
+	Long: `Pack images into a single texture atlas image and write the position of
+every frame as metadata.
+
+Images are packed with the MaxRects bin packing algorithm into the smallest
+atlas within --max-size. --trim removes transparent borders from each image
+first; the metadata records the trimmed area so engines can restore the
+original size. --padding keeps transparent pixels between frames and
+--extrude repeats the edge pixels of each frame outwards, which prevents
+texture filtering from bleeding neighbouring frames into each other.
+
+Images are the files and glob patterns given as arguments, or the outputs
+of a pipeline run with --pipeline-dir, optionally limited to one --group
+and its subgroups. Frames are named by file name, or for pipeline outputs
+by their path in the output directory.
+
+Metadata formats (--data, comma-separated):
+  json-hash   TexturePacker JSON (Hash), read by Phaser, PixiJS and others
+              (atlas.json)
+  json-array  TexturePacker JSON (Array) (atlas.array.json)
+  phaser      Phaser 3 multi-atlas JSON (atlas.phaser.json)
+  csv         One row per frame (atlas.csv)
+
+Examples:
+  # Pack icons into atlas.png and atlas.json
+  asset-generator atlas pack icons/*.png
+
+  # Trimmed sprites with 2px extrusion for a game engine
+  asset-generator atlas pack "sprites/*.png" -o sheets/sprites.png --trim --extrude 2
+
+  # Pack a pipeline group with Phaser and CSV metadata
+  asset-generator atlas pack --pipeline-dir ./assets --group characters/heroes \
+    -o heroes.png --data phaser,csv
+
+  # Power-of-two square atlas for older GPUs
+  asset-generator atlas pack tiles/*.png --pot --square --max-size 2048`,
+	RunE: runAtlasPack,
+}
+
+func init() {
+	rootCmd.AddCommand(atlasCmd)
+	atlasCmd.AddCommand(atlasPackCmd)
+
+	atlasPackCmd.Flags().StringVarP(&atlasOutput, "output", "o", "atlas.png", "atlas image path (png or webp); metadata is written next to it")
+	atlasPackCmd.Flags().StringSliceVar(&atlasData, "data", []string{"json-hash"}, "metadata formats: json-hash, json-array, phaser, csv")
+	atlasPackCmd.Flags().IntVar(&atlasMaxSize, "max-size", 4096, "largest atlas width and height in pixels")
+	atlasPackCmd.Flags().IntVar(&atlasPadding, "padding", 2, "transparent pixels between frames")
+	atlasPackCmd.Flags().IntVar(&atlasExtrude, "extrude", 0, "pixels by which frame edges are repeated outwards")
+	atlasPackCmd.Flags().BoolVar(&atlasTrim, "trim", false, "remove transparent borders from images")
+	atlasPackCmd.Flags().IntVar(&atlasTrimThreshold, "trim-threshold", 0, "alpha at or below which pixels are trimmed (0-255)")
+	atlasPackCmd.Flags().BoolVar(&atlasPowerOfTwo, "pot", false, "round the atlas size up to powers of two")
+	atlasPackCmd.Flags().BoolVar(&atlasSquare, "square", false, "make the atlas square")
+	atlasPackCmd.Flags().StringVar(&atlasPipelineDir, "pipeline-dir", "", "pack the outputs recorded in this pipeline output directory")
+	atlasPackCmd.Flags().StringVar(&atlasGroup, "group", "", "with --pipeline-dir, pack only this group and its subgroups (group path or output directory, e.g. characters/heroes)")
+}
+
+func runAtlasPack(cmd *cobra.Command, args []string) error {
+	imageFormat := processor.FormatFromPath(atlasOutput)
+	if imageFormat != "png" && imageFormat != "webp" {
+		return fmt.Errorf("atlas output must be a .png or .webp file, got '%s'", atlasOutput)
+	}
+	var formats []atlas.Format
+	for _, name := range atlasData {
+		format, err := atlas.ParseFormat(name)
+		if err != nil {
+			return err
+		}
+		formats = append(formats, format)
+	}
+	if atlasMaxSize < 1 {
+		return fmt.Errorf("--max-size must be positive")
+	}
+	if atlasTrimThreshold < 0 || atlasTrimThreshold > 255 {
+		return fmt.Errorf("--trim-threshold must be between 0 and 255")
+	}
+
+	paths, names, err := collectImages(args, atlasPipelineDir, atlasGroup)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Packing %d images\n", len(paths))
+	}
+	sprites, err := atlas.Load(paths, names)
+	if err != nil {
+		return err
+	}
+	sheet, err := atlas.Pack(sprites, atlas.Options{
+		MaxWidth:      atlasMaxSize,
+		MaxHeight:     atlasMaxSize,
+		Padding:       atlasPadding,
+		Extrude:       atlasExtrude,
+		Trim:          atlasTrim,
+		TrimThreshold: uint8(atlasTrimThreshold),
+		PowerOfTwo:    atlasPowerOfTwo,
+		Square:        atlasSquare,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack atlas: %w", err)
+	}
+
+	if err := saveImage(atlasOutput, sheet.Image, imageFormat); err != nil {
+		return err
+	}
+	if !quiet {
+		size := sheet.Image.Rect.Size()
+		fmt.Fprintf(os.Stderr, "✓ Packed %d frames: %s (%dx%d)\n", len(sheet.Frames), atlasOutput, size.X, size.Y)
+	}
+
+	for _, format := range formats {
+		file := atlas.MetadataPath(atlasOutput, format)
+		err := writeOutputFile(file, func(w io.Writer) error {
+			return atlas.WriteMetadata(w, sheet, format, filepath.Base(atlasOutput))
+		})
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "✓ Metadata (%s): %s\n", format, file)
+		}
+	}
+
+	return nil
+}
+
+// collectImages returns the images named by file and glob arguments, or the
+// outputs of a pipeline group, with the names their frames get. Matches of
+// a glob are sorted in natural order (frame_2 before frame_10); files keep
+// the order given.
+func collectImages(args []string, pipelineDir, group string) ([]string, []string, error) {
+	if pipelineDir != "" {
+		if len(args) > 0 {
+			return nil, nil, fmt.Errorf("cannot combine image arguments with --pipeline-dir")
+		}
+		return pipelineGroupImages(pipelineDir, group)
+	}
+	if group != "" {
+		return nil, nil, fmt.Errorf("--group requires --pipeline-dir")
+	}
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("no images given (pass image files or --pipeline-dir)")
+	}
+
+	var paths, names []string
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, nil, fmt.Errorf("invalid pattern '%s': %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, nil, fmt.Errorf("no files match '%s'", arg)
+			}
+			sort.Slice(matches, func(i, j int) bool { return naturalLess(matches[i], matches[j]) })
+		} else if _, err := os.Stat(arg); err != nil {
+			return nil, nil, fmt.Errorf("file not found: %s", arg)
+		}
+		for _, file := range matches {
+			paths = append(paths, file)
+			names = append(names, filepath.Base(file))
+		}
+	}
+	return paths, names, nil
+}
+
+// pipelineGroupImages returns the generated outputs recorded in a pipeline
+// manifest, limited to a group and its subgroups, in asset path order. Like
+// --only, a group matches by its name path or by its output directory.
+func pipelineGroupImages(outputDir, group string) ([]string, []string, error) {
+	manifest, err := loadPipelineManifest(outputDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	group = strings.Trim(filepath.ToSlash(group), "/")
+
+	keys := make([]string, 0, len(manifest.Assets))
+	for key, entry := range manifest.Assets {
+		if entry.Status != manifestStatusCompleted && entry.Status != manifestStatusSelected {
+			continue
+		}
+		if inGroup(entry.Group, group) || inGroup(path.Dir(entry.Output), group) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		if group != "" {
+			return nil, nil, fmt.Errorf("no generated assets in group '%s' of %s", group, filepath.Join(outputDir, manifestFileName))
+		}
+		return nil, nil, fmt.Errorf("no generated assets in %s", filepath.Join(outputDir, manifestFileName))
+	}
+	sort.Slice(keys, func(i, j int) bool { return naturalLess(keys[i], keys[j]) })
+
+	var paths, names []string
+	for _, key := range keys {
+		entry := manifest.Assets[key]
+		file := filepath.Join(outputDir, filepath.FromSlash(entry.Output))
+		if _, err := os.Stat(file); err != nil {
+			return nil, nil, fmt.Errorf("output of %s is missing: %s", key, file)
+		}
+		paths = append(paths, file)
+		names = append(names, entry.Output)
+	}
+	return paths, names, nil
+}
+
+// naturalLess orders strings with runs of digits compared by their numeric
+// value, so that frame_2 sorts before frame_10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da == "" || db == "" {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		// Compare the numbers without leading zeros, then by length
+		na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if len(na) != len(nb) {
+			return len(na) < len(nb)
+		}
+		if na != nb {
+			return na < nb
+		}
+		if len(da) != len(db) {
+			return len(da) < len(db)
+		}
+		a, b = a[len(da):], b[len(db):]
+	}
+	return len(a) < len(b)
+}
+
+// digitPrefix returns the run of ASCII digits s starts with
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// inGroup reports whether a slash-separated group path is group or one of
+// its subgroups; every path is in the empty group
+func inGroup(groupPath, group string) bool {
+	return group == "" || groupPath == group || strings.HasPrefix(groupPath, group+"/")
+}
+
+// saveImage encodes img to a file in a format
+func saveImage(file string, img image.Image, format string) error {
+	return writeOutputFile(file, func(w io.Writer) error {
+		return processor.Encode(w, img, format, 0)
+	})
+}
+
+// writeOutputFile creates a file, and its directory, and writes it with
+// write
+func writeOutputFile(file string, write func(w io.Writer) error) error {
+	if dir := filepath.Dir(file); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", file, err)
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
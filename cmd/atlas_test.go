@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectImages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.png", "a.png", "c.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, names, err := collectImages([]string{filepath.Join(dir, "c.jpg"), filepath.Join(dir, "*.png")}, "", "")
+	if err != nil {
+		t.Fatalf("collectImages() error = %v", err)
+	}
+	if want := []string{"c.jpg", "a.png", "b.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if paths[1] != filepath.Join(dir, "a.png") {
+		t.Errorf("paths = %v", paths)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		group string
+	}{
+		{name: "no images"},
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.png")}},
+		{name: "no glob matches", args: []string{filepath.Join(dir, "*.webp")}},
+		{name: "group without pipeline dir", args: []string{filepath.Join(dir, "a.png")}, group: "heroes"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := collectImages(tt.args, "", tt.group); err == nil {
+				t.Error("collectImages() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestPipelineGroupImages(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &PipelineManifest{Assets: map[string]*ManifestAsset{
+		"characters/heroes/knight":   {ID: "knight", Group: "characters/heroes", Output: "characters/heroes/knight.png", Status: manifestStatusCompleted},
+		"characters/heroes/archer":   {ID: "archer", Group: "characters/heroes", Output: "characters/heroes/archer.png", Status: manifestStatusSelected},
+		"characters/villains/goblin": {ID: "goblin", Group: "characters/villains", Output: "characters/villains/goblin.png", Status: manifestStatusCompleted},
+		"enemies/goblin":             {ID: "goblin", Group: "enemies", Output: "art/villains/goblin.png", Status: manifestStatusCompleted},
+		"characters/heroes/mage":     {ID: "mage", Group: "characters/heroes", Output: "characters/heroes/mage.png", Status: manifestStatusFailed},
+	}}
+	if err := manifest.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range manifest.Assets {
+		path := filepath.Join(dir, filepath.FromSlash(entry.Output))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		group   string
+		want    []string
+		wantErr bool
+	}{
+		{group: "characters/heroes", want: []string{"characters/heroes/archer.png", "characters/heroes/knight.png"}},
+		{group: "characters/", want: []string{"characters/heroes/archer.png", "characters/heroes/knight.png", "characters/villains/goblin.png"}},
+		{group: "", want: []string{"characters/heroes/archer.png", "characters/heroes/knight.png", "characters/villains/goblin.png", "art/villains/goblin.png"}},
+		{group: "characters/her", wantErr: true},
+		{group: "art/villains", want: []string{"art/villains/goblin.png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			_, names, err := pipelineGroupImages(dir, tt.group)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pipelineGroupImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("names = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	sorted := []string{"frame_1.png", "frame_2.png", "frame_02b.png", "frame_10.png", "frame_10a.png", "frame_b.png", "walk/frame_3.png"}
+	for i := range sorted {
+		for j := range sorted {
+			if got, want := naturalLess(sorted[i], sorted[j]), i < j; got != want {
+				t.Errorf("naturalLess(%q, %q) = %v, want %v", sorted[i], sorted[j], got, want)
+			}
+		}
+	}
+}
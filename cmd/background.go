@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backgroundMode      string
+	backgroundColor     string
+	backgroundTolerance int
+	backgroundFeather   int
+	backgroundOutput    string
+	backgroundInPlace   bool
+)
+
+// backgroundCmd represents the background command
+var backgroundCmd = &cobra.Command{
+	Use:   "background",
+	Short: "Edit image backgrounds",
+	Long: `Edit the backgrounds of images.
+
+Examples:
+  # Make the white background of a sprite transparent
+  asset-generator background remove sprite.png`,
+}
+
+// backgroundRemoveCmd represents the background remove command
+var backgroundRemoveCmd = &cobra.Command{
+	Use:   "remove [image-file...]",
+	Short: "Make flat image backgrounds transparent",
+	Long: `Make the flat background of generated sprites and icons transparent.
+
+The background color is sampled from the image corners unless --color sets
+it. Pixels within --tolerance of it are background; --feather fades pixels
+slightly further away to partly transparent for smooth edges, taking the
+background color out of them so no fringe remains.
+
+Modes:
+  flood  Clear only background connected to the image borders, keeping
+         enclosed areas of the same color such as white eyes (default)
+  key    Clear every pixel of the background color, including holes
+
+Examples:
+  # Remove a white background (writes sprite_nobg.png)
+  asset-generator background remove sprite.png
+
+  # Soft edges for anti-aliased icons, replacing the originals
+  asset-generator background remove icons/*.png --feather 24 --in-place
+
+  # Key out a green screen, including the gaps between letters
+  asset-generator background remove logo.jpg --mode key --color "#00ff00" --tolerance 40
+
+  # Specify the output path
+  asset-generator background remove hero.jpg -o hero.png
+
+Output files are written next to their inputs as name_nobg.png unless
+--output or --in-place is given. Output is RGBA PNG unless --output names
+another format with transparency (webp, gif, bmp, tiff); JPEG inputs are
+replaced by a PNG in place.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBackgroundRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(backgroundCmd)
+	backgroundCmd.AddCommand(backgroundRemoveCmd)
+
+	backgroundRemoveCmd.Flags().StringVar(&backgroundMode, "mode", "flood", "how to find the background: flood (from the borders), key (everywhere)")
+	backgroundRemoveCmd.Flags().StringVar(&backgroundColor, "color", "", "background color, as a name or #rrggbb (default: sampled from the corners)")
+	backgroundRemoveCmd.Flags().IntVar(&backgroundTolerance, "tolerance", 10, "largest per-channel difference from the background color (0-255)")
+	backgroundRemoveCmd.Flags().IntVar(&backgroundFeather, "feather", 0, "fade pixels up to this much further from the background color (0-255, 0=hard edges)")
+	backgroundRemoveCmd.Flags().StringVarP(&backgroundOutput, "output", "o", "", "output file path (single file mode only)")
+	backgroundRemoveCmd.Flags().BoolVar(&backgroundInPlace, "in-place", false, "replace original file(s) with the transparent version")
+}
+
+func runBackgroundRemove(cmd *cobra.Command, args []string) error {
+	mode, err := processor.ParseBackgroundMode(backgroundMode)
+	if err != nil {
+		return err
+	}
+	if backgroundTolerance < 0 || backgroundTolerance > 255 {
+		return fmt.Errorf("tolerance must be between 0 and 255")
+	}
+	if backgroundFeather < 0 || backgroundFeather > 255 {
+		return fmt.Errorf("feather must be between 0 and 255")
+	}
+	opts := processor.BackgroundOptions{
+		Mode:      mode,
+		Tolerance: uint8(backgroundTolerance),
+		Feather:   uint8(backgroundFeather),
+	}
+	if backgroundColor != "" {
+		c, err := processor.ParseColor(backgroundColor)
+		if err != nil {
+			return err
+		}
+		opts.Color = &c
+	}
+
+	// Validate output flag usage
+	if backgroundOutput != "" && len(args) > 1 {
+		return fmt.Errorf("--output can only be used with a single input file")
+	}
+	if backgroundOutput != "" && backgroundInPlace {
+		return fmt.Errorf("cannot specify both --output and --in-place")
+	}
+	if backgroundOutput != "" && processor.FormatFromPath(backgroundOutput) == "jpeg" {
+		return fmt.Errorf("jpeg output cannot hold transparency (use png, webp, gif, bmp or tiff)")
+	}
+
+	processedCount := 0
+	errorCount := 0
+
+	for _, inputPath := range args {
+		if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", inputPath)
+			errorCount++
+			continue
+		}
+
+		// Determine output path; JPEGs can't hold transparency, so in place
+		// they are replaced by a PNG
+		var outputPath string
+		if backgroundInPlace {
+			outputPath = inputPath
+			if processor.FormatFromPath(inputPath) == "jpeg" {
+				outputPath = processor.ReplaceExtension(inputPath, "png")
+			}
+		} else if backgroundOutput != "" {
+			outputPath = backgroundOutput
+		} else {
+			outputPath = fmt.Sprintf("%s_nobg.png", strings.TrimSuffix(inputPath, filepath.Ext(inputPath)))
+		}
+
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Removing background: %s -> %s (mode: %s, tolerance: %d, feather: %d)\n",
+				inputPath, outputPath, mode, backgroundTolerance, backgroundFeather)
+		}
+
+		if err := processor.RemoveBackground(inputPath, outputPath, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing background from %s: %v\n", inputPath, err)
+			errorCount++
+			continue
+		}
+		if backgroundInPlace && outputPath != inputPath {
+			if err := os.Remove(inputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", inputPath, err)
+			}
+		}
+
+		processedCount++
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "✓ Removed background: %s\n", outputPath)
+		}
+	}
+
+	// Summary
+	if !quiet && len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "\nBackground removal complete: %d succeeded, %d failed\n", processedCount, errorCount)
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("failed to remove background from %d image(s)", errorCount)
+	}
+
+	return nil
+}
+
+// validateRemoveBackground checks the --remove-background options of the
+// generate and pipeline commands before anything is generated
+func validateRemoveBackground(mode, color, imageFormat string) error {
+	if _, err := processor.ParseBackgroundMode(mode); err != nil {
+		return err
+	}
+	if color != "" {
+		if _, err := processor.ParseColor(color); err != nil {
+			return err
+		}
+	}
+	if format, _ := processor.ParseFormat(imageFormat); format == "jpeg" {
+		return fmt.Errorf("--remove-background cannot be used with jpeg output, which has no transparency")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import "testing"
+
+func TestValidateRemoveBackground(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		color       string
+		imageFormat string
+		wantErr     bool
+	}{
+		{name: "defaults", mode: "flood"},
+		{name: "key with color", mode: "key", color: "#00ff00", imageFormat: "webp"},
+		{name: "bad mode", mode: "magic", wantErr: true},
+		{name: "bad color", mode: "flood", color: "chartreuse", wantErr: true},
+		{name: "jpeg output", mode: "flood", imageFormat: "jpg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRemoveBackground(tt.mode, tt.color, tt.imageFormat)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRemoveBackground() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -22,9 +22,10 @@ var (
 var convertCmd = &cobra.Command{
 	Use:   "convert",
 	Short: "Convert images to different formats",
-	Long: `Convert images to different formats, primarily focusing on SVG conversion.
+	Long: `Convert images to different formats: between raster formats with
+'convert image', or to SVG with 'convert svg'.
 
-Supports two conversion methods:
+SVG conversion supports two methods:
   - primitive: Uses geometric shapes to approximate the image (fogleman/primitive)
   - gotrace:   Pure-Go edge tracing for vector conversion (dennwc/gotrace)
 
@@ -36,7 +37,10 @@ Examples:
   asset-generator convert svg input.png --method gotrace
 
   # Specify custom output path
-  asset-generator convert svg input.png -o output.svg --shapes 200`,
+  asset-generator convert svg input.png -o output.svg --shapes 200
+
+  # Convert a PNG to JPEG with a white background
+  asset-generator convert image input.png --to jpg`,
 }
 
 // convertSvgCmd represents the svg conversion command
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertImageTo         string
+	convertImageQuality    int
+	convertImageLossless   bool
+	convertImageBackground string
+	convertImageOutput     string
+	convertImageOutDir     string
+)
+
+// convertImageCmd represents the image format conversion command
+var convertImageCmd = &cobra.Command{
+	Use:   "image <input-file...>",
+	Short: "Convert images between PNG, JPEG, WebP, GIF, BMP and TIFF",
+	Long: `Convert one or more images to another raster format.
+
+Transparent images converted to formats without alpha (JPEG) are flattened
+onto a background color, white by default. Setting --background flattens
+images for the other formats too.
+
+Output files are written next to their inputs with the extension of the new
+format, or into --out-dir. An input already in the target format needs
+--output or --out-dir, and inputs that would be written to the same file (such
+as a/x.png and b/x.png with --out-dir) are rejected rather than overwritten.
+Glob patterns are expanded, so quoting them works on shells that do not
+expand them.
+
+Supported formats:
+  png   - Lossless, metadata stripped
+  jpeg  - Lossy, --quality sets the quality (default: 90)
+  webp  - Lossless only; --quality is not supported, as the encoder cannot
+          write lossy WebP
+  gif   - 256-color palette, so not lossless for most images
+  bmp   - Uncompressed
+  tiff  - Lossless, Deflate-compressed
+
+Examples:
+  # Convert a PNG to JPEG on a white background
+  asset-generator convert image icon.png --to jpg
+
+  # Convert every PNG to WebP into another directory
+  asset-generator convert image 'renders/*.png' --to webp --out-dir web/
+
+  # High-quality JPEGs flattened onto a dark background
+  asset-generator convert image *.png --to jpeg --quality 95 --background "#1e1e1e"
+
+  # TIFF for print, failing rather than losing any detail
+  asset-generator convert image poster.png --to tiff --lossless
+
+  # Specify the output path
+  asset-generator convert image hero.png --to webp -o site/hero.webp`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConvertImage,
+}
+
+func init() {
+	convertCmd.AddCommand(convertImageCmd)
+
+	convertImageCmd.Flags().StringVar(&convertImageTo, "to", "", "output format: png, jpeg (jpg), webp, gif, bmp, tiff (required)")
+	convertImageCmd.Flags().IntVar(&convertImageQuality, "quality", 90, "JPEG quality (1-100)")
+	convertImageCmd.Flags().BoolVar(&convertImageLossless, "lossless", false, "fail instead of converting to a lossy format")
+	convertImageCmd.Flags().StringVar(&convertImageBackground, "background", "", "background color for flattening transparency, as a name or #rrggbb (default: white for JPEG)")
+	convertImageCmd.Flags().StringVarP(&convertImageOutput, "output", "o", "", "output file path (single file mode only)")
+	convertImageCmd.Flags().StringVar(&convertImageOutDir, "out-dir", "", "directory to write converted images to (default: next to the input)")
+	convertImageCmd.MarkFlagRequired("to")
+}
+
+func runConvertImage(cmd *cobra.Command, args []string) error {
+	format, err := processor.ParseFormat(convertImageTo)
+	if err != nil {
+		return err
+	}
+	if convertImageQuality < 1 || convertImageQuality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	if convertImageLossless && (format == "jpeg" || format == "gif") {
+		return fmt.Errorf("%s output is lossy and cannot be used with --lossless", format)
+	}
+	if cmd.Flags().Changed("quality") && format != "jpeg" && !quiet {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: --quality only applies to JPEG output\n")
+	}
+	if convertImageOutput != "" && convertImageOutDir != "" {
+		return fmt.Errorf("cannot use both --output and --out-dir")
+	}
+
+	inputs, err := expandImageArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) > 1 && convertImageOutput != "" {
+		return fmt.Errorf("--output can only be used with a single input file")
+	}
+	outputPaths, err := convertImageOutputPaths(inputs, format)
+	if err != nil {
+		return err
+	}
+
+	// Build the chain: flatten for formats without alpha, or on request
+	chain := &processor.Chain{Format: format, JPEGQuality: convertImageQuality}
+	if convertImageBackground != "" || format == "jpeg" {
+		background := "white"
+		if convertImageBackground != "" {
+			background = convertImageBackground
+		}
+		bg, err := processor.ParseColor(background)
+		if err != nil {
+			return err
+		}
+		chain.Steps = append(chain.Steps, processor.FlattenStep{Background: bg})
+	}
+
+	if convertImageOutDir != "" {
+		if err := os.MkdirAll(convertImageOutDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	successCount := 0
+	failCount := 0
+
+	for i, inputPath := range inputs {
+		outputPath := outputPaths[i]
+
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Converting: %s -> %s\n", inputPath, outputPath)
+		}
+
+		if err := chain.ProcessFile(inputPath, outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting %s: %v\n", inputPath, err)
+			failCount++
+			continue
+		}
+
+		successCount++
+		if !quiet {
+			if info, err := os.Stat(outputPath); err == nil {
+				fmt.Fprintf(os.Stderr, "✓ Converted: %s -> %s (%.2f KB)\n", inputPath, outputPath, float64(info.Size())/1024)
+			} else {
+				fmt.Fprintf(os.Stderr, "✓ Converted: %s -> %s\n", inputPath, outputPath)
+			}
+		}
+	}
+
+	// Summary
+	if !quiet && len(inputs) > 1 {
+		fmt.Fprintf(os.Stderr, "\nConversion complete: %d succeeded, %d failed\n", successCount, failCount)
+	}
+
+	if failCount > 0 {
+		return fmt.Errorf("failed to convert %d image(s)", failCount)
+	}
+
+	return nil
+}
+
+// convertImageOutputPath returns where an input file is converted to
+func convertImageOutputPath(inputPath, format string) string {
+	if convertImageOutput != "" {
+		return convertImageOutput
+	}
+	outputPath := processor.ReplaceExtension(inputPath, format)
+	if convertImageOutDir != "" {
+		outputPath = filepath.Join(convertImageOutDir, filepath.Base(outputPath))
+	}
+	return outputPath
+}
+
+// convertImageOutputPaths returns the output path of every input, refusing to
+// overwrite an input without --output or to write two inputs to one file
+func convertImageOutputPaths(inputs []string, format string) ([]string, error) {
+	outputPaths := make([]string, len(inputs))
+	sources := make(map[string]string, len(inputs))
+	for i, inputPath := range inputs {
+		outputPath := convertImageOutputPath(inputPath, format)
+		if convertImageOutput == "" && filepath.Clean(outputPath) == filepath.Clean(inputPath) {
+			return nil, fmt.Errorf("%s is already %s and would be overwritten; use --output or --out-dir", inputPath, format)
+		}
+		if other, ok := sources[filepath.Clean(outputPath)]; ok {
+			return nil, fmt.Errorf("%s and %s would both be converted to %s", other, inputPath, outputPath)
+		}
+		sources[filepath.Clean(outputPath)] = inputPath
+		outputPaths[i] = outputPath
+	}
+	return outputPaths, nil
+}
+
+// expandImageArgs expands glob patterns among the input arguments, keeping
+// plain paths as they are
+func expandImageArgs(args []string) ([]string, error) {
+	var inputs []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match '%s'", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
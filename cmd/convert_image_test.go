@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandImageArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "c.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "plain paths", args: []string{"x.png", "y.png"}, want: 2},
+		{name: "glob", args: []string{filepath.Join(dir, "*.png")}, want: 2},
+		{name: "glob and path", args: []string{filepath.Join(dir, "*.jpg"), "x.png"}, want: 2},
+		{name: "no matches", args: []string{filepath.Join(dir, "*.gif")}, wantErr: true},
+		{name: "bad pattern", args: []string{filepath.Join(dir, "[")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandImageArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandImageArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expandImageArgs() = %v, want %d paths", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertImageOutputPath(t *testing.T) {
+	defer func(output, outDir string) { convertImageOutput, convertImageOutDir = output, outDir }(convertImageOutput, convertImageOutDir)
+
+	tests := []struct {
+		name   string
+		output string
+		outDir string
+		input  string
+		format string
+		want   string
+	}{
+		{name: "next to input", input: "renders/hero.png", format: "jpeg", want: "renders/hero.jpg"},
+		{name: "out dir", outDir: "web", input: "renders/hero.png", format: "webp", want: filepath.Join("web", "hero.webp")},
+		{name: "explicit output", output: "site/hero.webp", input: "hero.png", format: "webp", want: "site/hero.webp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertImageOutput, convertImageOutDir = tt.output, tt.outDir
+			if got := convertImageOutputPath(tt.input, tt.format); got != tt.want {
+				t.Errorf("convertImageOutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertImageOutputPaths(t *testing.T) {
+	defer func(output, outDir string) { convertImageOutput, convertImageOutDir = output, outDir }(convertImageOutput, convertImageOutDir)
+
+	tests := []struct {
+		name    string
+		output  string
+		outDir  string
+		inputs  []string
+		format  string
+		want    []string
+		wantErr string
+	}{
+		{name: "next to inputs", inputs: []string{"a/x.png", "b/x.png"}, format: "webp", want: []string{"a/x.webp", "b/x.webp"}},
+		{name: "same format in place", inputs: []string{"a/x.png"}, format: "png", wantErr: "would be overwritten"},
+		{name: "same format out dir", outDir: "web", inputs: []string{"a/x.png"}, format: "png", want: []string{filepath.Join("web", "x.png")}},
+		{name: "same format explicit output", output: "a/x.png", inputs: []string{"a/x.png"}, format: "png", want: []string{"a/x.png"}},
+		{name: "shared basename out dir", outDir: "web", inputs: []string{"a/x.png", "b/x.png"}, format: "webp", wantErr: "would both be converted"},
+		{name: "different extensions", inputs: []string{"x.png", "x.bmp"}, format: "jpeg", wantErr: "would both be converted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertImageOutput, convertImageOutDir = tt.output, tt.outDir
+			got, err := convertImageOutputPaths(tt.inputs, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("convertImageOutputPaths() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("convertImageOutputPaths() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertImageOutputPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
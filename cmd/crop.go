@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"image/color"
 	"os"
 	"path/filepath"
 
@@ -10,10 +11,15 @@ import (
 )
 
 var (
+	cropMode           string
+	cropColor          string
+	cropPadding        int
+	cropPaddingPercent float64
 	cropThreshold      int
 	cropTolerance      int
 	cropPreserveAspect bool
 	cropQuality        int
+	cropFormat         string
 	cropOutput         string
 	cropInPlace        bool
 )
@@ -49,12 +55,37 @@ Examples:
   # Batch crop multiple images
   asset-generator crop *.jpg --in-place
 
+  # Crop and convert to WebP (replaces image.png with image.webp)
+  asset-generator crop image.png --format webp
+
   # Adjust sensitivity for darker backgrounds
   asset-generator crop image.png --threshold 200 --tolerance 20
 
   # More aggressive whitespace detection
   asset-generator crop image.png --threshold 240 --tolerance 5
 
+  # Crop a dark or colored background, sampled from the corners
+  asset-generator crop render.png --mode auto --tolerance 20
+
+  # Crop transparent borders only, keeping a 16px margin
+  asset-generator crop sprite.png --mode alpha --padding 16
+
+Background Modes:
+  white  Near-white borders, set by --threshold and --tolerance (default)
+  auto   Borders of the color sampled from the image corners
+  color  Borders of the --color color
+  alpha  Transparent borders only, whatever their color
+
+  In auto and color modes --tolerance is the largest per-channel difference
+  from the background color; in alpha mode it is the largest alpha that
+  still counts as transparent. Transparent pixels are background in every
+  mode.
+
+Padding:
+  --padding keeps a margin of N pixels around the content, and
+  --padding-percent a margin of a percentage of the larger content side.
+  The margin never extends beyond the original image.
+
 Whitespace Detection:
   The tool identifies pixels as "whitespace" when all RGB values are above
   (threshold - tolerance). Default settings (threshold=250, tolerance=10)
@@ -68,7 +99,13 @@ Whitespace Detection:
 Aspect Ratio Preservation:
   When --preserve-aspect is enabled, the crop bounds are expanded (if needed)
   to match the original image's aspect ratio. This ensures the cropped image
-  maintains the same width:height proportions as the original.`,
+  maintains the same width:height proportions as the original.
+
+Output Format:
+  Images are written in the format of the output file's extension (png, jpg,
+  webp, gif, bmp, tif/tiff), else in the input format. --format converts to
+  the given format and changes the output extension to match; in place (the
+  default for a single file) the original is replaced by the converted file.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runCrop,
 }
@@ -76,10 +113,15 @@ Aspect Ratio Preservation:
 func init() {
 	rootCmd.AddCommand(cropCmd)
 
+	cropCmd.Flags().StringVar(&cropMode, "mode", "white", "background to crop: white, auto (corner color), color, alpha")
+	cropCmd.Flags().StringVar(&cropColor, "color", "", "background color for --mode color, as a name or #rrggbb")
+	cropCmd.Flags().IntVar(&cropPadding, "padding", 0, "margin in pixels to keep around the content")
+	cropCmd.Flags().Float64Var(&cropPaddingPercent, "padding-percent", 0, "margin to keep, as a percentage of the larger content side")
 	cropCmd.Flags().IntVar(&cropThreshold, "threshold", 250, "whitespace detection threshold (0-255)")
 	cropCmd.Flags().IntVar(&cropTolerance, "tolerance", 10, "tolerance for near-white colors (0-255)")
 	cropCmd.Flags().BoolVar(&cropPreserveAspect, "preserve-aspect", false, "preserve original aspect ratio")
 	cropCmd.Flags().IntVar(&cropQuality, "quality", 90, "JPEG quality (1-100)")
+	cropCmd.Flags().StringVar(&cropFormat, "format", "", "output format: png, jpeg, webp, gif, bmp, tiff (default: from the output extension)")
 	cropCmd.Flags().StringVarP(&cropOutput, "output", "o", "", "output file path (single file mode only)")
 	cropCmd.Flags().BoolVarP(&cropInPlace, "in-place", "i", false, "replace original file(s) with cropped version")
 }
@@ -95,6 +137,21 @@ func runCrop(cmd *cobra.Command, args []string) error {
 	if cropQuality < 1 || cropQuality > 100 {
 		return fmt.Errorf("quality must be between 1 and 100")
 	}
+	mode, bgColor, err := parseCropMode(cropMode, cropColor)
+	if err != nil {
+		return err
+	}
+	if cropPadding < 0 || cropPaddingPercent < 0 {
+		return fmt.Errorf("padding cannot be negative")
+	}
+
+	if cropFormat != "" {
+		format, err := processor.ParseFormat(cropFormat)
+		if err != nil {
+			return err
+		}
+		cropFormat = format
+	}
 
 	// Check for conflicting options
 	if len(args) > 1 && cropOutput != "" {
@@ -107,9 +164,14 @@ func runCrop(cmd *cobra.Command, args []string) error {
 
 	// Build crop options
 	opts := processor.CropOptions{
+		Mode:                mode,
+		Color:               bgColor,
+		Padding:             cropPadding,
+		PaddingPercent:      cropPaddingPercent,
 		Threshold:           uint8(cropThreshold),
 		Tolerance:           uint8(cropTolerance),
 		JPEGQuality:         cropQuality,
+		Format:              cropFormat,
 		PreserveAspectRatio: cropPreserveAspect,
 	}
 
@@ -127,16 +189,25 @@ func runCrop(cmd *cobra.Command, args []string) error {
 		// Determine output path
 		var outputPath string
 		if cropInPlace || cropOutput == "" && len(args) == 1 {
-			// In-place mode
+			// In-place mode; converting the format replaces the original
+			// with a file of the new extension
+			outputPath = imagePath
+			if cropFormat != "" {
+				outputPath = processor.ReplaceExtension(imagePath, cropFormat)
+			}
 			if verbose {
 				fmt.Fprintf(os.Stderr, "Cropping: %s (in-place)\n", imagePath)
 			}
-			if err := processor.AutoCropInPlace(imagePath, opts); err != nil {
+			if err := processor.AutoCropImage(imagePath, outputPath, opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Error cropping %s: %v\n", imagePath, err)
 				failCount++
 				continue
 			}
-			outputPath = imagePath
+			if outputPath != imagePath {
+				if err := os.Remove(imagePath); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", imagePath, err)
+				}
+			}
 		} else {
 			// Output to different file
 			if cropOutput != "" {
@@ -145,6 +216,9 @@ func runCrop(cmd *cobra.Command, args []string) error {
 				// Generate output filename (add -cropped suffix)
 				ext := filepath.Ext(imagePath)
 				nameWithoutExt := imagePath[:len(imagePath)-len(ext)]
+				if cropFormat != "" {
+					ext = processor.Extension(cropFormat)
+				}
 				outputPath = fmt.Sprintf("%s-cropped%s", nameWithoutExt, ext)
 			}
 
@@ -182,3 +256,29 @@ func runCrop(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// parseCropMode parses a crop mode and the color it needs in color mode.
+// A color given without a mode selects color mode.
+func parseCropMode(mode, colorName string) (processor.CropMode, *color.NRGBA, error) {
+	cropMode, err := processor.ParseCropMode(mode)
+	if err != nil {
+		return "", nil, err
+	}
+	if colorName == "" {
+		if cropMode == processor.CropColor {
+			return "", nil, fmt.Errorf("--mode color needs a background color")
+		}
+		return cropMode, nil, nil
+	}
+	c, err := processor.ParseColor(colorName)
+	if err != nil {
+		return "", nil, err
+	}
+	switch cropMode {
+	case processor.CropWhite:
+		cropMode = processor.CropColor
+	case processor.CropAuto, processor.CropAlpha:
+		return "", nil, fmt.Errorf("a background color cannot be used with %s crop mode", cropMode)
+	}
+	return cropMode, &c, nil
+}
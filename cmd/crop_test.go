@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+)
+
+func TestParseCropMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		color     string
+		want      processor.CropMode
+		wantColor bool
+		wantErr   bool
+	}{
+		{name: "default", mode: "white", want: processor.CropWhite},
+		{name: "auto", mode: "auto", want: processor.CropAuto},
+		{name: "color implies color mode", mode: "white", color: "#101010", want: processor.CropColor, wantColor: true},
+		{name: "color mode", mode: "color", color: "black", want: processor.CropColor, wantColor: true},
+		{name: "color mode without color", mode: "color", wantErr: true},
+		{name: "color with auto mode", mode: "auto", color: "black", wantErr: true},
+		{name: "bad color", mode: "color", color: "chartreuse", wantErr: true},
+		{name: "bad mode", mode: "dark", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, c, err := parseCropMode(tt.mode, tt.color)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCropMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mode != tt.want {
+				t.Errorf("parseCropMode() mode = %q, want %q", mode, tt.want)
+			}
+			if (c != nil) != tt.wantColor {
+				t.Errorf("parseCropMode() color = %v, want color %v", c, tt.wantColor)
+			}
+		})
+	}
+}
@@ -15,7 +15,9 @@ var (
 	downscaleHeight     int
 	downscalePercentage float64
 	downscaleFilter     string
+	downscaleLinear     bool
 	downscaleQuality    int
+	downscaleFormat     string
 	downscaleOutput     string
 	downscaleInPlace    bool
 )
@@ -52,16 +54,25 @@ Examples:
   # Use different filter for speed
   asset-generator downscale large.png --width 512 --filter bilinear
 
+  # Downscale and convert to TIFF for print (writes photo_downscaled.tiff)
+  asset-generator downscale photo.png --percentage 50 --format tiff
+
 Filter Options:
   lanczos   - Highest quality, best for photographs (default)
   bilinear  - Good balance of speed and quality
   nearest   - Fastest, best for pixel art or icons
 
+Transparent pixels never darken the edges next to them. --linear blends in
+linear light rather than sRGB, which keeps thin highlights and outlines at
+their true brightness; it is slower.
+
 The command will:
   - Automatically maintain aspect ratio if only one dimension is specified
   - Prevent accidental upscaling (will error if target > source)
   - Create output directory if needed (when using --output)
-  - Preserve image format (PNG/JPEG)
+  - Write the format of the output extension (png, jpg, webp, gif, bmp,
+    tif/tiff), else the input format; --format converts to the given format
+    and changes the output extension to match
   - Use quality setting for JPEG output (default: 90)`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runDownscale,
@@ -74,7 +85,9 @@ func init() {
 	downscaleCmd.Flags().IntVarP(&downscaleHeight, "height", "l", 0, "target height in pixels (0=auto from width)")
 	downscaleCmd.Flags().Float64VarP(&downscalePercentage, "percentage", "p", 0, "scale by percentage (1-100, 0=use width/height instead)")
 	downscaleCmd.Flags().StringVar(&downscaleFilter, "filter", "lanczos", "resampling filter: lanczos, bilinear, nearest")
+	downscaleCmd.Flags().BoolVar(&downscaleLinear, "linear", false, "resample in linear light instead of sRGB (slower, truer brightness)")
 	downscaleCmd.Flags().IntVar(&downscaleQuality, "quality", 90, "JPEG quality (1-100)")
+	downscaleCmd.Flags().StringVar(&downscaleFormat, "format", "", "output format: png, jpeg, webp, gif, bmp, tiff (default: from the output extension)")
 	downscaleCmd.Flags().StringVar(&downscaleOutput, "output-file", "", "output file path (single file mode only)")
 	downscaleCmd.Flags().BoolVar(&downscaleInPlace, "in-place", false, "replace original file(s) with downscaled version")
 }
@@ -119,6 +132,15 @@ func runDownscale(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid filter '%s' (valid options: %s)", downscaleFilter, strings.Join(validFilters, ", "))
 	}
 
+	// Validate output format
+	if downscaleFormat != "" {
+		format, err := processor.ParseFormat(downscaleFormat)
+		if err != nil {
+			return err
+		}
+		downscaleFormat = format
+	}
+
 	// Validate output flag usage
 	if downscaleOutput != "" && len(args) > 1 {
 		return fmt.Errorf("--output can only be used with a single input file")
@@ -145,7 +167,9 @@ func runDownscale(cmd *cobra.Command, args []string) error {
 		Height:      downscaleHeight,
 		Percentage:  downscalePercentage,
 		Filter:      filterType,
+		Linear:      downscaleLinear,
 		JPEGQuality: downscaleQuality,
+		Format:      downscaleFormat,
 	}
 
 	// Process each input file
@@ -165,13 +189,21 @@ func runDownscale(cmd *cobra.Command, args []string) error {
 		// Determine output path
 		var outputPath string
 		if downscaleInPlace {
+			// Converting the format replaces the original with a file of
+			// the new extension
 			outputPath = inputPath
+			if downscaleFormat != "" {
+				outputPath = processor.ReplaceExtension(inputPath, downscaleFormat)
+			}
 		} else if downscaleOutput != "" {
 			outputPath = downscaleOutput
 		} else {
 			// Generate default output path: input_downscaled.ext
 			ext := filepath.Ext(inputPath)
 			nameWithoutExt := strings.TrimSuffix(inputPath, ext)
+			if downscaleFormat != "" {
+				ext = processor.Extension(downscaleFormat)
+			}
 			outputPath = fmt.Sprintf("%s_downscaled%s", nameWithoutExt, ext)
 		}
 
@@ -186,12 +218,7 @@ func runDownscale(cmd *cobra.Command, args []string) error {
 		}
 
 		// Perform downscaling
-		var err error
-		if downscaleInPlace {
-			err = processor.DownscaleInPlace(inputPath, opts)
-		} else {
-			err = processor.DownscaleImage(inputPath, outputPath, opts)
-		}
+		err := processor.DownscaleImage(inputPath, outputPath, opts)
 
 		if err != nil {
 			if !quiet {
@@ -201,9 +228,15 @@ func runDownscale(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
+		if downscaleInPlace && outputPath != inputPath {
+			if err := os.Remove(inputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", inputPath, err)
+			}
+		}
+
 		processedCount++
 		if !quiet {
-			if downscaleInPlace {
+			if downscaleInPlace && outputPath == inputPath {
 				fmt.Fprintf(os.Stderr, "✓ Downscaled: %s\n", inputPath)
 			} else {
 				fmt.Fprintf(os.Stderr, "✓ Downscaled: %s -> %s\n", inputPath, outputPath)
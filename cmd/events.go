@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// Events written with --events, in the order they occur for an asset
+const (
+	eventSessionStarted = "session_started" // Generation submitted to a server
+	eventProgress       = "progress"        // Progress reported while generating
+	eventImageReady     = "image_ready"     // Server finished generating
+	eventDownloaded     = "downloaded"      // Image saved locally
+	eventPostprocessed  = "postprocessed"   // Image cropped or downscaled
+	eventAssetFailed    = "asset_failed"    // Asset or request failed
+	eventPipelineDone   = "pipeline_done"   // Pipeline or batch run finished
+)
+
+var (
+	eventsFormat string
+	eventsFile   string
+
+	// events receives the events of the running command; nil unless --events is set
+	events *eventStream
+)
+
+// addEventFlags adds the event stream flags to a command
+func addEventFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&eventsFormat, "events", "", "emit machine-readable events: ndjson")
+	cmd.Flags().StringVar(&eventsFile, "events-file", "", "write events to a file instead of stdout (implies --events ndjson)")
+}
+
+// event is one line of the event stream. Fields that don't apply to an event
+// are left out.
+type event struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	ID         string    `json:"id,omitempty"` // Asset ID path or batch request ID
+	Name       string    `json:"name,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Server     string    `json:"server,omitempty"`
+	Seed       *int64    `json:"seed,omitempty"`
+	Images     []string  `json:"images,omitempty"` // Image paths on the server
+	Path       string    `json:"path,omitempty"`   // Local file
+	Operations []string  `json:"operations,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ElapsedMS  int64     `json:"elapsed_ms,omitempty"` // Since the asset started
+
+	*progressPayload
+	*totalsPayload
+}
+
+// progressPayload is the payload of progress events
+type progressPayload struct {
+	Progress float64 `json:"progress"`
+	Phase    string  `json:"phase,omitempty"`
+	Step     int     `json:"step,omitempty"`
+	Steps    int     `json:"steps,omitempty"`
+}
+
+// totalsPayload is the payload of pipeline_done events
+type totalsPayload struct {
+	Total      int   `json:"total"`
+	Completed  int   `json:"completed"`
+	Failed     int   `json:"failed"`
+	Skipped    int   `json:"skipped"` // Kept selections, and requests already completed or cancelled before starting
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// eventStream writes events as newline-delimited JSON
+type eventStream struct {
+	mu     sync.Mutex
+	out    io.Writer
+	file   *os.File // Set when writing to --events-file
+	broken bool     // A write failed; the stream is abandoned
+}
+
+// openEventStream returns the stream selected by the flags, or nil when
+// events are disabled
+func openEventStream() (*eventStream, error) {
+	if eventsFormat == "" && eventsFile != "" {
+		eventsFormat = "ndjson"
+	}
+	switch eventsFormat {
+	case "":
+		return nil, nil
+	case "ndjson":
+	default:
+		return nil, fmt.Errorf("unsupported events format %q (supported: ndjson)", eventsFormat)
+	}
+
+	if eventsFile == "" || eventsFile == "-" {
+		return &eventStream{out: os.Stdout}, nil
+	}
+	file, err := os.Create(eventsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events file: %w", err)
+	}
+	return &eventStream{out: file, file: file}, nil
+}
+
+// Stdout reports whether events are written to stdout, which then carries
+// nothing else
+func (s *eventStream) Stdout() bool {
+	return s != nil && s.file == nil
+}
+
+// Close closes the events file
+func (s *eventStream) Close() error {
+	if s == nil || s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// Emit writes an event, stamping its time
+func (s *eventStream) Emit(e event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken {
+		return
+	}
+	if _, err := s.out.Write(append(line, '\n')); err != nil {
+		s.broken = true
+		fmt.Fprintf(stderr, "⚠ Warning: Failed to write events: %v\n", err)
+	}
+}
+
+// Done emits the pipeline_done event of a run
+func (s *eventStream) Done(totals totalsPayload, start time.Time, err error) {
+	if s == nil {
+		return
+	}
+	totals.DurationMS = time.Since(start).Milliseconds()
+	e := event{Event: eventPipelineDone, totalsPayload: &totals}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.Emit(e)
+}
+
+// Asset returns the events of one asset or request. Its methods do nothing
+// when events are disabled.
+func (s *eventStream) Asset(id, name string) *assetEvents {
+	if s == nil {
+		return nil
+	}
+	return &assetEvents{stream: s, id: id, name: name, start: time.Now()}
+}
+
+// assetEvents emits the events of one asset, adding its ID, server, session
+// and elapsed time to each
+type assetEvents struct {
+	stream *eventStream
+	id     string
+	name   string
+	start  time.Time
+
+	mu        sync.Mutex
+	server    string
+	sessionID string
+	update    client.ProgressUpdate // Latest detailed progress
+}
+
+// emit fills in the asset's fields and writes the event
+func (a *assetEvents) emit(e event) {
+	a.mu.Lock()
+	e.ID, e.Name, e.Server, e.SessionID = a.id, a.name, a.server, a.sessionID
+	a.mu.Unlock()
+	e.ElapsedMS = time.Since(a.start).Milliseconds()
+	a.stream.Emit(e)
+}
+
+// Attach emits the session and progress events of a request. It keeps the
+// callbacks already set, so it goes after a progress task's Attach.
+func (a *assetEvents) Attach(req *client.GenerationRequest) {
+	if a == nil {
+		return
+	}
+	seed := requestSeed(req)
+
+	previousSession := req.SessionCallback
+	req.SessionCallback = func(sessionID string) {
+		a.mu.Lock()
+		a.sessionID = sessionID
+		a.update = client.ProgressUpdate{}
+		a.mu.Unlock()
+		a.emit(event{Event: eventSessionStarted, Seed: seed})
+		if previousSession != nil {
+			previousSession(sessionID)
+		}
+	}
+
+	// Detailed updates come before the matching ProgressCallback, which all
+	// servers send, so progress is emitted once per report
+	previousUpdate := req.ProgressUpdateCallback
+	req.ProgressUpdateCallback = func(update client.ProgressUpdate) {
+		a.mu.Lock()
+		a.update = update
+		a.mu.Unlock()
+		if previousUpdate != nil {
+			previousUpdate(update)
+		}
+	}
+
+	previousProgress := req.ProgressCallback
+	req.ProgressCallback = func(progress float64, status string) {
+		a.mu.Lock()
+		payload := &progressPayload{Progress: progress, Phase: a.update.Phase, Step: a.update.Step, Steps: a.update.Steps}
+		a.mu.Unlock()
+		if payload.Phase == "" && progress > 0 && progress < 1 {
+			payload.Phase = client.PhaseGenerating
+		}
+		a.emit(event{Event: eventProgress, progressPayload: payload})
+		if previousProgress != nil {
+			previousProgress(progress, status)
+		}
+	}
+}
+
+// requestSeed returns the seed a request sets, if any
+func requestSeed(req *client.GenerationRequest) *int64 {
+	var seed int64
+	switch s := req.Parameters["seed"].(type) {
+	case int64:
+		seed = s
+	case int:
+		seed = int64(s)
+	default:
+		return nil
+	}
+	return &seed
+}
+
+// SetServer records the server generating the asset
+func (a *assetEvents) SetServer(server string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.server = server
+}
+
+// ImageReady emits the paths of the generated images on the server
+func (a *assetEvents) ImageReady(images []string) {
+	if a == nil {
+		return
+	}
+	a.emit(event{Event: eventImageReady, Images: images})
+}
+
+// AttachDownload emits the downloaded and postprocessed events of a download,
+// keeping the callbacks already set
+func (a *assetEvents) AttachDownload(opts *client.DownloadOptions) {
+	if a == nil {
+		return
+	}
+	previousDownloaded := opts.DownloadedCallback
+	opts.DownloadedCallback = func(path string) {
+		a.emit(event{Event: eventDownloaded, Path: path})
+		if previousDownloaded != nil {
+			previousDownloaded(path)
+		}
+	}
+	previousPostprocessed := opts.PostprocessedCallback
+	opts.PostprocessedCallback = func(path string, operations []string) {
+		a.emit(event{Event: eventPostprocessed, Path: path, Operations: operations})
+		if previousPostprocessed != nil {
+			previousPostprocessed(path, operations)
+		}
+	}
+}
+
+// Failed emits the asset_failed event
+func (a *assetEvents) Failed(err error) {
+	if a == nil {
+		return
+	}
+	a.emit(event{Event: eventAssetFailed, Error: err.Error()})
+}
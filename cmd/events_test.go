@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+)
+
+func TestAssetEvents(t *testing.T) {
+	var out bytes.Buffer
+	stream := &eventStream{out: &out}
+
+	req := &client.GenerationRequest{Parameters: map[string]interface{}{"seed": int64(42)}}
+	ev := stream.Asset("characters/hero", "Hero")
+	ev.Attach(req)
+	ev.SetServer("http://gpu1:7801")
+
+	// What the client calls during a generation, in order
+	req.SessionCallback("session-1")
+	req.ProgressCallback(0, "Starting generation...")
+	req.ProgressUpdateCallback(client.ProgressUpdate{Phase: client.PhaseGenerating, Progress: 0.5, Step: 10, Steps: 20})
+	req.ProgressCallback(0.5, "Generating... (step 10/20)")
+	ev.ImageReady([]string{"View/local/raw/hero.png"})
+
+	opts := &client.DownloadOptions{}
+	ev.AttachDownload(opts)
+	opts.DownloadedCallback("out/hero.png")
+	opts.PostprocessedCallback("out/hero.png", []string{"auto_crop"})
+	ev.Failed(errors.New("hook failed"))
+	stream.Done(totalsPayload{Total: 3, Completed: 1, Failed: 1, Skipped: 1}, time.Now(), nil)
+
+	var got []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var e map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line is not JSON: %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+
+	wantEvents := []string{eventSessionStarted, eventProgress, eventProgress, eventImageReady, eventDownloaded, eventPostprocessed, eventAssetFailed, eventPipelineDone}
+	if len(got) != len(wantEvents) {
+		t.Fatalf("got %d events, want %d: %v", len(got), len(wantEvents), got)
+	}
+	for i, want := range wantEvents {
+		if got[i]["event"] != want {
+			t.Errorf("event %d = %v, want %s", i, got[i]["event"], want)
+		}
+		if _, ok := got[i]["time"]; !ok {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+
+	// Asset events carry the asset's ID, server and session
+	for _, e := range got[:7] {
+		if e["id"] != "characters/hero" || e["server"] != "http://gpu1:7801" || e["session_id"] != "session-1" {
+			t.Errorf("%v event is missing asset fields: %v", e["event"], e)
+		}
+	}
+
+	checks := []struct {
+		event int
+		field string
+		want  interface{}
+	}{
+		{0, "seed", 42.0},
+		{1, "progress", 0.0},
+		{2, "progress", 0.5},
+		{2, "phase", client.PhaseGenerating},
+		{2, "step", 10.0},
+		{2, "steps", 20.0},
+		{4, "path", "out/hero.png"},
+		{6, "error", "hook failed"},
+		{7, "total", 3.0},
+		{7, "completed", 1.0},
+		{7, "failed", 1.0},
+		{7, "skipped", 1.0},
+	}
+	for _, c := range checks {
+		if got[c.event][c.field] != c.want {
+			t.Errorf("%v event: %s = %v, want %v", got[c.event]["event"], c.field, got[c.event][c.field], c.want)
+		}
+	}
+	if _, ok := got[3]["progress"]; ok {
+		t.Error("image_ready event has progress fields")
+	}
+	if _, ok := got[7]["id"]; ok {
+		t.Error("pipeline_done event has asset fields")
+	}
+}
+
+func TestOpenEventStream(t *testing.T) {
+	defer func(format, file string) { eventsFormat, eventsFile = format, file }(eventsFormat, eventsFile)
+
+	tests := []struct {
+		name       string
+		format     string
+		file       string
+		wantStream bool
+		wantStdout bool
+		wantErr    bool
+	}{
+		{name: "disabled"},
+		{name: "stdout", format: "ndjson", wantStream: true, wantStdout: true},
+		{name: "file", format: "ndjson", file: "events.ndjson", wantStream: true},
+		{name: "file implies ndjson", file: "events.ndjson", wantStream: true},
+		{name: "unknown format", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventsFormat, eventsFile = tt.format, tt.file
+			if tt.file != "" {
+				eventsFile = filepath.Join(t.TempDir(), tt.file)
+			}
+
+			stream, err := openEventStream()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openEventStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			defer stream.Close()
+			if (stream != nil) != tt.wantStream {
+				t.Errorf("openEventStream() = %v, want a stream: %v", stream, tt.wantStream)
+			}
+			if stream.Stdout() != tt.wantStdout {
+				t.Errorf("Stdout() = %v, want %v", stream.Stdout(), tt.wantStdout)
+			}
+		})
+	}
+}
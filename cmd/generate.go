@@ -12,6 +12,7 @@ import (
 
 	"github.com/opd-ai/asset-generator/pkg/client"
 	"github.com/opd-ai/asset-generator/pkg/output"
+	"github.com/opd-ai/asset-generator/pkg/processor"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -33,21 +34,42 @@ var (
 	generateOutputDir        string // Directory to save downloaded images
 	generateFilenameTemplate string // Template for custom filenames
 	generateStylePrefix      string // Prefix to prepend to all prompts
+	generatePreviewDir       string // Directory for step previews (implies WebSocket)
 	// SkimmedCFG (Distilled CFG) options
 	generateSkimmedCFG      bool    // Enable Skimmed CFG for improved quality/speed
 	generateSkimmedCFGScale float64 // Skimmed CFG scale value
 	generateSkimmedCFGStart float64 // Start percentage for Skimmed CFG (0-1)
 	generateSkimmedCFGEnd   float64 // End percentage for Skimmed CFG (0-1)
+	// Background removal postprocessing options
+	generateRemoveBackground          bool   // Make flat backgrounds transparent
+	generateRemoveBackgroundMode      string // Background detection (flood, key)
+	generateRemoveBackgroundColor     string // Background color (default: sampled)
+	generateRemoveBackgroundTolerance int    // Largest difference from the background color
+	generateRemoveBackgroundFeather   int    // Soft edge width
 	// Auto-crop postprocessing options
-	generateAutoCrop               bool // Enable auto-crop to remove whitespace
-	generateAutoCropThreshold      int  // Whitespace detection threshold (0-255)
-	generateAutoCropTolerance      int  // Tolerance for near-white colors (0-255)
-	generateAutoCropPreserveAspect bool // Preserve aspect ratio when cropping
+	generateAutoCrop               bool    // Enable auto-crop to remove whitespace
+	generateAutoCropThreshold      int     // Whitespace detection threshold (0-255)
+	generateAutoCropTolerance      int     // Tolerance for near-white colors (0-255)
+	generateAutoCropPreserveAspect bool    // Preserve aspect ratio when cropping
+	generateAutoCropMode           string  // Background to crop (white, auto, color, alpha)
+	generateAutoCropColor          string  // Background color for color mode
+	generateAutoCropPadding        int     // Margin in pixels kept around the content
+	generateAutoCropPaddingPercent float64 // Margin as a percentage of the larger content side
+	// Pad postprocessing options
+	generatePadWidth      int    // Minimum canvas width
+	generatePadHeight     int    // Minimum canvas height
+	generatePadAspect     string // Canvas aspect ratio
+	generatePadMargin     int    // Margin added on every side
+	generatePadAnchor     string // Where the image is placed
+	generatePadFill       string // Fill of the added area (color, edge, mirror)
+	generatePadBackground string // Fill color
 	// Downscale postprocessing options
 	generateDownscaleWidth      int     // Target width for postprocessing downscale
 	generateDownscaleHeight     int     // Target height for postprocessing downscale
 	generateDownscalePercentage float64 // Scale by percentage
 	generateDownscaleFilter     string  // Downscaling algorithm (lanczos, bilinear, nearest)
+	generateDownscaleLinear     bool    // Resample in linear light
+	generateImageFormat         string  // Format to save images in (png, jpeg, webp, gif, bmp, tiff)
 	// LoRA (Low-Rank Adaptation) options
 	generateLoras       []string  // LoRA models to apply (format: "name" or "name:weight")
 	generateLoraWeights []float64 // Explicit weights for LoRAs (alternative to inline format)
@@ -119,6 +141,10 @@ Examples:
     --save-images --downscale-width 1024 \
     --downscale-filter lanczos
   
+  # Save images as WebP
+  asset-generator generate image \
+    --prompt "game icon" --save-images --image-format webp
+  
   # Use Skimmed CFG for improved quality and faster generation
   asset-generator generate image \
     --prompt "detailed portrait" \
@@ -134,6 +160,11 @@ Examples:
     --prompt "cyberpunk cityscape" \
     --lora "cyberpunk-lora" --lora "neon-lights:1.2" --lora "futuristic:0.5"
   
+  # Watch step previews to stop a bad generation early
+  asset-generator generate image \
+    --prompt "dragon over a castle" \
+    --preview-dir ./previews
+  
   # Skimmed CFG with custom range (apply only during middle of generation)
   asset-generator generate image \
     --prompt "landscape painting" \
@@ -200,18 +231,38 @@ func init() {
 	generateImageCmd.Flags().StringVar(&generateScheduler, "scheduler", "simple", "scheduler/noise schedule (simple, normal, karras, exponential, sgm_uniform)")
 	generateImageCmd.Flags().BoolVar(&generateUseWebSocket, "websocket", false, "use WebSocket for real-time progress (requires SwarmUI)")
 	generateImageCmd.Flags().BoolVar(&generateSaveImages, "save-images", false, "download and save generated images to local disk")
+	generateImageCmd.Flags().StringVar(&generatePreviewDir, "preview-dir", "", "write step previews and a GIF of the denoise progression to this directory (implies --websocket)")
+	addEventFlags(generateImageCmd)
 	generateImageCmd.Flags().StringVar(&generateOutputDir, "output-dir", ".", "directory to save downloaded images (default: current directory)")
 	generateImageCmd.Flags().StringVar(&generateFilenameTemplate, "filename-template", "", "template for custom filenames (e.g., 'image-{index}-{seed}.png')")
 	// Auto-crop postprocessing flags
+	generateImageCmd.Flags().BoolVar(&generateRemoveBackground, "remove-background", false, "make flat image backgrounds transparent (saves PNG)")
+	generateImageCmd.Flags().StringVar(&generateRemoveBackgroundMode, "remove-background-mode", "flood", "background detection: flood (from the borders), key (everywhere)")
+	generateImageCmd.Flags().StringVar(&generateRemoveBackgroundColor, "remove-background-color", "", "background color, as a name or #rrggbb (default: sampled from the corners)")
+	generateImageCmd.Flags().IntVar(&generateRemoveBackgroundTolerance, "remove-background-tolerance", 10, "largest per-channel difference from the background color (0-255)")
+	generateImageCmd.Flags().IntVar(&generateRemoveBackgroundFeather, "remove-background-feather", 0, "soft edge width beyond the tolerance (0-255)")
 	generateImageCmd.Flags().BoolVar(&generateAutoCrop, "auto-crop", false, "automatically crop whitespace borders from images")
 	generateImageCmd.Flags().IntVar(&generateAutoCropThreshold, "auto-crop-threshold", 250, "whitespace detection threshold (0-255, higher = more aggressive)")
 	generateImageCmd.Flags().IntVar(&generateAutoCropTolerance, "auto-crop-tolerance", 10, "tolerance for near-white colors (0-255)")
 	generateImageCmd.Flags().BoolVar(&generateAutoCropPreserveAspect, "auto-crop-preserve-aspect", false, "preserve original aspect ratio when auto-cropping")
+	generateImageCmd.Flags().StringVar(&generateAutoCropMode, "auto-crop-mode", "white", "background to crop: white, auto (corner color), color, alpha")
+	generateImageCmd.Flags().StringVar(&generateAutoCropColor, "auto-crop-color", "", "background color to crop, as a name or #rrggbb")
+	generateImageCmd.Flags().IntVar(&generateAutoCropPadding, "auto-crop-padding", 0, "margin in pixels to keep around the content")
+	generateImageCmd.Flags().Float64Var(&generateAutoCropPaddingPercent, "auto-crop-padding-percent", 0, "margin to keep, as a percentage of the larger content side")
 	// Downscale postprocessing flags
+	generateImageCmd.Flags().IntVar(&generatePadWidth, "pad-width", 0, "pad the canvas to at least this width")
+	generateImageCmd.Flags().IntVar(&generatePadHeight, "pad-height", 0, "pad the canvas to at least this height")
+	generateImageCmd.Flags().StringVar(&generatePadAspect, "pad-aspect", "", "pad the canvas to an aspect ratio, such as 1:1 or 16:9")
+	generateImageCmd.Flags().IntVar(&generatePadMargin, "pad-margin", 0, "margin in pixels to add on every side")
+	generateImageCmd.Flags().StringVar(&generatePadAnchor, "pad-anchor", "center", "where to place the image when padding: center, top, bottom-left, ...")
+	generateImageCmd.Flags().StringVar(&generatePadFill, "pad-fill", "color", "how to fill the padding: color, edge, mirror")
+	generateImageCmd.Flags().StringVar(&generatePadBackground, "pad-background", "transparent", "padding color, as a name or #rrggbb")
 	generateImageCmd.Flags().IntVar(&generateDownscaleWidth, "downscale-width", 0, "downscale images to this width after download (0=auto from height)")
 	generateImageCmd.Flags().IntVar(&generateDownscaleHeight, "downscale-height", 0, "downscale images to this height after download (0=auto from width)")
 	generateImageCmd.Flags().Float64Var(&generateDownscalePercentage, "downscale-percentage", 0, "downscale by percentage (1-100, 0=disabled, overrides width/height)")
 	generateImageCmd.Flags().StringVar(&generateDownscaleFilter, "downscale-filter", "lanczos", "downscaling algorithm: lanczos (best), bilinear, nearest")
+	generateImageCmd.Flags().BoolVar(&generateDownscaleLinear, "downscale-linear", false, "downscale in linear light instead of sRGB")
+	generateImageCmd.Flags().StringVar(&generateImageFormat, "image-format", "", "save images as png, jpeg, webp, gif, bmp or tiff (default: from the filename extension)")
 	// SkimmedCFG (Distilled CFG) flags - advanced sampling technique for improved quality/speed
 	generateImageCmd.Flags().BoolVar(&generateSkimmedCFG, "skimmed-cfg", false, "enable Skimmed CFG (Distilled CFG) for improved quality and speed")
 	generateImageCmd.Flags().Float64Var(&generateSkimmedCFGScale, "skimmed-cfg-scale", 3.0, "Skimmed CFG scale value (typically lower than standard CFG)")
@@ -268,6 +319,26 @@ func runGenerateImage(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("prompt is required")
 	}
 
+	// Validate the image format before generating anything
+	if generateImageFormat != "" {
+		if _, err := processor.ParseFormat(generateImageFormat); err != nil {
+			return err
+		}
+	}
+	if generateAutoCrop {
+		if _, _, err := parseCropMode(generateAutoCropMode, generateAutoCropColor); err != nil {
+			return err
+		}
+	}
+	if _, err := parsePadOptions(generatePadWidth, generatePadHeight, generatePadAspect, generatePadMargin, generatePadAnchor, generatePadFill, generatePadBackground); err != nil {
+		return err
+	}
+	if generateRemoveBackground {
+		if err := validateRemoveBackground(generateRemoveBackgroundMode, generateRemoveBackgroundColor, generateImageFormat); err != nil {
+			return err
+		}
+	}
+
 	// Apply style prefix to prompt if specified
 	finalPrompt := generatePrompt
 	if generateStylePrefix != "" {
@@ -360,22 +431,59 @@ func runGenerateImage(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Record step previews, which SwarmUI streams over the WebSocket API
+	var previews *previewRecorder
+	if generatePreviewDir != "" {
+		var err error
+		if previews, err = newPreviewRecorder(generatePreviewDir); err != nil {
+			return err
+		}
+		req.ProgressUpdateCallback = previews.Record
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Writing previews to %s (press Ctrl+C to stop a bad generation early)\n", generatePreviewDir)
+		}
+	}
+
 	// Execute generation with progress tracking
 	// Use WebSocket if flag is enabled, otherwise use HTTP
-	var result *client.GenerationResult
+	if (generateUseWebSocket || previews != nil) && verbose {
+		fmt.Fprintf(os.Stderr, "Using WebSocket for real-time progress updates\n")
+	}
 	var err error
-	if generateUseWebSocket {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Using WebSocket for real-time progress updates\n")
-		}
+	if events, err = openEventStream(); err != nil {
+		return err
+	}
+	defer func() {
+		events.Close()
+		events = nil
+	}()
+
+	display := newProgressDisplay()
+	task := display.Start(finalPrompt)
+	task.Attach(req)
+	ev := events.Asset("", "")
+	ev.Attach(req)
+	ev.SetServer(assetClient.BaseURL())
+
+	var result *client.GenerationResult
+	if generateUseWebSocket || previews != nil {
 		result, err = assetClient.GenerateImageWS(ctx, req)
 	} else {
 		result, err = assetClient.GenerateImage(ctx, req)
 	}
+	task.Done()
+	display.Stop()
+
+	// Write the GIF even when the generation was stopped early
+	if previews != nil {
+		finishPreviews(previews)
+	}
 
 	if err != nil {
+		ev.Failed(err)
 		return fmt.Errorf("generation failed: %w", err)
 	}
+	ev.ImageReady(result.ImagePaths)
 
 	// Download images if requested
 	if generateSaveImages {
@@ -400,22 +508,42 @@ func runGenerateImage(cmd *cobra.Command, args []string) error {
 			FilenameTemplate: generateFilenameTemplate,
 			Metadata:         templateMetadata,
 			// Auto-crop options
-			AutoCrop:               generateAutoCrop,
-			AutoCropThreshold:      uint8(generateAutoCropThreshold),
-			AutoCropTolerance:      uint8(generateAutoCropTolerance),
-			AutoCropPreserveAspect: generateAutoCropPreserveAspect,
+			RemoveBackground:          generateRemoveBackground,
+			RemoveBackgroundMode:      generateRemoveBackgroundMode,
+			RemoveBackgroundColor:     generateRemoveBackgroundColor,
+			RemoveBackgroundTolerance: uint8(generateRemoveBackgroundTolerance),
+			RemoveBackgroundFeather:   uint8(generateRemoveBackgroundFeather),
+			AutoCrop:                  generateAutoCrop,
+			AutoCropThreshold:         uint8(generateAutoCropThreshold),
+			AutoCropTolerance:         uint8(generateAutoCropTolerance),
+			AutoCropPreserveAspect:    generateAutoCropPreserveAspect,
+			AutoCropMode:              generateAutoCropMode,
+			AutoCropColor:             generateAutoCropColor,
+			AutoCropPadding:           generateAutoCropPadding,
+			AutoCropPaddingPercent:    generateAutoCropPaddingPercent,
 			// Downscale options
+			PadWidth:            generatePadWidth,
+			PadHeight:           generatePadHeight,
+			PadAspect:           generatePadAspect,
+			PadMargin:           generatePadMargin,
+			PadAnchor:           generatePadAnchor,
+			PadFill:             generatePadFill,
+			PadBackground:       generatePadBackground,
 			DownscaleWidth:      generateDownscaleWidth,
 			DownscaleHeight:     generateDownscaleHeight,
 			DownscalePercentage: generateDownscalePercentage,
 			DownscaleFilter:     generateDownscaleFilter,
+			DownscaleLinear:     generateDownscaleLinear,
+			Format:              generateImageFormat,
 		}
+		ev.AttachDownload(opts)
 
 		// Download images with options
 		var savedPaths []string
 		savedPaths, err = assetClient.DownloadImagesWithOptions(ctx, result.ImagePaths, opts)
 
 		if err != nil {
+			ev.Failed(err)
 			return fmt.Errorf("failed to download images: %w", err)
 		}
 
@@ -449,7 +577,8 @@ func runGenerateImage(cmd *cobra.Command, args []string) error {
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "Output saved to: %s\n", outputFile)
 		}
-	} else {
+	} else if !events.Stdout() {
+		// With events on stdout, the result is in the image_ready and downloaded events
 		fmt.Println(outputData)
 	}
 
@@ -574,6 +703,25 @@ func stringSimilarity(s1, s2 string) int {
 	return score
 }
 
+// finishPreviews writes the preview GIFs and reports them
+func finishPreviews(previews *previewRecorder) {
+	gifs, err := previews.Finish()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n", err)
+	}
+	if quiet {
+		return
+	}
+	if previews.count == 0 {
+		fmt.Fprintf(os.Stderr, "No previews received from the server\n")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✓ Wrote %d previews to %s\n", previews.count, previews.dir)
+	for _, path := range gifs {
+		fmt.Fprintf(os.Stderr, "  Progression: %s\n", path)
+	}
+}
+
 func setupSignalHandler(cancel context.CancelFunc) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
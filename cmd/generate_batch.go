@@ -0,0 +1,774 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	batchStatusCompleted = "completed"
+	batchStatusFailed    = "failed"
+)
+
+var (
+	batchInput       string
+	batchOutputDir   string
+	batchConcurrency int
+	batchResume      bool
+	batchServers     []string
+	// Defaults for fields a request leaves out
+	batchModel     string
+	batchSteps     int
+	batchWidth     int
+	batchHeight    int
+	batchCfgScale  float64
+	batchSampler   string
+	batchScheduler string
+	batchNegPrompt string
+)
+
+// generateBatchCmd represents the generate batch command
+var generateBatchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Generate images from a JSONL or CSV file of requests",
+	Long: `Generate one image per line of a JSONL or CSV request file.
+
+Each request may set the following fields; fields left out use the flag
+defaults below:
+
+  id        Identifier used in results and the default filename (default: line-N)
+  prompt    Generation prompt (required)
+  negative  Negative prompt (also accepted as negative_prompt)
+  seed      Seed (-1 or empty for a random seed, which is recorded in the results)
+  size      Image size as WIDTHxHEIGHT, or use width and height
+  steps     Number of inference steps
+  model     Model name
+  loras     LoRAs as "name:weight" entries separated by ';' (JSONL also accepts
+            a list of entries or an object of names to weights)
+  filename  Output filename relative to --output-dir (default: <id>.png)
+
+JSONL files hold one JSON object per line. CSV files need a header row naming
+the columns, in any order, which suits files exported from spreadsheets.
+
+Results are appended to <input>.results.jsonl next to the input (for example
+requests.csv.results.jsonl) as each request finishes, with the status, seed,
+files, server and any error. With --resume, requests that already completed
+with the same settings and whose files still exist are skipped, so an
+interrupted or partly failed batch can be continued.
+
+Requests run concurrently on the servers given with --server or listed under
+"servers:" in the config file.
+
+Examples:
+  # Generate every request of a JSONL file into ./renders
+  asset-generator generate batch --input requests.jsonl --output-dir ./renders
+
+  # CSV exported from a spreadsheet, with defaults for missing columns
+  asset-generator generate batch --input sheet.csv --steps 30 --width 1024 --height 1024
+
+  # Continue after an interruption, four requests at a time
+  asset-generator generate batch --input requests.jsonl --resume --concurrency 4
+
+Example JSONL line:
+  {"id": "hero", "prompt": "knight in armor", "seed": 42, "size": "768x1344", "loras": ["detail:0.6"], "filename": "chars/hero.png"}
+
+Example CSV:
+  id,prompt,negative,seed,size,model,loras,filename
+  hero,knight in armor,blurry,42,768x1344,flux1-dev,detail:0.6,chars/hero.png`,
+	RunE: runGenerateBatch,
+}
+
+func init() {
+	generateCmd.AddCommand(generateBatchCmd)
+
+	generateBatchCmd.Flags().StringVar(&batchInput, "input", "", "JSONL or CSV file of generation requests (required)")
+	generateBatchCmd.Flags().StringVar(&batchOutputDir, "output-dir", "", "directory for generated images (default: directory of the input file)")
+	generateBatchCmd.Flags().IntVar(&batchConcurrency, "concurrency", 0, "requests to run at once (0 = total concurrency of the servers)")
+	generateBatchCmd.Flags().BoolVar(&batchResume, "resume", false, "skip requests that already completed according to the results file")
+	generateBatchCmd.Flags().StringArrayVar(&batchServers, "server", []string{}, "generation server as URL[,weight=N][,concurrency=N] (repeatable; overrides servers in the config file)")
+
+	// Defaults for requests
+	generateBatchCmd.Flags().StringVar(&batchModel, "model", "", "model for requests without one")
+	generateBatchCmd.Flags().IntVar(&batchSteps, "steps", 20, "inference steps for requests without steps")
+	generateBatchCmd.Flags().IntVar(&batchWidth, "width", 512, "image width for requests without a size")
+	generateBatchCmd.Flags().IntVar(&batchHeight, "height", 512, "image height for requests without a size")
+	generateBatchCmd.Flags().Float64Var(&batchCfgScale, "cfg-scale", 7.5, "CFG scale (guidance)")
+	generateBatchCmd.Flags().StringVar(&batchSampler, "sampler", "euler_a", "sampling method")
+	generateBatchCmd.Flags().StringVar(&batchScheduler, "scheduler", "simple", "scheduler/noise schedule (simple, normal, karras, exponential, sgm_uniform)")
+	generateBatchCmd.Flags().StringVar(&batchNegPrompt, "negative-prompt", "", "negative prompt for requests without one")
+
+	addEventFlags(generateBatchCmd)
+
+	generateBatchCmd.MarkFlagRequired("input")
+}
+
+// batchRequest is one request of a batch file
+type batchRequest struct {
+	ID             string     `json:"id,omitempty"`
+	Prompt         string     `json:"prompt"`
+	Negative       string     `json:"negative,omitempty"`
+	NegativePrompt string     `json:"negative_prompt,omitempty"` // Alias of negative
+	Seed           *int64     `json:"seed,omitempty"`
+	Size           string     `json:"size,omitempty"` // WIDTHxHEIGHT
+	Width          int        `json:"width,omitempty"`
+	Height         int        `json:"height,omitempty"`
+	Steps          int        `json:"steps,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Loras          batchLoras `json:"loras,omitempty"`
+	Filename       string     `json:"filename,omitempty"`
+
+	line int // 1-based line (JSONL) or data row (CSV) in the input file
+}
+
+// batchLoras holds LoRAs as "name" or "name:weight" entries
+type batchLoras []string
+
+// UnmarshalJSON accepts "a:0.8;b", ["a:0.8", "b"] or {"a": 0.8, "b": 1}
+func (l *batchLoras) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*l = splitBatchLoras(text)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+
+	var weights map[string]float64
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return fmt.Errorf("loras must be a string, a list or an object of weights")
+	}
+	*l = nil
+	for name, weight := range weights {
+		*l = append(*l, name+":"+strconv.FormatFloat(weight, 'g', -1, 64))
+	}
+	sort.Strings(*l)
+	return nil
+}
+
+// splitBatchLoras splits a list of LoRAs separated by ';' or ','
+func splitBatchLoras(text string) batchLoras {
+	var loras batchLoras
+	for _, lora := range strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == ',' }) {
+		if lora = strings.TrimSpace(lora); lora != "" {
+			loras = append(loras, lora)
+		}
+	}
+	return loras
+}
+
+// Key identifies the request in results
+func (r batchRequest) Key() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return fmt.Sprintf("line-%d", r.line)
+}
+
+// resolvedBatchRequest is a request with defaults applied
+type resolvedBatchRequest struct {
+	batchRequest
+	Width      int
+	Height     int
+	Steps      int
+	Model      string
+	Negative   string
+	Loras      map[string]float64
+	OutputPath string
+	Hash       string // Fingerprint of everything but a random seed, for resume
+}
+
+// resolve applies the flag defaults to a request and validates it
+func (r batchRequest) resolve(outputDir string) (*resolvedBatchRequest, error) {
+	if strings.TrimSpace(r.Prompt) == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+
+	resolved := &resolvedBatchRequest{
+		batchRequest: r,
+		Width:        batchWidth,
+		Height:       batchHeight,
+		Steps:        batchSteps,
+		Model:        batchModel,
+		Negative:     batchNegPrompt,
+	}
+
+	if r.Size != "" {
+		w, h, ok := strings.Cut(strings.ToLower(r.Size), "x")
+		width, errW := strconv.Atoi(strings.TrimSpace(w))
+		height, errH := strconv.Atoi(strings.TrimSpace(h))
+		if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("invalid size '%s' (expected WIDTHxHEIGHT)", r.Size)
+		}
+		resolved.Width, resolved.Height = width, height
+	}
+	if r.Width > 0 {
+		resolved.Width = r.Width
+	}
+	if r.Height > 0 {
+		resolved.Height = r.Height
+	}
+	if r.Steps > 0 {
+		resolved.Steps = r.Steps
+	}
+	if r.Model != "" {
+		resolved.Model = r.Model
+	}
+	if r.NegativePrompt != "" {
+		resolved.Negative = r.NegativePrompt
+	}
+	if r.Negative != "" {
+		resolved.Negative = r.Negative
+	}
+
+	loras, err := parseLoraParameters(r.Loras, nil, "1.0")
+	if err != nil {
+		return nil, fmt.Errorf("invalid loras: %w", err)
+	}
+	resolved.Loras = loras
+
+	filename := r.Filename
+	if filename == "" {
+		filename = sanitizeFilename(r.Key()) + ".png"
+	}
+	if filepath.IsAbs(filename) || strings.HasPrefix(filepath.Clean(filename), "..") {
+		return nil, fmt.Errorf("filename '%s' must be relative to the output directory", r.Filename)
+	}
+	resolved.OutputPath = filepath.Join(outputDir, filename)
+
+	data, _ := json.Marshal(struct {
+		Prompt, Negative, Model, Output string
+		Width, Height, Steps            int
+		CfgScale                        float64
+		Sampler, Scheduler              string
+		Seed                            *int64
+		Loras                           map[string]float64
+	}{
+		r.Prompt, resolved.Negative, resolved.Model, filepath.ToSlash(resolved.OutputPath),
+		resolved.Width, resolved.Height, resolved.Steps,
+		batchCfgScale, batchSampler, batchScheduler,
+		r.fixedSeed(), loras,
+	})
+	sum := sha256.Sum256(data)
+	resolved.Hash = hex.EncodeToString(sum[:8])
+
+	return resolved, nil
+}
+
+// fixedSeed returns the requested seed, or nil for a random seed
+func (r batchRequest) fixedSeed() *int64 {
+	if r.Seed == nil || *r.Seed < 0 {
+		return nil
+	}
+	return r.Seed
+}
+
+// generationRequest builds the API request for a resolved request
+func (r *resolvedBatchRequest) generationRequest(seed int64) *client.GenerationRequest {
+	req := &client.GenerationRequest{
+		Prompt: r.Prompt,
+		Model:  r.Model,
+		Parameters: map[string]interface{}{
+			"steps":     r.Steps,
+			"width":     r.Width,
+			"height":    r.Height,
+			"cfgscale":  batchCfgScale,
+			"sampler":   batchSampler,
+			"scheduler": batchScheduler,
+			"seed":      seed,
+			"images":    1,
+		},
+	}
+	if r.Negative != "" {
+		req.Parameters["negative_prompt"] = r.Negative
+	}
+	if len(r.Loras) > 0 {
+		req.Parameters["loras"] = r.Loras
+	}
+	return req
+}
+
+// batchResult is a line of the results file
+type batchResult struct {
+	Line       int       `json:"line"`
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Prompt     string    `json:"prompt"`
+	Seed       int64     `json:"seed"`
+	Model      string    `json:"model,omitempty"`
+	Files      []string  `json:"files,omitempty"`
+	Server     string    `json:"server,omitempty"`
+	Hash       string    `json:"hash"`
+	DurationMS int64     `json:"duration_ms"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// readBatchRequests reads a JSONL or CSV request file, chosen by extension
+func readBatchRequests(path string) ([]batchRequest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input: %w", err)
+	}
+	defer file.Close()
+
+	var requests []batchRequest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		requests, err = parseBatchCSV(file)
+	case ".jsonl", ".ndjson":
+		requests, err = parseBatchJSONL(file)
+	default:
+		return nil, fmt.Errorf("unsupported input file '%s' (expected .jsonl, .ndjson or .csv)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]int)
+	for _, r := range requests {
+		if previous, ok := seen[r.Key()]; ok {
+			return nil, fmt.Errorf("line %d: id '%s' is already used on line %d", r.line, r.Key(), previous)
+		}
+		seen[r.Key()] = r.line
+	}
+
+	return requests, nil
+}
+
+// parseBatchJSONL parses one JSON request per line, skipping blank lines
+func parseBatchJSONL(r io.Reader) ([]batchRequest, error) {
+	var requests []batchRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var request batchRequest
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		request.line = line
+		requests = append(requests, request)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return requests, nil
+}
+
+// parseBatchCSV parses requests from CSV with a header row naming the columns
+func parseBatchCSV(r io.Reader) ([]batchRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))) // Spreadsheets may add a BOM
+		switch column {
+		case "id", "prompt", "negative", "negative_prompt", "seed", "size", "width", "height", "steps", "model", "loras", "filename":
+			header[i] = column
+		default:
+			return nil, fmt.Errorf("unknown CSV column '%s'", column)
+		}
+	}
+
+	var requests []batchRequest
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		request := batchRequest{line: row}
+		empty := true
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if i >= len(header) || value == "" {
+				continue
+			}
+			empty = false
+			if err := request.setField(header[i], value); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+		}
+		if !empty {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests, nil
+}
+
+// setField sets a request field from a CSV value
+func (r *batchRequest) setField(column, value string) error {
+	var err error
+	number := func() int {
+		var n int
+		n, err = strconv.Atoi(value)
+		return n
+	}
+
+	switch column {
+	case "id":
+		r.ID = value
+	case "prompt":
+		r.Prompt = value
+	case "negative":
+		r.Negative = value
+	case "negative_prompt":
+		r.NegativePrompt = value
+	case "seed":
+		var seed int64
+		seed, err = strconv.ParseInt(value, 10, 64)
+		r.Seed = &seed
+	case "size":
+		r.Size = value
+	case "width":
+		r.Width = number()
+	case "height":
+		r.Height = number()
+	case "steps":
+		r.Steps = number()
+	case "model":
+		r.Model = value
+	case "loras":
+		r.Loras = splitBatchLoras(value)
+	case "filename":
+		r.Filename = value
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s '%s'", column, value)
+	}
+	return nil
+}
+
+// batchResultsPath returns the results file written next to the input
+func batchResultsPath(input string) string {
+	return input + ".results.jsonl"
+}
+
+// loadBatchResults reads a results file; later lines override earlier ones
+func loadBatchResults(path string) (map[string]batchResult, error) {
+	results := make(map[string]batchResult)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return results, nil
+		}
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var result batchResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return nil, fmt.Errorf("results line %d: %w", i+1, err)
+		}
+		results[result.ID] = result
+	}
+
+	return results, nil
+}
+
+// isBatchDone reports whether a request completed with the same settings and
+// its files are still present
+func isBatchDone(r *resolvedBatchRequest, previous map[string]batchResult) bool {
+	result, ok := previous[r.Key()]
+	if !ok || result.Status != batchStatusCompleted || result.Hash != r.Hash {
+		return false
+	}
+	if _, err := os.Stat(r.OutputPath); err != nil {
+		return false
+	}
+	return true
+}
+
+func runGenerateBatch(cmd *cobra.Command, args []string) error {
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Setup signal handler
+	setupSignalHandler(cancel)
+
+	requests, err := readBatchRequests(batchInput)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("no requests in %s", batchInput)
+	}
+
+	outputDir := batchOutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(batchInput)
+	}
+
+	// Resolve every request before generating anything, so mistakes in the
+	// file are reported up front
+	resolved := make([]*resolvedBatchRequest, len(requests))
+	for i, request := range requests {
+		if resolved[i], err = request.resolve(outputDir); err != nil {
+			return fmt.Errorf("line %d: %w", request.line, err)
+		}
+	}
+
+	resultsPath := batchResultsPath(batchInput)
+	previous := make(map[string]batchResult)
+	if batchResume {
+		if previous, err = loadBatchResults(resultsPath); err != nil {
+			return err
+		}
+	}
+
+	var pending []*resolvedBatchRequest
+	for _, r := range resolved {
+		if !isBatchDone(r, previous) {
+			pending = append(pending, r)
+		}
+	}
+
+	if events, err = openEventStream(); err != nil {
+		return err
+	}
+	defer func() {
+		events.Close()
+		events = nil
+	}()
+
+	if !quiet {
+		fmt.Fprintf(stderr, "Loaded %d requests from %s\n", len(requests), batchInput)
+		if skipped := len(resolved) - len(pending); skipped > 0 {
+			fmt.Fprintf(stderr, "Skipping %d requests that already completed\n", skipped)
+		}
+		fmt.Fprintln(stderr)
+	}
+	if len(pending) == 0 {
+		if !quiet {
+			fmt.Fprintf(stderr, "✓ Nothing to do\n")
+		}
+		events.Done(totalsPayload{Total: len(resolved), Skipped: len(resolved)}, time.Now(), nil)
+		return nil
+	}
+
+	pool, err := newServerPool(ctx, batchServers)
+	if err != nil {
+		return err
+	}
+
+	// Results are appended as requests finish, so an interrupted batch can resume
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !batchResume {
+		flags |= os.O_TRUNC
+	}
+	resultsFile, err := os.OpenFile(resultsPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer resultsFile.Close()
+
+	workers := batchConcurrency
+	if workers <= 0 {
+		workers = pool.Capacity()
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		completed int
+		failed    int
+		started   int
+	)
+	slots := make(chan struct{}, workers)
+
+	// Show a progress bar per running request
+	display := newProgressDisplay()
+	runStart := time.Now()
+
+	for _, r := range pending {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		mu.Lock()
+		started++
+		if !quiet {
+			fmt.Fprintf(stderr, "[%d/%d] Generating: %s\n", started, len(pending), r.Key())
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(r *resolvedBatchRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := runBatchRequest(ctx, pool, r, display)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Status == batchStatusCompleted {
+				completed++
+				if !quiet {
+					fmt.Fprintf(stderr, "  ✓ %s saved to: %s\n", r.Key(), strings.Join(result.Files, ", "))
+				}
+			} else {
+				failed++
+				fmt.Fprintf(stderr, "  ⚠ Warning: %s failed: %s\n", r.Key(), result.Error)
+			}
+
+			line, _ := json.Marshal(result)
+			if _, err := resultsFile.Write(append(line, '\n')); err != nil {
+				fmt.Fprintf(stderr, "  ⚠ Warning: Failed to write result: %v\n", err)
+			}
+		}(r)
+	}
+	wg.Wait()
+	display.Stop()
+
+	// Requests still waiting for a slot when the batch was cancelled never
+	// started; they are left for --resume
+	cancelled := len(pending) - started
+
+	var runErr error
+	if err := ctx.Err(); err != nil {
+		runErr = fmt.Errorf("batch cancelled: %w", err)
+	}
+	events.Done(totalsPayload{Total: len(resolved), Completed: completed, Failed: failed, Skipped: len(resolved) - len(pending) + cancelled}, runStart, runErr)
+
+	if !quiet {
+		fmt.Fprintf(stderr, "\nCompleted %d/%d requests", completed, len(pending))
+		if failed > 0 {
+			fmt.Fprintf(stderr, ", %d failed", failed)
+		}
+		if cancelled > 0 {
+			fmt.Fprintf(stderr, ", %d cancelled before starting", cancelled)
+		}
+		fmt.Fprintf(stderr, "\nResults written to: %s\n", resultsPath)
+		printServerSummary(pool)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d requests failed (see %s; rerun with --resume to retry them)", failed, len(pending), resultsPath)
+	}
+	return nil
+}
+
+// runBatchRequest generates and downloads the image of one request
+func runBatchRequest(ctx context.Context, pool *client.ServerPool, r *resolvedBatchRequest, display *progressDisplay) batchResult {
+	seed := rand.Int63n(1 << 31)
+	if fixed := r.fixedSeed(); fixed != nil {
+		seed = *fixed
+	}
+
+	result := batchResult{
+		Line:   r.line,
+		ID:     r.Key(),
+		Prompt: r.Prompt,
+		Seed:   seed,
+		Model:  r.Model,
+		Hash:   r.Hash,
+	}
+	start := time.Now()
+
+	req := r.generationRequest(seed)
+	task := display.Start(r.Key())
+	task.Attach(req)
+	defer task.Done()
+	ev := events.Asset(r.Key(), "")
+	ev.Attach(req)
+
+	err := pool.Do(ctx, r.Key(), func(c *client.AssetClient) error {
+		result.Server = c.BaseURL()
+		ev.SetServer(result.Server)
+
+		generated, err := c.GenerateImage(ctx, req)
+		if err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+		if len(generated.ImagePaths) == 0 {
+			return fmt.Errorf("no images generated")
+		}
+		ev.ImageReady(generated.ImagePaths)
+
+		opts := &client.DownloadOptions{
+			OutputDir:        filepath.Dir(r.OutputPath),
+			FilenameTemplate: filepath.Base(r.OutputPath),
+			Metadata: map[string]interface{}{
+				"prompt": r.Prompt,
+				"seed":   seed,
+				"model":  r.Model,
+				"width":  r.Width,
+				"height": r.Height,
+			},
+		}
+		ev.AttachDownload(opts)
+
+		files, err := c.DownloadImagesWithOptions(ctx, generated.ImagePaths, opts)
+		if err != nil {
+			return fmt.Errorf("download failed: %w", err)
+		}
+		result.Files = files
+		return nil
+	})
+
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.FinishedAt = time.Now()
+	if err != nil {
+		result.Status = batchStatusFailed
+		result.Error = err.Error()
+		ev.Failed(err)
+	} else {
+		result.Status = batchStatusCompleted
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchRequests(t *testing.T) {
+	seed := int64(42)
+
+	tests := []struct {
+		name    string
+		parse   func(string) ([]batchRequest, error)
+		input   string
+		want    []batchRequest
+		wantErr bool
+	}{
+		{
+			name:  "jsonl with every field",
+			parse: func(s string) ([]batchRequest, error) { return parseBatchJSONL(strings.NewReader(s)) },
+			input: `{"id": "hero", "prompt": "knight", "negative": "blurry", "seed": 42, "size": "768x1344", "model": "flux", "loras": ["detail:0.6"], "filename": "chars/hero.png"}`,
+			want: []batchRequest{{
+				ID: "hero", Prompt: "knight", Negative: "blurry", Seed: &seed, Size: "768x1344",
+				Model: "flux", Loras: batchLoras{"detail:0.6"}, Filename: "chars/hero.png", line: 1,
+			}},
+		},
+		{
+			name:  "jsonl loras as string and object, blank lines skipped",
+			parse: func(s string) ([]batchRequest, error) { return parseBatchJSONL(strings.NewReader(s)) },
+			input: "{\"prompt\": \"a\", \"loras\": \"x:0.5; y\"}\n\n{\"prompt\": \"b\", \"loras\": {\"y\": 1, \"x\": 0.5}}\n",
+			want: []batchRequest{
+				{Prompt: "a", Loras: batchLoras{"x:0.5", "y"}, line: 1},
+				{Prompt: "b", Loras: batchLoras{"x:0.5", "y:1"}, line: 3},
+			},
+		},
+		{
+			name:    "jsonl unknown field",
+			parse:   func(s string) ([]batchRequest, error) { return parseBatchJSONL(strings.NewReader(s)) },
+			input:   `{"prompt": "a", "promt": "b"}`,
+			wantErr: true,
+		},
+		{
+			name:  "csv with columns in any order",
+			parse: func(s string) ([]batchRequest, error) { return parseBatchCSV(strings.NewReader(s)) },
+			input: "\ufeffPrompt,seed,loras,id\nknight,42,detail:0.6;style,hero\n,,,\nmage,,,\n",
+			want: []batchRequest{
+				{ID: "hero", Prompt: "knight", Seed: &seed, Loras: batchLoras{"detail:0.6", "style"}, line: 1},
+				{Prompt: "mage", line: 3},
+			},
+		},
+		{
+			name:    "csv unknown column",
+			parse:   func(s string) ([]batchRequest, error) { return parseBatchCSV(strings.NewReader(s)) },
+			input:   "prompt,colour\nknight,red\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv invalid seed",
+			parse:   func(s string) ([]batchRequest, error) { return parseBatchCSV(strings.NewReader(s)) },
+			input:   "prompt,seed\nknight,abc\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBatchRequest(t *testing.T) {
+	batchWidth, batchHeight, batchSteps, batchModel = 512, 512, 20, "default-model"
+	random := int64(-1)
+
+	tests := []struct {
+		name       string
+		request    batchRequest
+		wantWidth  int
+		wantHeight int
+		wantModel  string
+		wantOutput string
+		wantErr    bool
+	}{
+		{"defaults", batchRequest{Prompt: "a", line: 3}, 512, 512, "default-model", "out/line-3.png", false},
+		{"size", batchRequest{ID: "hero", Prompt: "a", Size: "768x1344", Model: "flux"}, 768, 1344, "flux", "out/hero.png", false},
+		{"width overrides size", batchRequest{Prompt: "a", Size: "768x1344", Width: 640, Filename: "x/y.png"}, 640, 1344, "default-model", "out/x/y.png", false},
+		{"random seed", batchRequest{Prompt: "a", Seed: &random, line: 1}, 512, 512, "default-model", "out/line-1.png", false},
+		{"missing prompt", batchRequest{Size: "512x512"}, 0, 0, "", "", true},
+		{"invalid size", batchRequest{Prompt: "a", Size: "512"}, 0, 0, "", "", true},
+		{"escaping filename", batchRequest{Prompt: "a", Filename: "../hero.png"}, 0, 0, "", "", true},
+		{"invalid lora weight", batchRequest{Prompt: "a", Loras: batchLoras{"x:heavy"}}, 0, 0, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.request.resolve("out")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", got.Width, got.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", got.Model, tt.wantModel)
+			}
+			if got.OutputPath != filepath.FromSlash(tt.wantOutput) {
+				t.Errorf("output = %q, want %q", got.OutputPath, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestBatchResume(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "requests.jsonl")
+	resultsPath := batchResultsPath(input)
+	if resultsPath != filepath.Join(dir, "requests.jsonl.results.jsonl") {
+		t.Fatalf("batchResultsPath() = %s", resultsPath)
+	}
+
+	done, err := batchRequest{ID: "done", Prompt: "a"}.resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, _ := batchRequest{ID: "changed", Prompt: "b"}.resolve(dir)
+	failed, _ := batchRequest{ID: "failed", Prompt: "c"}.resolve(dir)
+	deleted, _ := batchRequest{ID: "deleted", Prompt: "d"}.resolve(dir)
+	for _, r := range []*resolvedBatchRequest{done, changed, failed} {
+		os.WriteFile(r.OutputPath, []byte("png"), 0644)
+	}
+
+	results := strings.Join([]string{
+		`{"id": "done", "status": "failed", "hash": "` + done.Hash + `"}`,
+		`{"id": "done", "status": "completed", "hash": "` + done.Hash + `"}`,
+		`{"id": "changed", "status": "completed", "hash": "0000"}`,
+		`{"id": "failed", "status": "failed", "hash": "` + failed.Hash + `"}`,
+		`{"id": "deleted", "status": "completed", "hash": "` + deleted.Hash + `"}`,
+	}, "\n")
+	os.WriteFile(resultsPath, []byte(results), 0644)
+
+	previous, err := loadBatchResults(resultsPath)
+	if err != nil {
+		t.Fatalf("loadBatchResults() error = %v", err)
+	}
+
+	tests := []struct {
+		request *resolvedBatchRequest
+		want    bool
+	}{
+		{done, true},
+		{changed, false},
+		{failed, false},
+		{deleted, false},
+	}
+	for _, tt := range tests {
+		if got := isBatchDone(tt.request, previous); got != tt.want {
+			t.Errorf("isBatchDone(%s) = %v, want %v", tt.request.Key(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg" // Register decoders for previews
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	previewFrameDelay = 10  // Delay of GIF frames, in 100ths of a second
+	previewFinalDelay = 150 // Hold the last frame so the result is visible
+)
+
+// previewRecorder writes the step previews of a generation to a directory
+// and collects them into an animated GIF of the denoise progression
+type previewRecorder struct {
+	dir string
+
+	mu     sync.Mutex
+	last   map[int]string            // Last preview per batch image, to skip repeats
+	frames map[int][]*image.Paletted // GIF frames per batch image
+	count  int
+	err    error // First failure, reported by Finish
+}
+
+// newPreviewRecorder creates the preview directory
+func newPreviewRecorder(dir string) (*previewRecorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create preview directory: %w", err)
+	}
+	return &previewRecorder{
+		dir:    dir,
+		last:   make(map[int]string),
+		frames: make(map[int][]*image.Paletted),
+	}, nil
+}
+
+// Record writes the preview of a progress update, if it has a new one. It is
+// used as a GenerationRequest's ProgressUpdateCallback.
+func (p *previewRecorder) Record(update client.ProgressUpdate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if update.Preview == "" || update.Preview == p.last[update.BatchIndex] {
+		return
+	}
+	p.last[update.BatchIndex] = update.Preview
+
+	data, ext, err := update.DecodePreview()
+	if err != nil {
+		p.fail(err)
+		return
+	}
+
+	path := filepath.Join(p.dir, fmt.Sprintf("preview-%d-step-%03d%s", update.BatchIndex, update.Step, ext))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		p.fail(fmt.Errorf("failed to write preview: %w", err))
+		return
+	}
+	p.count++
+	if verbose {
+		fmt.Fprintf(stderr, "  Preview (step %d/%d): %s\n", update.Step, update.Steps, path)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		p.fail(fmt.Errorf("failed to decode preview for GIF: %w", err))
+		return
+	}
+
+	// Frames must share the size of the first frame
+	frames := p.frames[update.BatchIndex]
+	if len(frames) > 0 && img.Bounds().Size() != frames[0].Bounds().Size() {
+		return
+	}
+	frame := image.NewPaletted(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()), palette.Plan9)
+	draw.FloydSteinberg.Draw(frame, frame.Bounds(), img, img.Bounds().Min)
+	p.frames[update.BatchIndex] = append(frames, frame)
+}
+
+// fail keeps the first error; previews are best effort and never stop a generation
+func (p *previewRecorder) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// Finish writes the animated GIF of each batch image's previews and returns
+// their paths
+func (p *previewRecorder) Finish() ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	indexes := make([]int, 0, len(p.frames))
+	for index := range p.frames {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	var paths []string
+	for _, index := range indexes {
+		frames := p.frames[index]
+		anim := &gif.GIF{Image: frames, Delay: make([]int, len(frames))}
+		for i := range anim.Delay {
+			anim.Delay[i] = previewFrameDelay
+		}
+		anim.Delay[len(frames)-1] = previewFinalDelay
+
+		path := filepath.Join(p.dir, fmt.Sprintf("progression-%d.gif", index))
+		file, err := os.Create(path)
+		if err != nil {
+			return paths, fmt.Errorf("failed to create GIF: %w", err)
+		}
+		err = gif.EncodeAll(file, anim)
+		file.Close()
+		if err != nil {
+			return paths, fmt.Errorf("failed to write GIF: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, p.err
+}
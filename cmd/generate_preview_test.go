@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+)
+
+// previewDataURL encodes a solid PNG as a data URL like SwarmUI's previews
+func previewDataURL(t *testing.T, width, height int, c color.Color) string {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestPreviewRecorder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "previews")
+	recorder, err := newPreviewRecorder(dir)
+	if err != nil {
+		t.Fatalf("newPreviewRecorder() error = %v", err)
+	}
+
+	dark := previewDataURL(t, 16, 12, color.Gray{Y: 40})
+	light := previewDataURL(t, 16, 12, color.Gray{Y: 200})
+	updates := []client.ProgressUpdate{
+		{Step: 2, Steps: 10, Preview: dark},
+		{Step: 3, Steps: 10, Preview: dark}, // Repeated preview is skipped
+		{Step: 5, Steps: 10},                // No preview
+		{Step: 6, Steps: 10, Preview: light},
+		{Step: 8, Steps: 10, Preview: previewDataURL(t, 32, 24, color.White)}, // Saved, but not a GIF frame
+		{Step: 4, Steps: 10, BatchIndex: 1, Preview: light},
+	}
+	for _, update := range updates {
+		recorder.Record(update)
+	}
+
+	gifs, err := recorder.Finish()
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	for _, name := range []string{"preview-0-step-002.png", "preview-0-step-006.png", "preview-0-step-008.png", "preview-1-step-004.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected preview %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "preview-0-step-003.png")); err == nil {
+		t.Error("repeated preview was written again")
+	}
+	if recorder.count != 4 {
+		t.Errorf("count = %d, expected 4", recorder.count)
+	}
+
+	if len(gifs) != 2 {
+		t.Fatalf("Finish() = %v, expected a GIF per batch image", gifs)
+	}
+	file, err := os.Open(gifs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+	if len(anim.Image) != 2 {
+		t.Errorf("GIF has %d frames, expected 2", len(anim.Image))
+	}
+	if anim.Delay[len(anim.Delay)-1] != previewFinalDelay {
+		t.Errorf("last frame delay = %d, expected %d", anim.Delay[len(anim.Delay)-1], previewFinalDelay)
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	padWidth      int
+	padHeight     int
+	padAspect     string
+	padMargin     int
+	padAnchor     string
+	padFill       string
+	padBackground string
+	padQuality    int
+	padFormat     string
+	padOutput     string
+	padInPlace    bool
+)
+
+// padCmd represents the pad command
+var padCmd = &cobra.Command{
+	Use:   "pad [image-file...]",
+	Short: "Extend the canvas around images",
+	Long: `Extend the canvas around one or more images without scaling them.
+
+The canvas only grows: --margin adds pixels on every side, --aspect widens or
+heightens the canvas to an aspect ratio such as 1:1 or 16:9, and --width and
+--height set a minimum canvas size. The image is placed by --anchor: center
+(default), top, bottom, left, right, top-left, top-right, bottom-left or
+bottom-right.
+
+Fills:
+  color   The --background color, transparent by default
+  edge    Repeat the outermost pixels of the image
+  mirror  Reflect the image at its edges
+
+Examples:
+  # Square store listing with a 64px margin around the subject
+  asset-generator pad product.png --aspect 1:1 --margin 64 --background white
+
+  # Crop to the subject first, then square it
+  asset-generator crop logo.png && asset-generator pad logo.png --aspect 1:1 --margin 32 --in-place
+
+  # Extend a background to 16:9 without visible borders
+  asset-generator pad scene.png --aspect 16:9 --fill mirror
+
+  # Place icons at the bottom of a 512x512 transparent canvas
+  asset-generator pad icons/*.png -w 512 -l 512 --anchor bottom --in-place
+
+Output files are written next to their inputs as name_padded.ext unless
+--output or --in-place is given. The output format follows the extension,
+or --format. JPEG output, which cannot hold transparency, is flattened onto
+white.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPad,
+}
+
+func init() {
+	rootCmd.AddCommand(padCmd)
+
+	padCmd.Flags().IntVarP(&padWidth, "width", "w", 0, "minimum canvas width in pixels")
+	padCmd.Flags().IntVarP(&padHeight, "height", "l", 0, "minimum canvas height in pixels")
+	padCmd.Flags().StringVar(&padAspect, "aspect", "", "canvas aspect ratio, such as 1:1, 16:9 or 1.5")
+	padCmd.Flags().IntVar(&padMargin, "margin", 0, "margin in pixels to add on every side")
+	padCmd.Flags().StringVar(&padAnchor, "anchor", "center", "where to place the image: center, top, bottom, left, right, top-left, ...")
+	padCmd.Flags().StringVar(&padFill, "fill", "color", "how to fill the added area: color, edge, mirror")
+	padCmd.Flags().StringVar(&padBackground, "background", "transparent", "fill color, as a name or #rrggbb")
+	padCmd.Flags().IntVar(&padQuality, "quality", 90, "JPEG quality (1-100)")
+	padCmd.Flags().StringVar(&padFormat, "format", "", "output format: png, jpeg, webp, gif, bmp, tiff (default: from the output extension)")
+	padCmd.Flags().StringVarP(&padOutput, "output", "o", "", "output file path (single file mode only)")
+	padCmd.Flags().BoolVar(&padInPlace, "in-place", false, "replace original file(s) with the padded version")
+}
+
+func runPad(cmd *cobra.Command, args []string) error {
+	opts, err := parsePadOptions(padWidth, padHeight, padAspect, padMargin, padAnchor, padFill, padBackground)
+	if err != nil {
+		return err
+	}
+	if !opts.Enabled() {
+		return fmt.Errorf("at least one of --width, --height, --aspect or --margin must be specified")
+	}
+	if padQuality < 1 || padQuality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+	if padFormat != "" {
+		if padFormat, err = processor.ParseFormat(padFormat); err != nil {
+			return err
+		}
+	}
+	opts.JPEGQuality, opts.Format = padQuality, padFormat
+
+	// Validate output flag usage
+	if padOutput != "" && len(args) > 1 {
+		return fmt.Errorf("--output can only be used with a single input file")
+	}
+	if padOutput != "" && padInPlace {
+		return fmt.Errorf("cannot specify both --output and --in-place")
+	}
+
+	processedCount := 0
+	errorCount := 0
+
+	for _, inputPath := range args {
+		if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", inputPath)
+			errorCount++
+			continue
+		}
+
+		// Determine output path; converting the format in place replaces the
+		// original with a file of the new extension
+		var outputPath string
+		if padInPlace {
+			outputPath = inputPath
+			if padFormat != "" {
+				outputPath = processor.ReplaceExtension(inputPath, padFormat)
+			}
+		} else if padOutput != "" {
+			outputPath = padOutput
+		} else {
+			ext := filepath.Ext(inputPath)
+			if padFormat != "" {
+				ext = processor.Extension(padFormat)
+			}
+			outputPath = fmt.Sprintf("%s_padded%s", strings.TrimSuffix(inputPath, filepath.Ext(inputPath)), ext)
+		}
+
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Padding: %s -> %s (fill: %s, anchor: %s)\n",
+				inputPath, outputPath, opts.Fill, opts.Anchor)
+		}
+
+		if err := processor.Pad(inputPath, outputPath, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error padding %s: %v\n", inputPath, err)
+			errorCount++
+			continue
+		}
+		if padInPlace && outputPath != inputPath {
+			if err := os.Remove(inputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", inputPath, err)
+			}
+		}
+
+		processedCount++
+		if !quiet {
+			width, height, err := processor.GetImageDimensions(outputPath)
+			if err == nil {
+				fmt.Fprintf(os.Stderr, "✓ Padded: %s (%dx%d)\n", outputPath, width, height)
+			} else {
+				fmt.Fprintf(os.Stderr, "✓ Padded: %s\n", outputPath)
+			}
+		}
+	}
+
+	// Summary
+	if !quiet && len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "\nPad complete: %d succeeded, %d failed\n", processedCount, errorCount)
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("failed to pad %d image(s)", errorCount)
+	}
+
+	return nil
+}
+
+// parsePadOptions parses the pad flags shared by the pad command and the
+// --pad-* postprocessing flags of generate and pipeline
+func parsePadOptions(width, height int, aspect string, margin int, anchor, fill, background string) (processor.PadOptions, error) {
+	opts := processor.PadOptions{Width: width, Height: height, Margin: margin}
+	if width < 0 || height < 0 || margin < 0 {
+		return opts, fmt.Errorf("pad size and margin cannot be negative")
+	}
+
+	var err error
+	if aspect != "" {
+		if opts.Aspect, err = processor.ParseAspectRatio(aspect); err != nil {
+			return opts, err
+		}
+	}
+	if opts.Anchor, err = processor.ParseAnchor(anchor); err != nil {
+		return opts, err
+	}
+	if opts.Fill, err = processor.ParsePadFill(fill); err != nil {
+		return opts, err
+	}
+	bg, err := processor.ParseColor(background)
+	if err != nil {
+		return opts, err
+	}
+	opts.Background = bg
+	return opts, nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import "testing"
+
+func TestParsePadOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		aspect     string
+		margin     int
+		anchor     string
+		fill       string
+		background string
+		wantAspect float64
+		wantErr    bool
+	}{
+		{name: "square", aspect: "1:1", margin: 16, anchor: "center", fill: "color", background: "white", wantAspect: 1},
+		{name: "mirror", aspect: "16:9", anchor: "top-left", fill: "mirror", background: "transparent", wantAspect: 16.0 / 9},
+		{name: "margin only", margin: 8, anchor: "center", fill: "edge", background: "transparent"},
+		{name: "bad aspect", aspect: "wide", anchor: "center", fill: "color", background: "white", wantErr: true},
+		{name: "bad anchor", aspect: "1:1", anchor: "middle", fill: "color", background: "white", wantErr: true},
+		{name: "bad fill", aspect: "1:1", anchor: "center", fill: "blur", background: "white", wantErr: true},
+		{name: "bad background", aspect: "1:1", anchor: "center", fill: "color", background: "nope", wantErr: true},
+		{name: "negative margin", margin: -1, anchor: "center", fill: "color", background: "white", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parsePadOptions(0, 0, tt.aspect, tt.margin, tt.anchor, tt.fill, tt.background)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePadOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && opts.Aspect != tt.wantAspect {
+				t.Errorf("parsePadOptions() aspect = %v, want %v", opts.Aspect, tt.wantAspect)
+			}
+		})
+	}
+}
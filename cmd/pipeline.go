@@ -3,12 +3,17 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/opd-ai/asset-generator/pkg/client"
+	"github.com/opd-ai/asset-generator/pkg/processor"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -29,27 +34,69 @@ var (
 	pipelineNegPrompt     string
 	pipelineDryRun        bool
 	pipelineContinueError bool
+	pipelineCandidates    int
+	pipelineSeedStrategy  string // Resolved from --seed-strategy or the pipeline file
+	pipelineSeedFlag      string
+	pipelineWatch         bool
+	pipelineReport        bool
+	pipelineOnly          []string
+	pipelineSkip          []string
+	pipelineTags          []string
 	// Postprocessing options
-	pipelineAutoCrop               bool
-	pipelineAutoCropThreshold      int
-	pipelineAutoCropTolerance      int
-	pipelineAutoCropPreserveAspect bool
-	pipelineDownscaleWidth         int
-	pipelineDownscaleHeight        int
-	pipelineDownscalePercentage    float64
-	pipelineDownscaleFilter        string
+	pipelineRemoveBackground          bool
+	pipelineRemoveBackgroundMode      string
+	pipelineRemoveBackgroundColor     string
+	pipelineRemoveBackgroundTolerance int
+	pipelineRemoveBackgroundFeather   int
+	pipelineAutoCrop                  bool
+	pipelineAutoCropThreshold         int
+	pipelineAutoCropTolerance         int
+	pipelineAutoCropPreserveAspect    bool
+	pipelineAutoCropMode              string
+	pipelineAutoCropColor             string
+	pipelineAutoCropPadding           int
+	pipelineAutoCropPaddingPercent    float64
+	pipelinePadWidth                  int
+	pipelinePadHeight                 int
+	pipelinePadAspect                 string
+	pipelinePadMargin                 int
+	pipelinePadAnchor                 string
+	pipelinePadFill                   string
+	pipelinePadBackground             string
+	pipelineDownscaleWidth            int
+	pipelineDownscaleHeight           int
+	pipelineDownscalePercentage       float64
+	pipelineDownscaleFilter           string
+	pipelineDownscaleLinear           bool
+	pipelineImageFormat               string
 	// SkimmedCFG (Distilled CFG) options
 	pipelineSkimmedCFG      bool
 	pipelineSkimmedCFGScale float64
 	pipelineSkimmedCFGStart float64
 	pipelineSkimmedCFGEnd   float64
+	// Servers
+	pipelineServers []string
+	pipelinePool    *client.ServerPool // Distributes generations across the configured servers
+	pipelineDisplay *progressDisplay   // Progress of the running generations
 )
 
 // PipelineSpec represents the structure of a generic pipeline YAML file
 type PipelineSpec struct {
-	Assets []AssetGroup `yaml:"assets"`
+	SeedStrategy string        `yaml:"seed_strategy,omitempty"` // How asset seeds are derived: "index" (default) or "hash"
+	Hooks        PipelineHooks `yaml:"hooks,omitempty"`         // Commands run before and after assets, groups and the pipeline
+	Assets       []AssetGroup  `yaml:"assets"`
+
+	legacy bool // Converted from the legacy tarot format
 }
 
+const (
+	// seedStrategyIndex derives seeds from base seed + seed offset + position in group
+	seedStrategyIndex = "index"
+	// seedStrategyHash derives seeds from base seed + seed offset + hash of the asset's ID path,
+	// so inserting or reordering assets does not change the seeds of other assets
+	seedStrategyHash = "hash"
+)
+
 // AssetGroup represents a collection of related assets
 type AssetGroup struct {
 	Name       string                 `yaml:"name"`                // Group name (e.g., "characters", "backgrounds")
@@ -62,11 +109,35 @@ type AssetGroup struct {
 
 // Asset represents a single asset to generate
 type Asset struct {
-	ID       string                 `yaml:"id"`                 // Unique identifier for the asset
-	Name     string                 `yaml:"name"`               // Display name
-	Prompt   string                 `yaml:"prompt"`             // Generation prompt
-	Filename string                 `yaml:"filename,omitempty"` // Custom filename (optional, defaults to sanitized ID)
-	Metadata map[string]interface{} `yaml:"metadata,omitempty"` // Asset metadata (appended to prompt)
+	ID         string                 `yaml:"id"`                   // Unique identifier for the asset
+	Name       string                 `yaml:"name"`                 // Display name
+	Prompt     string                 `yaml:"prompt"`               // Generation prompt
+	Filename   string                 `yaml:"filename,omitempty"`   // Custom filename (optional, defaults to sanitized ID)
+	Metadata   map[string]interface{} `yaml:"metadata,omitempty"`   // Asset metadata (appended to prompt)
+	Candidates int                    `yaml:"candidates,omitempty"` // Number of candidate images to generate (overrides --candidates)
+	Tags       []string               `yaml:"tags,omitempty"`       // Free-form tags for selective runs (--tag)
+}
+
+// pipelineJob is a single asset resolved against its group hierarchy.
+// Seeds, prompts and paths are computed once up front so that every
+// consumer (generation, dry run, manifest) sees identical values.
+type pipelineJob struct {
+	Asset      Asset
+	GroupName  string                 // Display name of the owning group
+	GroupPath  string                 // Slash-separated path of sanitized group names
+	Index      int                    // Position of the asset within its group
+	SeedOffset int64                  // Seed offset of the owning group
+	Seed       int64                  // Resolved generation seed
+	Prompt     string                 // Prompt with metadata appended (before style prefix/suffix)
+	Metadata   map[string]interface{} // Merged group and asset metadata
+	OutputDir  string                 // Group output directory
+	RelDir     string                 // Group output directory relative to the pipeline output directory
+	OutputPath string                 // Canonical output file path
+}
+
+// Key returns the full ID path of the job's asset (e.g. "characters/heroes/hero_01").
+func (j pipelineJob) Key() string {
+	return assetKey(j.GroupPath, j.Asset.ID)
 }
 
 // pipelineCmd represents the pipeline command
@@ -88,7 +159,7 @@ Examples:
   # Process a legacy tarot deck pipeline (backward compatible)
   asset-generator pipeline --file tarot-spec.yaml --output-dir ./deck
   
-  # Preview what would be generated (dry run)
+  # Preview what would be generated and how long it will take (dry run)
   asset-generator pipeline --file assets-spec.yaml --dry-run
   
   # Use custom generation parameters
@@ -107,7 +178,36 @@ Examples:
   asset-generator pipeline --file assets-spec.yaml \
     --auto-crop --downscale-width 1024
 
+  # Save every asset as WebP (hero.png in the spec is written as hero.webp)
+  asset-generator pipeline --file assets-spec.yaml --image-format webp
+
+  # Derive seeds from asset IDs so inserting assets keeps approved art stable
+  asset-generator pipeline --file assets-spec.yaml --seed-strategy hash
+
+  # Regenerate a single asset, a group, or everything matching a pattern
+  asset-generator pipeline --file assets-spec.yaml --only hero_01
+  asset-generator pipeline --file assets-spec.yaml --only 'characters/heroes/*' --skip 'warrior_*'
+  asset-generator pipeline --file assets-spec.yaml --tag boss
+
+  # Regenerate changed assets whenever the pipeline file is saved
+  asset-generator pipeline --file assets-spec.yaml --watch --base-seed 42
+
+  # Generate 4 candidates per asset, then promote the one art direction picked
+  asset-generator pipeline --file assets-spec.yaml --candidates 4
+  asset-generator pipeline select hero_01 3
+
+  # Write an HTML contact sheet for reviewers after the run
+  asset-generator pipeline --file assets-spec.yaml --report
+
+  # Regenerate exactly what pipeline.lock pins and verify the outputs
+  asset-generator pipeline --file assets-spec.yaml --locked
+
+  # Spread assets across several GPU servers
+  asset-generator pipeline --file assets-spec.yaml \
+    --server http://gpu1:7801,weight=2 --server http://gpu2:7801
+
 Pipeline File Structure (Generic Format):
+  seed_strategy: hash  # optional: "index" (default) or "hash"
   assets:
     - name: Characters
       output_dir: characters
@@ -117,9 +217,11 @@ Pipeline File Structure (Generic Format):
           name: Hero Character
           prompt: "heroic warrior, detailed armor..."
           filename: hero.png
+          tags: [hero, key-art]  # optional: select with --tag
         - id: villain_01
           name: Villain Character
           prompt: "dark sorcerer, mysterious robes..."
+          candidates: 4  # generate options into candidates/villain_01/
           
     - name: Backgrounds
       output_dir: backgrounds
@@ -129,7 +231,7 @@ Pipeline File Structure (Generic Format):
           name: Forest Scene
           prompt: "mystical forest, sunbeams..."
 
-Legacy Tarot Format (Backward Compatible):
+Legacy Tarot Format (Backward Compatible, convert with 'pipeline migrate'):
   major_arcana:
     - number: 0
       name: The Fool
@@ -145,7 +247,73 @@ Legacy Tarot Format (Backward Compatible):
 
 Output Structure:
   Generated assets will be organized according to the structure
-  defined in your pipeline file.`,
+  defined in your pipeline file. A pipeline-manifest.json file in the
+  output directory records the prompt, seed and result of every asset.
+  With --report, a self-contained report.html contact sheet is written
+  next to it; 'pipeline report' rebuilds it from the manifest.
+
+Time Estimates:
+  Generation timings are recorded per model in ~/.asset-generator/timings.json.
+  --dry-run uses them to estimate the wall-clock time and GPU-minutes of each
+  group and of the whole run, and runs show an ETA for each asset.
+
+Seed Strategies:
+  index  Seeds are base seed + seed_offset + position within the group.
+         Inserting an asset shifts the seeds of every asset after it.
+  hash   Seeds are base seed + seed_offset + a hash of the asset's full ID
+         path (group names and asset ID), independent of asset order.
+
+Selective Runs:
+  --only and --skip take asset IDs, group names or glob patterns matched
+  against the asset ID, the full ID path (e.g. characters/heroes/hero_01)
+  and the path of every enclosing group (e.g. characters/heroes). Group
+  paths are built both from output_dir values and from group names in
+  sanitized form (lowercase, spaces replaced by underscores).
+  --tag selects assets whose "tags:" list contains any of the given tags.
+  Seeds and output paths are identical to a full run.
+
+Lockfile:
+  A finished run writes pipeline.lock to the output directory, pinning each
+  asset's prompt, seed, model and model hash, sampler, scheduler, parameters,
+  postprocessing, asset hooks, server version and output SHA-256, plus the
+  reasons an asset cannot be pinned completely. --locked refuses to run if
+  anything resolves differently, regenerates into a scratch directory inside
+  the output directory, and fails if an image is not bit-for-bit identical to
+  the shipped output. The scratch directory is removed when everything
+  matches.
+
+Multiple Servers:
+  Servers listed under "servers:" in the config file, or given with --server,
+  share the work, each running up to its concurrency at once. Each asset
+  prefers a server chosen by hashing its ID path and weights; assets on a
+  server that goes offline are retried on another one. Weights only set that
+  preference: once every server is busy, assets go to the first free slot.
+  Seeds and outputs do not depend on the server.
+
+Watch Mode:
+  --watch monitors the pipeline file and, each time it is saved, regenerates
+  only the assets whose resolved prompt or parameters changed since they were
+  last generated (tracked by a hash per asset in pipeline-manifest.json).
+  Failed assets are retried on the next change. Without --base-seed, the
+  base seed of the previous run is reused from the manifest.
+
+Hooks:
+  A top-level "hooks:" section runs commands at before_asset, after_asset,
+  after_group and after_pipeline. Each hook is a shell line or a list of
+  arguments, rendered as a Go template ({{.ID}}, {{.Output}}, {{.Seed}}, ...).
+  Hooks receive ASSET_ID, ASSET_OUTPUT, ASSET_SEED, ASSET_METADATA and more as
+  environment variables. A failing asset hook fails the asset.
+
+    hooks:
+      after_asset:
+        - 'asset-generator downscale "$ASSET_OUTPUT" --width 256 --output-file "{{.OutputDir}}/thumb-{{.ID}}.png"'
+
+Candidates:
+  With --candidates N (or "candidates: N" on an asset), N images are
+  generated per asset into <group>/candidates/<asset-id>/ instead of the
+  canonical filename. Use 'pipeline select <asset-id> <n>' to promote one.
+  Later runs keep selected candidates instead of regenerating them; delete
+  the canonical file to regenerate the asset.`,
 	RunE: runPipeline,
 }
 
@@ -154,7 +322,7 @@ func init() {
 
 	// Required flags
 	pipelineCmd.Flags().StringVar(&pipelineFile, "file", "", "pipeline YAML file (required)")
-	pipelineCmd.Flags().StringVar(&pipelineOutputDir, "output-dir", "./pipeline-output", "output directory for generated assets")
+	pipelineCmd.PersistentFlags().StringVar(&pipelineOutputDir, "output-dir", "./pipeline-output", "output directory for generated assets")
 
 	// Generation parameters
 	pipelineCmd.Flags().Int64Var(&pipelineBaseSeed, "base-seed", -1, "base seed for reproducible generation (0 or -1 for random)")
@@ -172,23 +340,51 @@ func init() {
 	pipelineCmd.Flags().StringVar(&pipelineNegPrompt, "negative-prompt", "", "negative prompt for all generations")
 
 	// Pipeline control
-	pipelineCmd.Flags().BoolVar(&pipelineDryRun, "dry-run", false, "preview pipeline without generating")
+	pipelineCmd.Flags().BoolVar(&pipelineDryRun, "dry-run", false, "preview pipeline and estimate its duration without generating")
 	pipelineCmd.Flags().BoolVar(&pipelineContinueError, "continue-on-error", false, "continue processing if individual generations fail")
+	pipelineCmd.Flags().BoolVar(&pipelineWatch, "watch", false, "watch the pipeline file and regenerate assets whose prompt or parameters changed")
+	pipelineCmd.Flags().BoolVar(&pipelineLocked, "locked", false, "refuse to run unless every asset resolves as recorded in pipeline.lock, then compare outputs with it")
+	pipelineCmd.Flags().BoolVar(&pipelineReport, "report", false, "write an HTML contact sheet (report.html) to the output directory after the run")
+	pipelineCmd.Flags().StringVar(&pipelineSeedFlag, "seed-strategy", "", "seed derivation: index or hash (overrides seed_strategy in the pipeline file)")
+	pipelineCmd.Flags().StringSliceVar(&pipelineOnly, "only", []string{}, "only process assets matching these IDs, group names or glob patterns")
+	pipelineCmd.Flags().StringSliceVar(&pipelineSkip, "skip", []string{}, "skip assets matching these IDs, group names or glob patterns")
+	pipelineCmd.Flags().StringSliceVar(&pipelineTags, "tag", []string{}, "only process assets with any of these tags")
+	pipelineCmd.Flags().StringArrayVar(&pipelineServers, "server", []string{}, "generation server as URL[,weight=N][,concurrency=N]; weight sets preference, concurrency the parallel slots (repeatable; overrides servers in the config file)")
+	pipelineCmd.Flags().IntVar(&pipelineCandidates, "candidates", 0, "number of candidate images per asset (0 or 1 = generate the canonical image directly)")
 
 	// Postprocessing options
+	pipelineCmd.Flags().BoolVar(&pipelineRemoveBackground, "remove-background", false, "make flat image backgrounds transparent (saves PNG)")
+	pipelineCmd.Flags().StringVar(&pipelineRemoveBackgroundMode, "remove-background-mode", "flood", "background detection: flood (from the borders), key (everywhere)")
+	pipelineCmd.Flags().StringVar(&pipelineRemoveBackgroundColor, "remove-background-color", "", "background color, as a name or #rrggbb (default: sampled from the corners)")
+	pipelineCmd.Flags().IntVar(&pipelineRemoveBackgroundTolerance, "remove-background-tolerance", 10, "largest per-channel difference from the background color (0-255)")
+	pipelineCmd.Flags().IntVar(&pipelineRemoveBackgroundFeather, "remove-background-feather", 0, "soft edge width beyond the tolerance (0-255)")
 	pipelineCmd.Flags().BoolVar(&pipelineAutoCrop, "auto-crop", false, "automatically crop whitespace borders")
 	pipelineCmd.Flags().IntVar(&pipelineAutoCropThreshold, "auto-crop-threshold", 250, "whitespace detection threshold (0-255)")
 	pipelineCmd.Flags().IntVar(&pipelineAutoCropTolerance, "auto-crop-tolerance", 10, "tolerance for near-white colors")
 	pipelineCmd.Flags().BoolVar(&pipelineAutoCropPreserveAspect, "auto-crop-preserve-aspect", false, "preserve aspect ratio when auto-cropping")
+	pipelineCmd.Flags().StringVar(&pipelineAutoCropMode, "auto-crop-mode", "white", "background to crop: white, auto (corner color), color, alpha")
+	pipelineCmd.Flags().StringVar(&pipelineAutoCropColor, "auto-crop-color", "", "background color to crop, as a name or #rrggbb")
+	pipelineCmd.Flags().IntVar(&pipelineAutoCropPadding, "auto-crop-padding", 0, "margin in pixels to keep around the content")
+	pipelineCmd.Flags().Float64Var(&pipelineAutoCropPaddingPercent, "auto-crop-padding-percent", 0, "margin to keep, as a percentage of the larger content side")
+	pipelineCmd.Flags().IntVar(&pipelinePadWidth, "pad-width", 0, "pad the canvas to at least this width")
+	pipelineCmd.Flags().IntVar(&pipelinePadHeight, "pad-height", 0, "pad the canvas to at least this height")
+	pipelineCmd.Flags().StringVar(&pipelinePadAspect, "pad-aspect", "", "pad the canvas to an aspect ratio, such as 1:1 or 16:9")
+	pipelineCmd.Flags().IntVar(&pipelinePadMargin, "pad-margin", 0, "margin in pixels to add on every side")
+	pipelineCmd.Flags().StringVar(&pipelinePadAnchor, "pad-anchor", "center", "where to place the image when padding: center, top, bottom-left, ...")
+	pipelineCmd.Flags().StringVar(&pipelinePadFill, "pad-fill", "color", "how to fill the padding: color, edge, mirror")
+	pipelineCmd.Flags().StringVar(&pipelinePadBackground, "pad-background", "transparent", "padding color, as a name or #rrggbb")
 	pipelineCmd.Flags().IntVar(&pipelineDownscaleWidth, "downscale-width", 0, "downscale to this width (0=disabled)")
 	pipelineCmd.Flags().IntVar(&pipelineDownscaleHeight, "downscale-height", 0, "downscale to this height (0=disabled)")
 	pipelineCmd.Flags().Float64Var(&pipelineDownscalePercentage, "downscale-percentage", 0, "downscale by percentage (0=disabled)")
 	pipelineCmd.Flags().StringVar(&pipelineDownscaleFilter, "downscale-filter", "lanczos", "downscaling filter (lanczos, bilinear, nearest)")
+	pipelineCmd.Flags().BoolVar(&pipelineDownscaleLinear, "downscale-linear", false, "downscale in linear light instead of sRGB")
+	pipelineCmd.Flags().StringVar(&pipelineImageFormat, "image-format", "", "save images as png, jpeg, webp, gif, bmp or tiff (default: from the filename extension)")
 	// SkimmedCFG (Distilled CFG) options
 	pipelineCmd.Flags().BoolVar(&pipelineSkimmedCFG, "skimmed-cfg", false, "enable Skimmed CFG for improved quality and speed")
 	pipelineCmd.Flags().Float64Var(&pipelineSkimmedCFGScale, "skimmed-cfg-scale", 3.0, "Skimmed CFG scale value")
 	pipelineCmd.Flags().Float64Var(&pipelineSkimmedCFGStart, "skimmed-cfg-start", 0.0, "start percentage for Skimmed CFG (0.0-1.0)")
 	pipelineCmd.Flags().Float64Var(&pipelineSkimmedCFGEnd, "skimmed-cfg-end", 1.0, "end percentage for Skimmed CFG (0.0-1.0)")
+	addEventFlags(pipelineCmd)
 
 	pipelineCmd.MarkFlagRequired("file")
 }
@@ -211,15 +407,93 @@ func runPipeline(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load pipeline: %w", err)
 	}
 
+	if err := resolveSeedStrategy(spec); err != nil {
+		return err
+	}
+	if err := spec.Hooks.validate(); err != nil {
+		return err
+	}
+	pipelineHooks = spec.Hooks
+	if pipelineImageFormat != "" {
+		if pipelineImageFormat, err = processor.ParseFormat(pipelineImageFormat); err != nil {
+			return err
+		}
+	}
+	if pipelineAutoCrop {
+		if _, _, err := parseCropMode(pipelineAutoCropMode, pipelineAutoCropColor); err != nil {
+			return err
+		}
+	}
+	if _, err := parsePadOptions(pipelinePadWidth, pipelinePadHeight, pipelinePadAspect, pipelinePadMargin, pipelinePadAnchor, pipelinePadFill, pipelinePadBackground); err != nil {
+		return err
+	}
+	if pipelineRemoveBackground {
+		if err := validateRemoveBackground(pipelineRemoveBackgroundMode, pipelineRemoveBackgroundColor, pipelineImageFormat); err != nil {
+			return err
+		}
+		// Transparent assets are saved as PNG unless another format is asked for
+		if pipelineImageFormat == "" {
+			pipelineImageFormat = "png"
+		}
+	}
+
+	// Validate asset filters before doing any work
+	for _, pattern := range append(append([]string{}, pipelineOnly...), pipelineSkip...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid asset pattern '%s': %w", pattern, err)
+		}
+	}
+
 	// Calculate total work
 	totalAssets := countAssets(spec.Assets)
 
 	if !quiet {
+		if spec.legacy {
+			fmt.Fprintf(os.Stderr, "Detected legacy tarot format (use 'pipeline migrate' to convert it to the generic format)\n")
+		}
 		fmt.Fprintf(os.Stderr, "Pipeline loaded: %d total assets\n", totalAssets)
 		printGroupSummary(spec.Assets, "")
 		fmt.Fprintf(os.Stderr, "\n")
 	}
 
+	if isPipelineFiltered() {
+		totalAssets = len(filterJobs(planPipeline(spec.Assets, pipelineOutputDir)))
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Selected %d assets matching filters\n\n", totalAssets)
+		}
+	}
+
+	// In watch mode, keep the seeds of the previous session so that unchanged
+	// assets are not regenerated just because a new random seed was drawn
+	if pipelineWatch && (pipelineBaseSeed == -1 || pipelineBaseSeed == 0) {
+		if previous, err := loadPipelineManifest(pipelineOutputDir); err == nil && previous.BaseSeed != 0 {
+			pipelineBaseSeed = previous.BaseSeed
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Reusing base seed from manifest: %d\n\n", pipelineBaseSeed)
+			}
+		}
+	}
+
+	// A locked run must resolve exactly like the run that wrote the lockfile
+	var lock *PipelineLock
+	if pipelineLocked {
+		if pipelineWatch {
+			return fmt.Errorf("--locked cannot be combined with --watch")
+		}
+		if lock, err = loadPipelineLock(pipelineOutputDir); err != nil {
+			return err
+		}
+		if lock == nil {
+			return fmt.Errorf("--locked requires %s in %s (run the pipeline once without --locked to create it)", lockFileName, pipelineOutputDir)
+		}
+		if pipelineBaseSeed == -1 || pipelineBaseSeed == 0 {
+			pipelineBaseSeed = lock.BaseSeed
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Using base seed from %s: %d\n\n", lockFileName, pipelineBaseSeed)
+			}
+		}
+	}
+
 	// Generate random seed if not specified (both -1 and 0 trigger random seed)
 	if pipelineBaseSeed == -1 || pipelineBaseSeed == 0 {
 		pipelineBaseSeed = time.Now().UnixNano()
@@ -228,36 +502,129 @@ func runPipeline(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	pipelineTimings = loadPipelineTimings()
+
 	if pipelineDryRun {
 		fmt.Fprintf(os.Stderr, "DRY RUN - No assets will be generated\n\n")
 		return previewPipeline(spec)
 	}
 
+	if events, err = openEventStream(); err != nil {
+		return err
+	}
+	defer func() {
+		events.Close()
+		events = nil
+	}()
+
+	pipelinePool, err = newServerPool(ctx, pipelineServers)
+	if err != nil {
+		return err
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(pipelineOutputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	// Load the manifest from previous runs so selections are preserved
+	manifest, err := loadPipelineManifest(pipelineOutputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	manifest.SpecFile = pipelineFile
+	manifest.BaseSeed = pipelineBaseSeed
+	manifest.SeedStrategy = pipelineSeedStrategy
+
 	if !quiet {
 		fmt.Fprintf(os.Stderr, "Output directory: %s\n", pipelineOutputDir)
-		fmt.Fprintf(os.Stderr, "Base seed: %d\n", pipelineBaseSeed)
+		fmt.Fprintf(os.Stderr, "Base seed: %d (strategy: %s)\n", pipelineBaseSeed, pipelineSeedStrategy)
 		fmt.Fprintf(os.Stderr, "Dimensions: %dx%d\n", pipelineWidth, pipelineHeight)
 		fmt.Fprintf(os.Stderr, "Steps: %d, CFG Scale: %.1f\n\n", pipelineSteps, pipelineCfgScale)
 	}
 
-	// Track progress
-	completed := 0
-	failed := 0
+	if pipelineWatch {
+		return watchPipeline(ctx, manifest)
+	}
 
-	// Process all groups
-	for _, group := range spec.Assets {
-		c, f, err := processGroup(ctx, group, pipelineOutputDir, &completed, totalAssets, nil)
-		completed += c
-		failed += f
+	// Plan the full pipeline before filtering so seeds and paths match a full run
+	allJobs := planPipeline(spec.Assets, pipelineOutputDir)
+	jobs := filterJobs(allJobs)
+
+	lockServers := resolveLockServers(ctx)
+	if pipelineLocked {
+		if diffs := checkPipelineLock(lock, jobs, manifest, lockServers); len(diffs) > 0 {
+			fmt.Fprintf(os.Stderr, "Pipeline resolves differently from %s:\n", lockFileName)
+			for i, diff := range diffs {
+				if i == 20 {
+					fmt.Fprintf(os.Stderr, "  ... and %d more\n", len(diffs)-i)
+					break
+				}
+				fmt.Fprintf(os.Stderr, "  %s\n", diff)
+			}
+			return fmt.Errorf("refusing to run with --locked: %d differences from %s", len(diffs), lockFileName)
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "✓ All assets resolve as in %s\n\n", lockFileName)
+		}
+		warnUnknownModelHashes(lock, jobs)
 
-		if err != nil && !pipelineContinueError {
+		// Regenerate into a scratch directory, so the shipped outputs being
+		// checked are never overwritten
+		if jobs, manifest, err = lockedScratchRun(spec, jobs, manifest); err != nil {
 			return err
 		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Regenerating into %s\n\n", pipelineOutputDir)
+		}
+	} else if lock, err = loadPipelineLock(pipelineOutputDir); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: Ignoring unreadable lockfile: %v\n", err)
+		lock = nil
+	}
+
+	runStart := time.Now()
+	completed, failed, kept, err := processJobs(ctx, jobs, manifest)
+	events.Done(totalsPayload{Total: len(jobs), Completed: completed, Failed: failed, Skipped: kept}, runStart, err)
+
+	// Write the report even if the run stopped early, so failures can be reviewed
+	if pipelineReport {
+		if reportPath, reportErr := writePipelineReport(pipelineOutputDir, manifest); reportErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to write report: %v\n", reportErr)
+		} else if !quiet {
+			fmt.Fprintf(os.Stderr, "Report written to: %s\n", reportPath)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := runAfterPipelineHooks(ctx, completed, failed); err != nil {
+		return err
+	}
+
+	// A locked run checks its outputs against the lockfile instead of updating it
+	var different []string
+	if pipelineLocked {
+		var identical int
+		identical, different = compareWithLock(lock, jobs, manifest)
+		if !quiet && identical > 0 {
+			fmt.Fprintf(os.Stderr, "✓ %d assets are bit-for-bit identical to %s\n", identical, lockFileName)
+		}
+		for _, key := range different {
+			fmt.Fprintf(os.Stderr, "⚠ Warning: %s differs from %s\n", key, lockFileName)
+			for _, reason := range lock.Assets[key].Unpinned {
+				fmt.Fprintf(os.Stderr, "    possible cause: %s\n", reason)
+			}
+		}
+		// Keep the regenerated images only when there is something to inspect
+		if len(different) == 0 && failed == 0 && !pipelineReport {
+			if err := os.RemoveAll(pipelineOutputDir); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", pipelineOutputDir, err)
+			}
+		}
+	} else if err := updatePipelineLock(lock, jobs, allJobs, manifest, lockServers); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to write %s: %v\n", lockFileName, err)
 	}
 
 	// Summary
@@ -266,15 +633,24 @@ func runPipeline(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "Pipeline Complete!\n")
 		fmt.Fprintf(os.Stderr, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 		fmt.Fprintf(os.Stderr, "Total assets generated: %d/%d\n", completed, totalAssets)
+		if kept > 0 {
+			fmt.Fprintf(os.Stderr, "Kept selected candidates: %d\n", kept)
+		}
 		if failed > 0 {
 			fmt.Fprintf(os.Stderr, "Failed: %d\n", failed)
 		}
-		fmt.Fprintf(os.Stderr, "Output location: %s\n", pipelineOutputDir)
+		if _, err := os.Stat(pipelineOutputDir); err == nil {
+			fmt.Fprintf(os.Stderr, "Output location: %s\n", pipelineOutputDir)
+		}
+		printServerSummary(pipelinePool)
 	}
 
 	if failed > 0 && !pipelineContinueError {
 		return fmt.Errorf("pipeline completed with %d failures", failed)
 	}
+	if len(different) > 0 {
+		return fmt.Errorf("%d assets differ from %s (regenerated images are in %s)", len(different), lockFileName, pipelineOutputDir)
+	}
 
 	return nil
 }
@@ -301,80 +677,464 @@ func printGroupSummary(groups []AssetGroup, indent string) {
 	}
 }
 
-// processGroup processes a single asset group and its subgroups
-func processGroup(ctx context.Context, group AssetGroup, baseOutputDir string, completed *int, totalAssets int, parentMetadata map[string]interface{}) (int, int, error) {
-	groupCompleted := 0
-	groupFailed := 0
+// planPipeline flattens the group hierarchy into an ordered list of jobs,
+// resolving metadata, seeds and output paths for every asset.
+func planPipeline(groups []AssetGroup, baseOutputDir string) []pipelineJob {
+	var jobs []pipelineJob
+	planGroups(groups, baseOutputDir, "", "", nil, &jobs)
+	return jobs
+}
+
+// planGroups recursively appends the jobs for groups and their subgroups
+func planGroups(groups []AssetGroup, baseOutputDir, parentPath, parentDir string, parentMetadata map[string]interface{}, jobs *[]pipelineJob) {
+	for _, group := range groups {
+		groupPath := assetKey(parentPath, sanitizeFilename(group.Name))
+		groupMetadata := mergeMetadata(parentMetadata, group.Metadata)
+		groupOutputDir := filepath.Join(baseOutputDir, group.OutputDir)
+		groupDir := path.Join(parentDir, filepath.ToSlash(group.OutputDir))
+
+		for i, asset := range group.Assets {
+			key := assetKey(groupPath, asset.ID)
+
+			// Merge group metadata with asset metadata
+			assetMetadata := mergeMetadata(groupMetadata, asset.Metadata)
+
+			// Determine filename
+			filename := asset.Filename
+			if filename == "" {
+				filename = sanitizeFilename(asset.ID) + ".png"
+			}
+			if pipelineImageFormat != "" {
+				filename = processor.ReplaceExtension(filename, pipelineImageFormat)
+			}
+
+			*jobs = append(*jobs, pipelineJob{
+				Asset:      asset,
+				GroupName:  group.Name,
+				GroupPath:  groupPath,
+				Index:      i,
+				SeedOffset: group.SeedOffset,
+				Seed:       assetSeed(group.SeedOffset, i, key),
+				Prompt:     buildEnhancedPrompt(asset.Prompt, assetMetadata),
+				Metadata:   assetMetadata,
+				OutputDir:  groupOutputDir,
+				RelDir:     groupDir,
+				OutputPath: filepath.Join(groupOutputDir, filename),
+			})
+		}
 
-	// Merge parent metadata with group metadata
-	groupMetadata := mergeMetadata(parentMetadata, group.Metadata)
+		planGroups(group.Subgroups, groupOutputDir, groupPath, groupDir, groupMetadata, jobs)
+	}
+}
 
-	// Create group output directory
-	groupOutputDir := filepath.Join(baseOutputDir, group.OutputDir)
-	if err := os.MkdirAll(groupOutputDir, 0755); err != nil {
-		return 0, 0, fmt.Errorf("failed to create group directory %s: %w", groupOutputDir, err)
+// resolveSeedStrategy sets the active seed strategy. The command line takes
+// precedence over the pipeline file; index-based seeding is the default.
+func resolveSeedStrategy(spec *PipelineSpec) error {
+	strategy := pipelineSeedFlag
+	if strategy == "" {
+		strategy = spec.SeedStrategy
+	}
+	if strategy == "" {
+		strategy = seedStrategyIndex
+	}
+	if strategy != seedStrategyIndex && strategy != seedStrategyHash {
+		return fmt.Errorf("invalid seed strategy '%s' (valid options: %s, %s)", strategy, seedStrategyIndex, seedStrategyHash)
 	}
+	pipelineSeedStrategy = strategy
+	return nil
+}
 
-	if len(group.Assets) > 0 && !quiet {
-		fmt.Fprintf(os.Stderr, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-		fmt.Fprintf(os.Stderr, "Processing: %s (%d assets)\n", group.Name, len(group.Assets))
-		fmt.Fprintf(os.Stderr, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+// isPipelineFiltered reports whether any asset selection flags are set
+func isPipelineFiltered() bool {
+	return len(pipelineOnly) > 0 || len(pipelineSkip) > 0 || len(pipelineTags) > 0
+}
+
+// filterJobs applies --only, --skip and --tag to a planned pipeline.
+// Jobs are filtered after planning so that seeds and paths are unaffected.
+func filterJobs(jobs []pipelineJob) []pipelineJob {
+	if !isPipelineFiltered() {
+		return jobs
 	}
 
-	// Process assets in this group
-	for i, asset := range group.Assets {
-		if err := ctx.Err(); err != nil {
-			return groupCompleted, groupFailed, fmt.Errorf("pipeline cancelled: %w", err)
+	var selected []pipelineJob
+	for _, job := range jobs {
+		if len(pipelineOnly) > 0 && !jobMatchesAny(job, pipelineOnly) {
+			continue
+		}
+		if len(pipelineTags) > 0 && !jobHasAnyTag(job, pipelineTags) {
+			continue
+		}
+		if jobMatchesAny(job, pipelineSkip) {
+			continue
 		}
+		selected = append(selected, job)
+	}
+	return selected
+}
 
-		// Merge group metadata with asset metadata
-		assetMetadata := mergeMetadata(groupMetadata, asset.Metadata)
+// jobMatchesAny reports whether a job matches any of the patterns. A pattern
+// matches the asset ID, the full ID path, or the path of any enclosing group,
+// where group paths are built from either group names or output directories.
+func jobMatchesAny(job pipelineJob, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, job.Asset.ID) {
+			return true
+		}
 
-		// Calculate seed
-		seed := pipelineBaseSeed + group.SeedOffset + int64(i)
+		for _, groupPath := range []string{job.GroupPath, job.RelDir} {
+			if groupPath == "" || groupPath == "." {
+				continue
+			}
+			if matchPattern(pattern, assetKey(groupPath, job.Asset.ID)) {
+				return true
+			}
 
-		// Determine filename
-		filename := asset.Filename
-		if filename == "" {
-			filename = sanitizeFilename(asset.ID) + ".png"
+			// Match enclosing groups by full path ("characters/heroes") or bare name ("heroes")
+			segments := strings.Split(groupPath, "/")
+			for i, segment := range segments {
+				if matchPattern(pattern, segment) || matchPattern(pattern, strings.Join(segments[:i+1], "/")) {
+					return true
+				}
+			}
 		}
-		outputPath := filepath.Join(groupOutputDir, filename)
+	}
+	return false
+}
 
-		if !quiet {
-			fmt.Fprintf(os.Stderr, "[%d/%d] Generating: %s\n", *completed+1, totalAssets, asset.Name)
+// matchPattern matches a glob pattern against a name, treating malformed
+// patterns (rejected up front in runPipeline) as non-matching
+func matchPattern(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// jobHasAnyTag reports whether the job's asset carries any of the given tags
+func jobHasAnyTag(job pipelineJob, tags []string) bool {
+	for _, tag := range tags {
+		for _, assetTag := range job.Asset.Tags {
+			if strings.EqualFold(tag, assetTag) {
+				return true
+			}
 		}
+	}
+	return false
+}
+
+// assetSeed derives the seed of an asset according to the active seed strategy
+func assetSeed(seedOffset int64, index int, key string) int64 {
+	if pipelineSeedStrategy == seedStrategyHash {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		// Keep the hash component within 31 bits so seeds stay in a range
+		// every backend accepts and the sum cannot overflow
+		return pipelineBaseSeed + seedOffset + int64(h.Sum64()&0x7fffffff)
+	}
+	return pipelineBaseSeed + seedOffset + int64(index)
+}
+
+// assetKey joins a group path and a name into a slash-separated ID path
+func assetKey(groupPath, name string) string {
+	if groupPath == "" {
+		return name
+	}
+	return groupPath + "/" + name
+}
 
-		// Build enhanced prompt with metadata
-		enhancedPrompt := buildEnhancedPrompt(asset.Prompt, assetMetadata)
+// processJobs generates every job in order, recording results in the manifest.
+// Jobs run concurrently up to the capacity of the server pool; with a single
+// server they run one at a time. It returns the number of completed, failed
+// and kept (previously selected) assets.
+func processJobs(ctx context.Context, jobs []pipelineJob, manifest *PipelineManifest) (int, int, int, error) {
+	var (
+		mu        sync.Mutex // Guards the counters, manifest, ETA and output below
+		wg        sync.WaitGroup
+		completed int
+		failed    int
+		kept      int
+		firstErr  error
+	)
+	eta := newRunETA(jobs)
+
+	// Show a progress bar per running generation
+	pipelineDisplay = newProgressDisplay()
+	defer func() {
+		pipelineDisplay.Stop()
+		pipelineDisplay = nil
+	}()
+
+	workers := 1
+	if pipelinePool != nil {
+		workers = pipelinePool.Capacity()
+	}
+	slots := make(chan struct{}, workers)
+
+	// Track groups so their hooks run once the last asset is done, which with
+	// several workers is not necessarily the last one started
+	groupRemaining := make(map[string]int)
+	groupCompleted := make(map[string]int)
+	groupFailed := make(map[string]int)
+	for _, job := range jobs {
+		groupRemaining[job.GroupPath]++
+	}
 
-		if err := generateAsset(ctx, enhancedPrompt, asset.Name, outputPath, seed, assetMetadata); err != nil {
-			groupFailed++
-			if pipelineContinueError {
-				fmt.Fprintf(os.Stderr, "  ⚠ Warning: Failed to generate %s: %v\n", asset.Name, err)
+	// finish records the outcome of job i; mu must be held
+	finish := func(i int, entry *ManifestAsset, err error, took time.Duration) {
+		job := jobs[i]
+		if entry != nil {
+			manifest.Assets[job.Key()] = entry
+			if saveErr := manifest.Save(pipelineOutputDir); saveErr != nil {
+				fmt.Fprintf(stderr, "  ⚠ Warning: Failed to save manifest: %v\n", saveErr)
+			}
+			savePipelineTimings()
+
+			if err != nil {
+				failed++
+				groupFailed[job.GroupPath]++
+				events.Emit(event{
+					Event:     eventAssetFailed,
+					ID:        job.Key(),
+					Name:      job.Asset.Name,
+					Server:    entry.Server,
+					Error:     err.Error(),
+					ElapsedMS: took.Milliseconds(),
+				})
+				if pipelineContinueError {
+					fmt.Fprintf(stderr, "  ⚠ Warning: Failed to generate %s: %v\n", job.Asset.Name, err)
+				} else if firstErr == nil {
+					firstErr = fmt.Errorf("failed to generate %s: %w", job.Asset.Name, err)
+				}
 			} else {
-				return groupCompleted, groupFailed, fmt.Errorf("failed to generate %s: %w", asset.Name, err)
+				completed++
+				groupCompleted[job.GroupPath]++
+			}
+			fmt.Fprintln(stderr)
+		}
+		eta.Done(i, took)
+
+		// Run group hooks once the last asset of the group is done
+		groupRemaining[job.GroupPath]--
+		if groupRemaining[job.GroupPath] == 0 && len(pipelineHooks.AfterGroup) > 0 && firstErr == nil {
+			hc := hookContext{
+				Event:     hookAfterGroup,
+				Group:     job.GroupPath,
+				GroupName: job.GroupName,
+				OutputDir: job.OutputDir,
+				Completed: groupCompleted[job.GroupPath],
+				Failed:    groupFailed[job.GroupPath],
+			}
+			if err := runHooks(ctx, pipelineHooks.AfterGroup, hc); err != nil {
+				if !pipelineContinueError {
+					firstErr = err
+				} else {
+					fmt.Fprintf(stderr, "⚠ Warning: %v\n\n", err)
+				}
+			}
+		}
+	}
+
+	currentGroup := ""
+	for i, job := range jobs {
+		// Wait for a free worker before announcing anything, so that output
+		// stays in order when jobs run one at a time
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		mu.Lock()
+		if firstErr != nil {
+			mu.Unlock()
+			break
+		}
+
+		// Announce each group as we enter it
+		if i == 0 || job.GroupPath != currentGroup {
+			currentGroup = job.GroupPath
+			if err := os.MkdirAll(job.OutputDir, 0755); err != nil {
+				firstErr = fmt.Errorf("failed to create group directory %s: %w", job.OutputDir, err)
+				mu.Unlock()
+				break
+			}
+			if !quiet {
+				fmt.Fprintf(stderr, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+				fmt.Fprintf(stderr, "Processing: %s (%d assets)\n", job.GroupName, groupRemaining[job.GroupPath])
+				fmt.Fprintf(stderr, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 			}
-		} else {
-			groupCompleted++
-			*completed++
+		}
+
+		// Keep candidates that were already promoted with 'pipeline select'
+		if isKeptSelection(job, manifest) {
+			kept++
 			if !quiet {
-				fmt.Fprintf(os.Stderr, "  ✓ Saved to: %s\n", outputPath)
+				fmt.Fprintf(stderr, "[%d/%d] Keeping selected candidate %d: %s\n\n", i+1, len(jobs), manifest.Assets[job.Key()].Selected, job.Asset.Name)
 			}
+			finish(i, nil, nil, 0)
+			mu.Unlock()
+			<-slots
+			continue
 		}
-		fmt.Fprintln(os.Stderr)
+
+		if !quiet {
+			fmt.Fprintf(stderr, "[%d/%d] Generating: %s%s\n", i+1, len(jobs), job.Asset.Name, eta.String())
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(i int, job pipelineJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			entry, err := processJob(ctx, job)
+
+			mu.Lock()
+			defer mu.Unlock()
+			finish(i, entry, err, time.Since(start))
+		}(i, job)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return completed, failed, kept, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return completed, failed, kept, fmt.Errorf("pipeline cancelled: %w", err)
 	}
+	return completed, failed, kept, nil
+}
 
-	// Process subgroups
-	for _, subgroup := range group.Subgroups {
-		c, f, err := processGroup(ctx, subgroup, groupOutputDir, completed, totalAssets, groupMetadata)
-		groupCompleted += c
-		groupFailed += f
-		if err != nil && !pipelineContinueError {
-			return groupCompleted, groupFailed, err
+// isKeptSelection reports whether a job's asset has a selected candidate that
+// is still present and must not be regenerated
+func isKeptSelection(job pipelineJob, manifest *PipelineManifest) bool {
+	entry := manifest.Assets[job.Key()]
+	if entry == nil || entry.Selected == 0 {
+		return false
+	}
+	_, err := os.Stat(job.OutputPath)
+	return err == nil
+}
+
+// runAfterPipelineHooks runs the after_pipeline hooks with the totals of a run
+func runAfterPipelineHooks(ctx context.Context, completed, failed int) error {
+	hc := hookContext{Event: hookAfterPipeline, Completed: completed, Failed: failed}
+	return runHooks(ctx, pipelineHooks.AfterPipeline, hc)
+}
+
+// processJob generates a single asset, or its candidates when more than one
+// image was requested, and returns its manifest entry with the outcome. The
+// caller adds the entry to the manifest, so jobs can run concurrently.
+func processJob(ctx context.Context, job pipelineJob) (*ManifestAsset, error) {
+	entry := newManifestAsset(job, pipelineOutputDir)
+	entry.Parameters = pipelineParameters(job.Seed)
+	start := time.Now()
+	defer func() {
+		entry.DurationMS = time.Since(start).Milliseconds()
+	}()
+
+	// A failing hook fails the asset, like a failed generation
+	if err := runHooks(ctx, pipelineHooks.BeforeAsset, assetHookContext(hookBeforeAsset, job, nil)); err != nil {
+		entry.Status = manifestStatusFailed
+		entry.Error = err.Error()
+		return entry, err
+	}
+
+	if err := generateJob(ctx, job, entry); err != nil {
+		return entry, err
+	}
+
+	if err := runHooks(ctx, pipelineHooks.AfterAsset, assetHookContext(hookAfterAsset, job, entry)); err != nil {
+		entry.Status = manifestStatusFailed
+		entry.Error = err.Error()
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// generateJob generates the canonical image of a job, or its candidates,
+// and updates the job's manifest entry
+func generateJob(ctx context.Context, job pipelineJob, entry *ManifestAsset) error {
+	count := jobCandidates(job)
+	if count <= 1 {
+		server, err := generateAsset(ctx, job.Key(), job.Prompt, job.Asset.Name, job.OutputPath, job.Seed, job.Metadata)
+		if err != nil {
+			entry.Status = manifestStatusFailed
+			entry.Error = err.Error()
+			return err
 		}
+		entry.Status = manifestStatusCompleted
+		entry.Server = server
+		if !quiet {
+			fmt.Fprintf(stderr, "  ✓ Saved to: %s\n", job.OutputPath)
+		}
+		return nil
 	}
 
-	return groupCompleted, groupFailed, nil
+	// Generate candidates into candidates/<asset-id>/ next to the canonical file
+	for n := 1; n <= count; n++ {
+		seed := candidateSeed(job, n)
+		candidatePath := candidatePath(job, n)
+		if err := os.MkdirAll(filepath.Dir(candidatePath), 0755); err != nil {
+			entry.Status = manifestStatusFailed
+			entry.Error = err.Error()
+			return fmt.Errorf("failed to create candidates directory: %w", err)
+		}
+
+		server, err := generateAsset(ctx, job.Key(), job.Prompt, job.Asset.Name, candidatePath, seed, job.Metadata)
+		if err != nil {
+			entry.Status = manifestStatusFailed
+			entry.Error = fmt.Sprintf("candidate %d: %v", n, err)
+			return fmt.Errorf("candidate %d: %w", n, err)
+		}
+
+		entry.Candidates = append(entry.Candidates, ManifestCandidate{
+			Index:  n,
+			Seed:   seed,
+			Output: manifestRelPath(pipelineOutputDir, candidatePath),
+			Server: server,
+		})
+		if !quiet {
+			fmt.Fprintf(stderr, "  ✓ Candidate %d/%d saved to: %s\n", n, count, candidatePath)
+		}
+	}
+
+	entry.Status = manifestStatusCandidates
+	return nil
+}
+
+// jobCandidates returns the number of candidates to generate for a job;
+// values of 0 or 1 mean the canonical image is generated directly
+func jobCandidates(job pipelineJob) int {
+	if job.Asset.Candidates > 0 {
+		return job.Asset.Candidates
+	}
+	return pipelineCandidates
+}
+
+// candidateSeed returns the seed for the n-th (1-based) candidate of a job.
+// The first candidate uses the asset's own seed; the others hash the asset
+// key and candidate index over the same 31-bit range as the hash strategy,
+// so they are as unlikely to collide with other assets' seeds as those are
+// with each other.
+func candidateSeed(job pipelineJob, n int) int64 {
+	if n <= 1 {
+		return job.Seed
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s#candidate-%d", job.Key(), n)
+	return pipelineBaseSeed + job.SeedOffset + int64(h.Sum64()&0x7fffffff)
+}
+
+// candidatePath returns the file path of the n-th (1-based) candidate of a job
+func candidatePath(job pipelineJob, n int) string {
+	base := filepath.Base(job.OutputPath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	return filepath.Join(job.OutputDir, "candidates", sanitizeFilename(job.Asset.ID), fmt.Sprintf("%s-%d%s", name, n, ext))
 }
 
 // mergeMetadata merges parent metadata with child metadata (child takes precedence)
@@ -404,10 +1164,17 @@ func buildEnhancedPrompt(basePrompt string, metadata map[string]interface{}) str
 		return basePrompt
 	}
 
-	// Collect metadata values as strings
+	// Collect metadata values as strings, in key order so that the
+	// resulting prompt is identical from one run to the next
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	var metadataParts []string
-	for _, v := range metadata {
-		if str, ok := v.(string); ok && str != "" {
+	for _, k := range keys {
+		if str, ok := metadata[k].(string); ok && str != "" {
 			metadataParts = append(metadataParts, str)
 		}
 	}
@@ -431,11 +1198,120 @@ func loadPipelineSpec(filename string) (*PipelineSpec, error) {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Convert the legacy tarot format (major_arcana/minor_arcana) to asset groups
+	legacyGroups, err := parseLegacyTarotSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	if legacyGroups != nil {
+		if len(spec.Assets) > 0 {
+			return nil, fmt.Errorf("pipeline file mixes 'assets' with the legacy 'major_arcana'/'minor_arcana' format")
+		}
+		spec.Assets = legacyGroups
+		spec.legacy = true
+	}
+
 	return &spec, nil
 }
 
-func generateAsset(ctx context.Context, prompt, name, outputPath string, seed int64, metadata map[string]interface{}) error {
-	// Build full prompt with style prefix and suffix
+// generateAsset generates and downloads one image on a server of the pool and
+// returns the URL of that server. key is the asset's ID path, which decides
+// the server it prefers.
+func generateAsset(ctx context.Context, key, prompt, name, outputPath string, seed int64, metadata map[string]interface{}) (string, error) {
+	// Build generation request
+	req := &client.GenerationRequest{
+		Prompt:     applyPipelineStyle(prompt),
+		Parameters: pipelineParameters(seed),
+	}
+
+	if pipelineModel != "" {
+		req.Model = pipelineModel
+	}
+
+	task := pipelineDisplay.Start(name)
+	task.Attach(req)
+	defer task.Done()
+	ev := events.Asset(key, name)
+	ev.Attach(req)
+
+	// Generate and download on the same server, since generated images are
+	// only available from the server that made them
+	var server string
+	err := pipelinePool.Do(ctx, key, func(c *client.AssetClient) error {
+		server = c.BaseURL()
+		ev.SetServer(server)
+
+		// Time only the generation itself: waiting for a slot and
+		// downloading are not part of the server's generation time
+		start := time.Now()
+		result, err := c.GenerateImage(ctx, req)
+		if err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+
+		if len(result.ImagePaths) == 0 {
+			return fmt.Errorf("no images generated")
+		}
+		recordPipelineTiming(time.Since(start))
+		ev.ImageReady(result.ImagePaths)
+
+		// Merge metadata for download
+		downloadMetadata := map[string]interface{}{
+			"prompt": prompt,
+			"name":   name,
+			"seed":   seed,
+		}
+		for k, v := range metadata {
+			downloadMetadata[k] = v
+		}
+
+		// Download with postprocessing options
+		opts := &client.DownloadOptions{
+			OutputDir:        filepath.Dir(outputPath),
+			FilenameTemplate: filepath.Base(outputPath),
+			Metadata:         downloadMetadata,
+			// Auto-crop options
+			RemoveBackground:          pipelineRemoveBackground,
+			RemoveBackgroundMode:      pipelineRemoveBackgroundMode,
+			RemoveBackgroundColor:     pipelineRemoveBackgroundColor,
+			RemoveBackgroundTolerance: uint8(pipelineRemoveBackgroundTolerance),
+			RemoveBackgroundFeather:   uint8(pipelineRemoveBackgroundFeather),
+			AutoCrop:                  pipelineAutoCrop,
+			AutoCropThreshold:         uint8(pipelineAutoCropThreshold),
+			AutoCropTolerance:         uint8(pipelineAutoCropTolerance),
+			AutoCropPreserveAspect:    pipelineAutoCropPreserveAspect,
+			AutoCropMode:              pipelineAutoCropMode,
+			AutoCropColor:             pipelineAutoCropColor,
+			AutoCropPadding:           pipelineAutoCropPadding,
+			AutoCropPaddingPercent:    pipelineAutoCropPaddingPercent,
+			// Downscale options
+			PadWidth:            pipelinePadWidth,
+			PadHeight:           pipelinePadHeight,
+			PadAspect:           pipelinePadAspect,
+			PadMargin:           pipelinePadMargin,
+			PadAnchor:           pipelinePadAnchor,
+			PadFill:             pipelinePadFill,
+			PadBackground:       pipelinePadBackground,
+			DownscaleWidth:      pipelineDownscaleWidth,
+			DownscaleHeight:     pipelineDownscaleHeight,
+			DownscalePercentage: pipelineDownscalePercentage,
+			DownscaleFilter:     pipelineDownscaleFilter,
+			DownscaleLinear:     pipelineDownscaleLinear,
+			Format:              pipelineImageFormat,
+		}
+		ev.AttachDownload(opts)
+
+		_, err = c.DownloadImagesWithOptions(ctx, result.ImagePaths, opts)
+		if err != nil {
+			return fmt.Errorf("download failed: %w", err)
+		}
+		return nil
+	})
+	return server, err
+}
+
+// applyPipelineStyle wraps a prompt with the configured style prefix and suffix
+func applyPipelineStyle(prompt string) string {
 	fullPrompt := prompt
 	if pipelineStylePrefix != "" {
 		fullPrompt = pipelineStylePrefix + ", " + fullPrompt
@@ -443,92 +1319,51 @@ func generateAsset(ctx context.Context, prompt, name, outputPath string, seed in
 	if pipelineStyleSuffix != "" {
 		fullPrompt = fullPrompt + ", " + pipelineStyleSuffix
 	}
+	return fullPrompt
+}
 
-	// Build generation request
-	req := &client.GenerationRequest{
-		Prompt: fullPrompt,
-		Parameters: map[string]interface{}{
-			"steps":     pipelineSteps,
-			"width":     pipelineWidth,
-			"height":    pipelineHeight,
-			"cfgscale":  pipelineCfgScale,
-			"sampler":   pipelineSampler,
-			"scheduler": pipelineScheduler,
-			"seed":      seed,
-			"images":    1, // Always generate one at a time for pipelines
-		},
+// pipelineParameters builds the generation parameters shared by every asset
+func pipelineParameters(seed int64) map[string]interface{} {
+	params := map[string]interface{}{
+		"steps":     pipelineSteps,
+		"width":     pipelineWidth,
+		"height":    pipelineHeight,
+		"cfgscale":  pipelineCfgScale,
+		"sampler":   pipelineSampler,
+		"scheduler": pipelineScheduler,
+		"seed":      seed,
+		"images":    1, // Always generate one at a time for pipelines
 	}
 
 	if pipelineNegPrompt != "" {
-		req.Parameters["negative_prompt"] = pipelineNegPrompt
+		params["negative_prompt"] = pipelineNegPrompt
 	}
 
 	// Add SkimmedCFG parameters if enabled
 	if pipelineSkimmedCFG {
-		req.Parameters["skimmedcfg"] = true
-		req.Parameters["skimmedcfgscale"] = pipelineSkimmedCFGScale
+		params["skimmedcfg"] = true
+		params["skimmedcfgscale"] = pipelineSkimmedCFGScale
 		if pipelineSkimmedCFGStart != 0.0 {
-			req.Parameters["skimmedcfgstart"] = pipelineSkimmedCFGStart
+			params["skimmedcfgstart"] = pipelineSkimmedCFGStart
 		}
 		if pipelineSkimmedCFGEnd != 1.0 {
-			req.Parameters["skimmedcfgend"] = pipelineSkimmedCFGEnd
+			params["skimmedcfgend"] = pipelineSkimmedCFGEnd
 		}
 	}
 
-	if pipelineModel != "" {
-		req.Model = pipelineModel
-	}
-
-	// Generate image
-	result, err := assetClient.GenerateImage(ctx, req)
-	if err != nil {
-		return fmt.Errorf("generation failed: %w", err)
-	}
-
-	if len(result.ImagePaths) == 0 {
-		return fmt.Errorf("no images generated")
-	}
-
-	// Merge metadata for download
-	downloadMetadata := map[string]interface{}{
-		"prompt": prompt,
-		"name":   name,
-		"seed":   seed,
-	}
-	for k, v := range metadata {
-		downloadMetadata[k] = v
-	}
-
-	// Download with postprocessing options
-	opts := &client.DownloadOptions{
-		OutputDir:        filepath.Dir(outputPath),
-		FilenameTemplate: filepath.Base(outputPath),
-		Metadata:         downloadMetadata,
-		// Auto-crop options
-		AutoCrop:               pipelineAutoCrop,
-		AutoCropThreshold:      uint8(pipelineAutoCropThreshold),
-		AutoCropTolerance:      uint8(pipelineAutoCropTolerance),
-		AutoCropPreserveAspect: pipelineAutoCropPreserveAspect,
-		// Downscale options
-		DownscaleWidth:      pipelineDownscaleWidth,
-		DownscaleHeight:     pipelineDownscaleHeight,
-		DownscalePercentage: pipelineDownscalePercentage,
-		DownscaleFilter:     pipelineDownscaleFilter,
-	}
-
-	_, err = assetClient.DownloadImagesWithOptions(ctx, result.ImagePaths, opts)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
-	}
-
-	return nil
+	return params
 }
 
 func previewPipeline(spec *PipelineSpec) error {
 	fmt.Println("Pipeline Preview:")
 	fmt.Println()
 
-	previewGroups(spec.Assets, "", nil)
+	planned := filterJobs(planPipeline(spec.Assets, pipelineOutputDir))
+	jobs := make(map[string]pipelineJob)
+	for _, job := range planned {
+		jobs[job.Key()] = job
+	}
+	previewGroups(spec.Assets, "", "", nil, jobs)
 
 	fmt.Println()
 	fmt.Println("Generation Parameters:")
@@ -537,6 +1372,7 @@ func previewPipeline(spec *PipelineSpec) error {
 	fmt.Printf("  CFG Scale: %.1f\n", pipelineCfgScale)
 	fmt.Printf("  Sampler: %s\n", pipelineSampler)
 	fmt.Printf("  Scheduler: %s\n", pipelineScheduler)
+	fmt.Printf("  Seed Strategy: %s (base seed: %d)\n", pipelineSeedStrategy, pipelineBaseSeed)
 	if pipelineModel != "" {
 		fmt.Printf("  Model: %s\n", pipelineModel)
 	}
@@ -549,12 +1385,35 @@ func previewPipeline(spec *PipelineSpec) error {
 	if pipelineNegPrompt != "" {
 		fmt.Printf("  Negative Prompt: %s\n", pipelineNegPrompt)
 	}
+	if pipelineCandidates > 1 {
+		fmt.Printf("  Candidates per asset: %d\n", pipelineCandidates)
+	}
+
+	if !spec.Hooks.empty() {
+		fmt.Println()
+		fmt.Println("Hooks:")
+		for _, event := range spec.Hooks.events() {
+			for _, hook := range event.Hooks {
+				fmt.Printf("  %s: %s\n", event.Name, hook.String())
+			}
+		}
+	}
+
+	// Previous selections in the output directory are kept, so leave them out of the estimate
+	manifest, _ := loadPipelineManifest(pipelineOutputDir)
+	printPipelineEstimate(planned, manifest)
 
 	return nil
 }
 
-func previewGroups(groups []AssetGroup, indent string, parentMetadata map[string]interface{}) {
+func previewGroups(groups []AssetGroup, indent, parentPath string, parentMetadata map[string]interface{}, jobs map[string]pipelineJob) {
 	for _, group := range groups {
+		// Skip groups with nothing selected by --only/--skip/--tag
+		groupPath := assetKey(parentPath, sanitizeFilename(group.Name))
+		if !groupHasJobs(groupPath, jobs) {
+			continue
+		}
+
 		fmt.Printf("%s%s (%s):\n", indent, group.Name, group.OutputDir)
 
 		// Merge metadata
@@ -564,14 +1423,18 @@ func previewGroups(groups []AssetGroup, indent string, parentMetadata map[string
 		}
 
 		// Preview assets
-		for i, asset := range group.Assets {
-			seed := pipelineBaseSeed + group.SeedOffset + int64(i)
-			assetMetadata := mergeMetadata(groupMetadata, asset.Metadata)
-			enhancedPrompt := buildEnhancedPrompt(asset.Prompt, assetMetadata)
+		for _, asset := range group.Assets {
+			job, ok := jobs[assetKey(groupPath, asset.ID)]
+			if !ok {
+				continue
+			}
 
-			fmt.Printf("%s  [%s] %s (seed: %d)\n", indent, asset.ID, asset.Name, seed)
+			fmt.Printf("%s  [%s] %s (seed: %d)\n", indent, asset.ID, asset.Name, job.Seed)
+			if asset.Candidates > 1 {
+				fmt.Printf("%s    Candidates: %d\n", indent, asset.Candidates)
+			}
 			if verbose {
-				fmt.Printf("%s    Prompt: %s\n", indent, enhancedPrompt)
+				fmt.Printf("%s    Prompt: %s\n", indent, job.Prompt)
 				if asset.Filename != "" {
 					fmt.Printf("%s    Filename: %s\n", indent, asset.Filename)
 				}
@@ -581,13 +1444,23 @@ func previewGroups(groups []AssetGroup, indent string, parentMetadata map[string
 		// Preview subgroups
 		if len(group.Subgroups) > 0 {
 			fmt.Println()
-			previewGroups(group.Subgroups, indent+"  ", groupMetadata)
+			previewGroups(group.Subgroups, indent+"  ", groupPath, groupMetadata, jobs)
 		}
 
 		fmt.Println()
 	}
 }
 
+// groupHasJobs reports whether any planned job belongs to the group or its subgroups
+func groupHasJobs(groupPath string, jobs map[string]pipelineJob) bool {
+	for _, job := range jobs {
+		if job.GroupPath == groupPath || strings.HasPrefix(job.GroupPath, groupPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func sanitizeFilename(name string) string {
 	// Convert to lowercase and replace spaces with underscores
 	name = strings.ToLower(name)
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// timingsFileName is the file in ~/.asset-generator where generation timings are recorded
+	timingsFileName = "timings.json"
+	// maxTimingSamples is the number of recent samples kept per model
+	maxTimingSamples = 200
+	// defaultModelKey records timings of runs that use the server's default model
+	defaultModelKey = "(default)"
+)
+
+// pipelineTimings records how long generations take, for estimates and ETAs.
+// It is nil when timings are not available.
+var pipelineTimings *timingStore
+
+// timingSample is the wall-clock time of a single generation on the server,
+// excluding waiting for a slot and downloading
+type timingSample struct {
+	Steps      int       `json:"steps"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	DurationMS int64     `json:"duration_ms"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// units is the amount of work of a sample, assuming generation time grows
+// with steps times pixels
+func (s timingSample) units() float64 {
+	return float64(s.Steps) * float64(s.Width) * float64(s.Height)
+}
+
+// timingStore holds recent generation timings per model. It is safe for
+// concurrent use.
+type timingStore struct {
+	Models map[string][]timingSample `json:"models"`
+
+	mu    sync.Mutex
+	path  string
+	dirty bool
+}
+
+// timingsPath returns the path of the timings file in the user's config directory
+func timingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".asset-generator", timingsFileName), nil
+}
+
+// loadTimingStore reads recorded timings. A missing file gives an empty store.
+func loadTimingStore(path string) (*timingStore, error) {
+	store := &timingStore{Models: make(map[string][]timingSample), path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read timings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse timings: %w", err)
+	}
+	if store.Models == nil {
+		store.Models = make(map[string][]timingSample)
+	}
+
+	return store, nil
+}
+
+// loadPipelineTimings loads the user's timings file, warning instead of
+// failing since estimates are optional
+func loadPipelineTimings() *timingStore {
+	path, err := timingsPath()
+	if err == nil {
+		var store *timingStore
+		if store, err = loadTimingStore(path); err == nil {
+			return store
+		}
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: Timing estimates unavailable: %v\n", err)
+	}
+	return nil
+}
+
+// Record adds a generation timing for a model
+func (s *timingStore) Record(model string, steps, width, height int, duration time.Duration) {
+	if model == "" {
+		model = defaultModelKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := append(s.Models[model], timingSample{
+		Steps:      steps,
+		Width:      width,
+		Height:     height,
+		DurationMS: duration.Milliseconds(),
+		RecordedAt: time.Now(),
+	})
+	if len(samples) > maxTimingSamples {
+		samples = samples[len(samples)-maxTimingSamples:]
+	}
+	s.Models[model] = samples
+	s.dirty = true
+}
+
+// Save writes the timings file if new samples were recorded
+func (s *timingStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal timings: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create timings directory: %w", err)
+	}
+
+	// Write to file (use temp file + rename for atomicity)
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write timings: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath) // Clean up temp file on error
+		return fmt.Errorf("failed to rename timings: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// Samples returns the samples used to estimate a model's timings: the
+// model's own samples, or those of every model if it was never timed
+func (s *timingStore) Samples(model string) (samples []timingSample, sameModel bool) {
+	if model == "" {
+		model = defaultModelKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if samples := s.Models[model]; len(samples) > 0 {
+		return append([]timingSample(nil), samples...), true
+	}
+
+	for _, modelSamples := range s.Models {
+		samples = append(samples, modelSamples...)
+	}
+	return samples, false
+}
+
+// Estimate predicts the duration of one generation. Samples with the same
+// steps and resolution are averaged; otherwise the average time per unit of
+// work (steps x pixels) is scaled to the requested size.
+func (s *timingStore) Estimate(model string, steps, width, height int) (time.Duration, bool) {
+	samples, _ := s.Samples(model)
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	var exactMS, exactCount int64
+	var totalMS, totalUnits float64
+	for _, sample := range samples {
+		if sample.Steps == steps && sample.Width == width && sample.Height == height {
+			exactMS += sample.DurationMS
+			exactCount++
+		}
+		totalMS += float64(sample.DurationMS)
+		totalUnits += sample.units()
+	}
+
+	if exactCount > 0 {
+		return time.Duration(exactMS/exactCount) * time.Millisecond, true
+	}
+	if totalUnits == 0 {
+		return 0, false
+	}
+
+	units := timingSample{Steps: steps, Width: width, Height: height}.units()
+	return time.Duration(totalMS / totalUnits * units * float64(time.Millisecond)), true
+}
+
+// jobEstimates predicts the duration of every job with the current
+// generation parameters, counting each candidate as a generation.
+// It returns nil if no timings were recorded.
+func jobEstimates(jobs []pipelineJob) []time.Duration {
+	if pipelineTimings == nil {
+		return nil
+	}
+
+	perImage, ok := pipelineTimings.Estimate(pipelineModel, pipelineSteps, pipelineWidth, pipelineHeight)
+	if !ok {
+		return nil
+	}
+
+	estimates := make([]time.Duration, len(jobs))
+	for i, job := range jobs {
+		images := jobCandidates(job)
+		if images < 1 {
+			images = 1
+		}
+		estimates[i] = perImage * time.Duration(images)
+	}
+	return estimates
+}
+
+// recordPipelineTiming records the duration of a successful generation
+func recordPipelineTiming(duration time.Duration) {
+	if pipelineTimings != nil {
+		pipelineTimings.Record(pipelineModel, pipelineSteps, pipelineWidth, pipelineHeight, duration)
+	}
+}
+
+// savePipelineTimings persists recorded timings, warning on failure
+func savePipelineTimings() {
+	if pipelineTimings == nil {
+		return
+	}
+	if err := pipelineTimings.Save(); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "  ⚠ Warning: Failed to save timings: %v\n", err)
+	}
+}
+
+// pipelineCapacity returns how many assets can be generated at once on the
+// configured servers, for estimates
+func pipelineCapacity() int {
+	if pipelinePool != nil {
+		return pipelinePool.Capacity()
+	}
+
+	servers, err := serverConfigs(pipelineServers)
+	if err != nil || len(servers) == 0 {
+		return 1
+	}
+	capacity := 0
+	for _, server := range servers {
+		capacity += max(1, server.Concurrency)
+	}
+	return capacity
+}
+
+// printPipelineEstimate prints the estimated generation time per group and in
+// total for the planned jobs
+func printPipelineEstimate(jobs []pipelineJob, manifest *PipelineManifest) {
+	fmt.Println()
+	fmt.Println("Estimate:")
+
+	if pipelineTimings == nil {
+		fmt.Println("  No recorded timings; run a pipeline once to calibrate estimates")
+		return
+	}
+	samples, sameModel := pipelineTimings.Samples(pipelineModel)
+
+	// Selected candidates are kept rather than regenerated
+	var pending []pipelineJob
+	for _, job := range jobs {
+		if manifest == nil || !isKeptSelection(job, manifest) {
+			pending = append(pending, job)
+		}
+	}
+
+	estimates := jobEstimates(pending)
+	if estimates == nil {
+		fmt.Println("  No recorded timings; run a pipeline once to calibrate estimates")
+		return
+	}
+
+	model := pipelineModel
+	if model == "" {
+		model = "server default"
+	}
+	if sameModel {
+		fmt.Printf("  Based on %d recorded generations with model %s\n", len(samples), model)
+	} else {
+		fmt.Printf("  Based on %d recorded generations with other models (model %s has no timings yet)\n", len(samples), model)
+	}
+
+	// Generations on several servers or slots run side by side
+	capacity := pipelineCapacity()
+
+	var total time.Duration
+	images := 0
+	for i := 0; i < len(pending); {
+		group := pending[i].GroupPath
+		var groupTotal time.Duration
+		groupImages := 0
+		for ; i < len(pending) && pending[i].GroupPath == group; i++ {
+			groupTotal += estimates[i]
+			groupImages += max(1, jobCandidates(pending[i]))
+		}
+		fmt.Printf("  %s: %d images, ~%s, %.1f GPU-minutes\n", pending[i-1].GroupName, groupImages, formatEstimate(groupTotal/time.Duration(capacity)), groupTotal.Minutes())
+		total += groupTotal
+		images += groupImages
+	}
+
+	if kept := len(jobs) - len(pending); kept > 0 {
+		fmt.Printf("  Kept selections (not regenerated): %d\n", kept)
+	}
+	wallClock := total / time.Duration(capacity)
+	fmt.Printf("  Total: %d images, ~%s wall-clock, %.1f GPU-minutes\n", images, formatEstimate(wallClock), total.Minutes())
+	if capacity > 1 {
+		fmt.Printf("  Wall-clock assumes %d concurrent generations on the configured servers\n", capacity)
+	}
+	fmt.Printf("  Estimated finish if started now: %s\n", time.Now().Add(wallClock).Format("2006-01-02 15:04"))
+}
+
+// runETA tracks the progress of a run against the estimates of its jobs,
+// correcting the remaining estimate by how fast this run actually is
+type runETA struct {
+	estimates []time.Duration
+	remaining time.Duration // Estimated time of the jobs not yet done
+	estimated time.Duration // Estimated time of the jobs done so far
+	actual    time.Duration // Actual time of the jobs done so far
+	workers   int           // Jobs running at once; 0 means one
+}
+
+// newRunETA returns an ETA tracker, or nil if no estimates are available
+func newRunETA(jobs []pipelineJob) *runETA {
+	estimates := jobEstimates(jobs)
+	if estimates == nil {
+		return nil
+	}
+
+	eta := &runETA{estimates: estimates, workers: pipelineCapacity()}
+	for _, estimate := range estimates {
+		eta.remaining += estimate
+	}
+	return eta
+}
+
+// Done records that job i finished (or was skipped) after the given time
+func (e *runETA) Done(i int, took time.Duration) {
+	if e == nil {
+		return
+	}
+	e.remaining -= e.estimates[i]
+	if took > 0 {
+		e.estimated += e.estimates[i]
+		e.actual += took
+	}
+}
+
+// Remaining returns the estimated wall-clock time left, including job i which
+// is about to start
+func (e *runETA) Remaining() time.Duration {
+	remaining := e.remaining
+	if e.estimated > 0 {
+		remaining = time.Duration(float64(remaining) * float64(e.actual) / float64(e.estimated))
+	}
+	return remaining / time.Duration(max(1, e.workers))
+}
+
+// String formats the ETA for progress lines
+func (e *runETA) String() string {
+	if e == nil {
+		return ""
+	}
+	remaining := e.Remaining()
+	return fmt.Sprintf(" (ETA %s, ~%s)", formatEstimate(remaining), time.Now().Add(remaining).Format("15:04"))
+}
+
+// formatEstimate rounds a duration for display
+func formatEstimate(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		return d.Round(time.Minute).String()
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	default:
+		return d.Round(100 * time.Millisecond).String()
+	}
+}
@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hook events, in the order they occur during a run
+const (
+	hookBeforeAsset   = "before_asset"
+	hookAfterAsset    = "after_asset"
+	hookAfterGroup    = "after_group"
+	hookAfterPipeline = "after_pipeline"
+)
+
+// pipelineHooks holds the hooks of the pipeline file being processed
+var pipelineHooks PipelineHooks
+
+// PipelineHooks declares commands to run at points of a pipeline run
+type PipelineHooks struct {
+	BeforeAsset   []HookCommand `yaml:"before_asset,omitempty"`   // Before each asset is generated
+	AfterAsset    []HookCommand `yaml:"after_asset,omitempty"`    // After each asset is generated and postprocessed
+	AfterGroup    []HookCommand `yaml:"after_group,omitempty"`    // After the last asset of each group
+	AfterPipeline []HookCommand `yaml:"after_pipeline,omitempty"` // After all assets were processed
+}
+
+// HookCommand is a single hook. In YAML it is either a string, which is run
+// as a shell line, or a list, which is run directly as program and arguments.
+// Both forms are rendered as Go templates before running.
+type HookCommand struct {
+	Shell string   // Shell line, run with sh -c (cmd /C on Windows)
+	Args  []string // Program and arguments, run without a shell
+}
+
+// UnmarshalYAML accepts a shell line or a list of program arguments
+func (h *HookCommand) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Decode(&h.Shell)
+	case yaml.SequenceNode:
+		if err := node.Decode(&h.Args); err != nil {
+			return err
+		}
+		if len(h.Args) == 0 {
+			return fmt.Errorf("line %d: hook command list is empty", node.Line)
+		}
+		return nil
+	default:
+		return fmt.Errorf("line %d: hook must be a shell line or a list of arguments", node.Line)
+	}
+}
+
+// MarshalYAML writes the hook back in the form it was declared in
+func (h HookCommand) MarshalYAML() (interface{}, error) {
+	if h.Args != nil {
+		return h.Args, nil
+	}
+	return h.Shell, nil
+}
+
+// String returns the hook as declared, before template rendering
+func (h HookCommand) String() string {
+	if h.Args != nil {
+		return strings.Join(h.Args, " ")
+	}
+	return h.Shell
+}
+
+// hookContext is passed to hook templates and exported to hooks as
+// environment variables
+type hookContext struct {
+	Event string
+
+	// Asset events
+	ID         string
+	Name       string
+	Group      string
+	Output     string // after_asset: empty unless the output file exists
+	OutputDir  string
+	Seed       int64
+	Prompt     string
+	Metadata   map[string]interface{}
+	Status     string   // after_asset only
+	Candidates []string // after_asset only: candidate files, when generated
+
+	// Group and pipeline events
+	GroupName string
+	Completed int
+	Failed    int
+}
+
+// assetHookContext builds the hook context for an asset event. entry is the
+// asset's manifest entry after generation, or nil before it. With candidates
+// the canonical file is not written, so Output is only set after the asset
+// when the file exists.
+func assetHookContext(event string, job pipelineJob, entry *ManifestAsset) hookContext {
+	hc := hookContext{
+		Event:     event,
+		ID:        job.Asset.ID,
+		Name:      job.Asset.Name,
+		Group:     job.GroupPath,
+		GroupName: job.GroupName,
+		Output:    job.OutputPath,
+		OutputDir: job.OutputDir,
+		Seed:      job.Seed,
+		Prompt:    applyPipelineStyle(job.Prompt),
+		Metadata:  job.Metadata,
+	}
+	if entry == nil {
+		return hc
+	}
+
+	hc.Status = entry.Status
+	if _, err := os.Stat(job.OutputPath); err != nil {
+		hc.Output = ""
+	}
+	for _, candidate := range entry.Candidates {
+		hc.Candidates = append(hc.Candidates, candidatePath(job, candidate.Index))
+	}
+	return hc
+}
+
+// env returns the environment variables describing the hook context
+func (c hookContext) env() []string {
+	env := []string{
+		"PIPELINE_HOOK=" + c.Event,
+		"PIPELINE_FILE=" + pipelineFile,
+		// Cleaned like the asset paths, so it is a prefix of them
+		"PIPELINE_OUTPUT_DIR=" + filepath.Clean(pipelineOutputDir),
+	}
+
+	switch c.Event {
+	case hookBeforeAsset, hookAfterAsset:
+		metadata, _ := json.Marshal(c.Metadata)
+		env = append(env,
+			"ASSET_ID="+c.ID,
+			"ASSET_NAME="+c.Name,
+			"ASSET_GROUP="+c.Group,
+			"ASSET_OUTPUT="+c.Output,
+			"ASSET_OUTPUT_DIR="+c.OutputDir,
+			"ASSET_SEED="+strconv.FormatInt(c.Seed, 10),
+			"ASSET_PROMPT="+c.Prompt,
+			"ASSET_METADATA="+string(metadata),
+		)
+		if c.Event == hookAfterAsset {
+			// Candidate paths, one per line
+			env = append(env,
+				"ASSET_STATUS="+c.Status,
+				"ASSET_CANDIDATES="+strings.Join(c.Candidates, "\n"),
+			)
+		}
+
+		// Individual metadata values, e.g. ASSET_META_SUIT_ELEMENT
+		keys := make([]string, 0, len(c.Metadata))
+		for key := range c.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			env = append(env, fmt.Sprintf("ASSET_META_%s=%v", envName(key), c.Metadata[key]))
+		}
+
+	case hookAfterGroup:
+		env = append(env,
+			"GROUP_NAME="+c.GroupName,
+			"GROUP_PATH="+c.Group,
+			"GROUP_OUTPUT_DIR="+c.OutputDir,
+			"GROUP_COMPLETED="+strconv.Itoa(c.Completed),
+			"GROUP_FAILED="+strconv.Itoa(c.Failed),
+		)
+
+	case hookAfterPipeline:
+		env = append(env,
+			"PIPELINE_COMPLETED="+strconv.Itoa(c.Completed),
+			"PIPELINE_FAILED="+strconv.Itoa(c.Failed),
+		)
+	}
+
+	return env
+}
+
+// envName converts a metadata key to an environment variable name
+func envName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// hookFuncs are the functions available in hook templates
+var hookFuncs = template.FuncMap{
+	// quote single-quotes a value for safe use in shell lines
+	"quote": func(v interface{}) string {
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", `'\''`) + "'"
+	},
+}
+
+// hookEvent is the list of hooks declared for one event
+type hookEvent struct {
+	Name  string
+	Hooks []HookCommand
+}
+
+// events returns the declared hooks of every event, in the order the events occur
+func (h PipelineHooks) events() []hookEvent {
+	return []hookEvent{
+		{hookBeforeAsset, h.BeforeAsset},
+		{hookAfterAsset, h.AfterAsset},
+		{hookAfterGroup, h.AfterGroup},
+		{hookAfterPipeline, h.AfterPipeline},
+	}
+}
+
+// empty reports whether no hooks are declared
+func (h PipelineHooks) empty() bool {
+	return len(h.BeforeAsset)+len(h.AfterAsset)+len(h.AfterGroup)+len(h.AfterPipeline) == 0
+}
+
+// validate parses every hook template so mistakes are reported before any
+// asset is generated
+func (h PipelineHooks) validate() error {
+	for _, event := range h.events() {
+		for _, hook := range event.Hooks {
+			for _, text := range append([]string{hook.Shell}, hook.Args...) {
+				if _, err := template.New(event.Name).Funcs(hookFuncs).Parse(text); err != nil {
+					return fmt.Errorf("invalid %s hook %q: %w", event.Name, hook.String(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// runHooks runs hooks in order, stopping at the first one that fails
+func runHooks(ctx context.Context, hooks []HookCommand, hc hookContext) error {
+	for _, hook := range hooks {
+		if err := runHook(ctx, hook, hc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook renders and runs a single hook. Hook output goes to stderr so it
+// does not mix with command output; in quiet mode it is only shown on failure.
+func runHook(ctx context.Context, hook HookCommand, hc hookContext) error {
+	var args []string
+	if hook.Args != nil {
+		for _, arg := range hook.Args {
+			rendered, err := renderHook(arg, hc)
+			if err != nil {
+				return err
+			}
+			args = append(args, rendered)
+		}
+	} else {
+		line, err := renderHook(hook.Shell, hc)
+		if err != nil {
+			return err
+		}
+		if runtime.GOOS == "windows" {
+			args = []string{"cmd", "/C", line}
+		} else {
+			args = []string{"sh", "-c", line}
+		}
+	}
+
+	if verbose {
+		fmt.Fprintf(stderr, "  Running %s hook: %s\n", hc.Event, hook.String())
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), hc.env()...)
+
+	var output bytes.Buffer
+	if quiet {
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+	} else {
+		cmd.Stdout = stderr
+		cmd.Stderr = stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		if output.Len() > 0 {
+			return fmt.Errorf("%s hook %q failed: %w\n%s", hc.Event, hook.String(), err, strings.TrimSpace(output.String()))
+		}
+		return fmt.Errorf("%s hook %q failed: %w", hc.Event, hook.String(), err)
+	}
+
+	return nil
+}
+
+// renderHook executes a hook template against the hook context
+func renderHook(text string, hc hookContext) (string, error) {
+	tmpl, err := template.New(hc.Event).Funcs(hookFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s hook %q: %w", hc.Event, text, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, hc); err != nil {
+		return "", fmt.Errorf("failed to render %s hook %q: %w", hc.Event, text, err)
+	}
+	return buf.String(), nil
+}
+
+// assetHookStrings lists asset hooks for fingerprinting, so that editing them
+// regenerates assets in watch mode
+func (h PipelineHooks) assetHookStrings() []string {
+	var result []string
+	for _, hook := range h.BeforeAsset {
+		result = append(result, hookBeforeAsset+": "+hook.String())
+	}
+	for _, hook := range h.AfterAsset {
+		result = append(result, hookAfterAsset+": "+hook.String())
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// legacyMinorSeedOffset is the seed offset of the minor arcana group, leaving
+	// room for the 22 major arcana cards
+	legacyMinorSeedOffset = 100
+	// legacySuitSeedStride separates the seeds of consecutive suits. Seed
+	// offsets are not cumulative, so suits are offset from legacyMinorSeedOffset.
+	legacySuitSeedStride = 20
+)
+
+// legacyTarotSpec is the original tarot-specific pipeline format, which
+// predates the generic asset group format
+type legacyTarotSpec struct {
+	MajorArcana []legacyMajorCard `yaml:"major_arcana"`
+	MinorArcana yaml.Node         `yaml:"minor_arcana"` // Mapping of suit name to legacySuit, kept as a node to preserve suit order
+}
+
+// legacyMajorCard is a major arcana card in the legacy format
+type legacyMajorCard struct {
+	Number int    `yaml:"number"`
+	Name   string `yaml:"name"`
+	Prompt string `yaml:"prompt"`
+}
+
+// legacySuit is a minor arcana suit in the legacy format
+type legacySuit struct {
+	SuitElement string            `yaml:"suit_element"`
+	SuitColor   string            `yaml:"suit_color"`
+	Cards       []legacyMinorCard `yaml:"cards"`
+}
+
+// legacyMinorCard is a minor arcana card in the legacy format
+type legacyMinorCard struct {
+	Rank   string `yaml:"rank"`
+	Name   string `yaml:"name,omitempty"` // Optional, defaults to "<Rank> of <Suit>"
+	Prompt string `yaml:"prompt"`
+}
+
+// isLegacy reports whether any legacy tarot section is present
+func (l *legacyTarotSpec) isLegacy() bool {
+	return len(l.MajorArcana) > 0 || l.MinorArcana.Kind != 0
+}
+
+// parseLegacyTarotSpec detects the legacy tarot format in a pipeline file and
+// converts it to asset groups. It returns nil groups if the file does not use
+// the legacy format.
+//
+// The mapping follows examples/tarot-deck/tarot-spec.yaml:
+//   - major_arcana becomes a "Major Arcana" group in major-arcana/ with IDs
+//     "00".."21" and filenames "00-the_fool.png"
+//   - minor_arcana becomes a "Minor Arcana" group in minor-arcana/ with one
+//     subgroup per suit, in file order, whose suit_element and suit_color
+//     become metadata; cards get IDs like "ace_of_wands" and filenames
+//     numbered by position ("01-ace_of_wands.png")
+//   - suits get seed offsets 100, 120, 140, ... so that no card shares a
+//     seed with another
+func parseLegacyTarotSpec(data []byte) ([]AssetGroup, error) {
+	var legacy legacyTarotSpec
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse legacy tarot format: %w", err)
+	}
+	if !legacy.isLegacy() {
+		return nil, nil
+	}
+
+	var groups []AssetGroup
+
+	if len(legacy.MajorArcana) > 0 {
+		major := AssetGroup{
+			Name:       "Major Arcana",
+			OutputDir:  "major-arcana",
+			SeedOffset: 0,
+		}
+		for _, card := range legacy.MajorArcana {
+			id := fmt.Sprintf("%02d", card.Number)
+			major.Assets = append(major.Assets, Asset{
+				ID:       id,
+				Name:     card.Name,
+				Prompt:   card.Prompt,
+				Filename: fmt.Sprintf("%s-%s.png", id, sanitizeFilename(card.Name)),
+			})
+		}
+		groups = append(groups, major)
+	}
+
+	if legacy.MinorArcana.Kind != 0 {
+		if legacy.MinorArcana.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("minor_arcana must be a mapping of suit names (line %d)", legacy.MinorArcana.Line)
+		}
+
+		minor := AssetGroup{
+			Name:       "Minor Arcana",
+			OutputDir:  "minor-arcana",
+			SeedOffset: legacyMinorSeedOffset,
+		}
+
+		// Mapping nodes hold alternating key and value nodes
+		content := legacy.MinorArcana.Content
+		for i := 0; i+1 < len(content); i += 2 {
+			suitName := content[i].Value
+			var suit legacySuit
+			if err := content[i+1].Decode(&suit); err != nil {
+				return nil, fmt.Errorf("invalid suit '%s': %w", suitName, err)
+			}
+			minor.Subgroups = append(minor.Subgroups, convertLegacySuit(suitName, suit, legacyMinorSeedOffset+int64(i/2)*legacySuitSeedStride))
+		}
+
+		groups = append(groups, minor)
+	}
+
+	return groups, nil
+}
+
+// convertLegacySuit converts a minor arcana suit to an asset subgroup
+func convertLegacySuit(suitName string, suit legacySuit, seedOffset int64) AssetGroup {
+	title := titleCase(suitName)
+	group := AssetGroup{
+		Name:       title,
+		OutputDir:  sanitizeFilename(suitName),
+		SeedOffset: seedOffset,
+	}
+
+	if suit.SuitElement != "" || suit.SuitColor != "" {
+		group.Metadata = make(map[string]interface{})
+		if suit.SuitElement != "" {
+			group.Metadata["suit_element"] = suit.SuitElement
+		}
+		if suit.SuitColor != "" {
+			group.Metadata["suit_color"] = suit.SuitColor
+		}
+	}
+
+	for i, card := range suit.Cards {
+		name := card.Name
+		if name == "" {
+			name = fmt.Sprintf("%s of %s", titleCase(card.Rank), title)
+		}
+		id := sanitizeFilename(name)
+		group.Assets = append(group.Assets, Asset{
+			ID:       id,
+			Name:     name,
+			Prompt:   card.Prompt,
+			Filename: fmt.Sprintf("%02d-%s.png", i+1, id),
+		})
+	}
+
+	return group
+}
+
+// titleCase capitalizes the first letter of every word
+func titleCase(s string) string {
+	words := strings.Fields(strings.ReplaceAll(s, "_", " "))
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,508 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lockFileName is the name of the lockfile written to the pipeline output directory
+const lockFileName = "pipeline.lock"
+
+// pipelineLocked makes a run fail if anything resolves differently from pipeline.lock
+var pipelineLocked bool
+
+// PipelineLock pins everything that determined each asset of a finished run,
+// so that a shipped asset can be regenerated and compared bit for bit
+type PipelineLock struct {
+	SpecFile     string                  `json:"spec_file"`
+	BaseSeed     int64                   `json:"base_seed"`
+	SeedStrategy string                  `json:"seed_strategy"`
+	GeneratedAt  time.Time               `json:"generated_at"`
+	Assets       map[string]*LockedAsset `json:"assets"` // Keyed by full ID path
+}
+
+// LockedAsset is the lock entry for a single asset
+type LockedAsset struct {
+	Prompt         string                 `json:"prompt"` // Prompt with metadata and style applied
+	NegativePrompt string                 `json:"negative_prompt,omitempty"`
+	Seed           int64                  `json:"seed"` // Seed of the output image (the selected candidate's seed for candidates)
+	Model          string                 `json:"model"`
+	ModelHash      string                 `json:"model_hash,omitempty"` // Empty when the server does not report one
+	Sampler        string                 `json:"sampler"`
+	Scheduler      string                 `json:"scheduler"`
+	Parameters     map[string]interface{} `json:"parameters"`               // Remaining generation parameters (steps, size, CFG scale, ...)
+	Postprocessing map[string]interface{} `json:"postprocessing,omitempty"` // Background removal, cropping, padding, downscaling and format
+	Hooks          []string               `json:"hooks,omitempty"`          // before_asset and after_asset hooks, which may change the output
+	Server         string                 `json:"server,omitempty"`
+	ServerVersion  string                 `json:"server_version,omitempty"`
+	Output         string                 `json:"output"` // Relative to the output directory
+	SHA256         string                 `json:"sha256"` // Hash of the output file
+	Unpinned       []string               `json:"unpinned,omitempty"`
+}
+
+// lockServer is what a server reports about itself and the model it runs
+type lockServer struct {
+	Version   string
+	Model     string // Resolved model name; the loaded model when no --model is given
+	ModelHash string
+}
+
+// loadPipelineLock reads the lockfile from an output directory. It returns
+// nil without an error if there is no lockfile.
+func loadPipelineLock(outputDir string) (*PipelineLock, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, lockFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", lockFileName, err)
+	}
+
+	var lock PipelineLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", lockFileName, err)
+	}
+	if lock.Assets == nil {
+		lock.Assets = make(map[string]*LockedAsset)
+	}
+	return &lock, nil
+}
+
+// Save writes the lockfile to the output directory atomically
+func (l *PipelineLock) Save(outputDir string) error {
+	l.GeneratedAt = time.Now()
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+
+	// Write to file (use temp file + rename for atomicity)
+	path := filepath.Join(outputDir, lockFileName)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath) // Clean up temp file on error
+		return fmt.Errorf("failed to rename lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// resolveLockServers asks every server of the pool for its version and the
+// model it will use. Servers that do not answer are left out.
+func resolveLockServers(ctx context.Context) map[string]lockServer {
+	servers := make(map[string]lockServer)
+	for _, c := range pipelinePool.Clients() {
+		status, err := c.GetServerStatus(ctx)
+		if err != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Could not query %s for the lockfile: %v\n", c.BaseURL(), err)
+			}
+			continue
+		}
+
+		server := lockServer{Version: status.Version, Model: pipelineModel}
+		if server.Model == "" {
+			for _, backend := range status.Backends {
+				if backend.ModelLoaded != "" {
+					server.Model = backend.ModelLoaded
+					break
+				}
+			}
+		}
+
+		if server.Model != "" {
+			if models, err := c.ListModels(); err == nil {
+				for _, model := range models {
+					if sameModel(model.Name, server.Model) {
+						server.ModelHash = model.Hash
+						break
+					}
+				}
+			}
+		}
+
+		servers[c.BaseURL()] = server
+	}
+	return servers
+}
+
+// sameModel reports whether two model names refer to the same model, allowing
+// the file extension to be left out
+func sameModel(a, b string) bool {
+	trim := func(name string) string {
+		return strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return a == b || trim(a) == trim(b)
+}
+
+// pipelinePostprocessing returns the postprocessing settings that apply to
+// every downloaded image, leaving out the steps that are turned off. It
+// returns nil if images are saved as generated.
+func pipelinePostprocessing() map[string]interface{} {
+	settings := make(map[string]interface{})
+	if pipelineRemoveBackground {
+		settings["remove_background"] = map[string]interface{}{
+			"mode":      pipelineRemoveBackgroundMode,
+			"color":     pipelineRemoveBackgroundColor,
+			"tolerance": pipelineRemoveBackgroundTolerance,
+			"feather":   pipelineRemoveBackgroundFeather,
+		}
+	}
+	if pipelineAutoCrop {
+		settings["auto_crop"] = map[string]interface{}{
+			"mode":            pipelineAutoCropMode,
+			"color":           pipelineAutoCropColor,
+			"threshold":       pipelineAutoCropThreshold,
+			"tolerance":       pipelineAutoCropTolerance,
+			"preserve_aspect": pipelineAutoCropPreserveAspect,
+			"padding":         pipelineAutoCropPadding,
+			"padding_percent": pipelineAutoCropPaddingPercent,
+		}
+	}
+	if pipelinePadWidth > 0 || pipelinePadHeight > 0 || pipelinePadAspect != "" || pipelinePadMargin > 0 {
+		settings["pad"] = map[string]interface{}{
+			"width":      pipelinePadWidth,
+			"height":     pipelinePadHeight,
+			"aspect":     pipelinePadAspect,
+			"margin":     pipelinePadMargin,
+			"anchor":     pipelinePadAnchor,
+			"fill":       pipelinePadFill,
+			"background": pipelinePadBackground,
+		}
+	}
+	if pipelineDownscaleWidth > 0 || pipelineDownscaleHeight > 0 || pipelineDownscalePercentage > 0 {
+		settings["downscale"] = map[string]interface{}{
+			"width":      pipelineDownscaleWidth,
+			"height":     pipelineDownscaleHeight,
+			"percentage": pipelineDownscalePercentage,
+			"filter":     pipelineDownscaleFilter,
+			"linear":     pipelineDownscaleLinear,
+		}
+	}
+	if pipelineImageFormat != "" {
+		settings["image_format"] = pipelineImageFormat
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	return settings
+}
+
+// resolveLockedAsset resolves the lock entry of a job with the current
+// settings: seed is the seed of the output image and server the server that
+// generates it ("" if not known). The output file hash is not filled in.
+func resolveLockedAsset(job pipelineJob, seed int64, server string, servers map[string]lockServer) *LockedAsset {
+	params := pipelineParameters(seed)
+	for _, key := range []string{"seed", "sampler", "scheduler", "negative_prompt"} {
+		delete(params, key)
+	}
+
+	locked := &LockedAsset{
+		Prompt:         applyPipelineStyle(job.Prompt),
+		NegativePrompt: pipelineNegPrompt,
+		Seed:           seed,
+		Model:          pipelineModel,
+		Sampler:        pipelineSampler,
+		Scheduler:      pipelineScheduler,
+		Parameters:     params,
+		Postprocessing: pipelinePostprocessing(),
+		Hooks:          pipelineHooks.assetHookStrings(),
+		Server:         server,
+		Output:         manifestRelPath(pipelineOutputDir, job.OutputPath),
+	}
+
+	info, known := servers[server]
+	switch {
+	case server == "":
+		locked.Unpinned = append(locked.Unpinned, "the server that generated the asset is not recorded")
+	case !known:
+		locked.Unpinned = append(locked.Unpinned, fmt.Sprintf("server %s could not be queried for its version and model", server))
+	default:
+		locked.ServerVersion = info.Version
+		if info.Version == "" {
+			locked.Unpinned = append(locked.Unpinned, fmt.Sprintf("server %s did not report its version", server))
+		}
+		if info.Model != "" {
+			locked.Model = info.Model
+		}
+		locked.ModelHash = info.ModelHash
+	}
+
+	if pipelineModel == "" {
+		if locked.Model == "" {
+			locked.Unpinned = append(locked.Unpinned, "no --model was given and the server's default model is unknown")
+		} else {
+			locked.Unpinned = append(locked.Unpinned, "no --model was given; the server's default model was recorded but may change")
+		}
+	}
+	if locked.Model != "" && locked.ModelHash == "" && known {
+		locked.Unpinned = append(locked.Unpinned, fmt.Sprintf("model hash unknown: server did not report a hash for model %s, so a replaced model file is not detected", locked.Model))
+	}
+
+	return locked
+}
+
+// lockedSeedAndServer returns the seed and server that produced a job's
+// canonical image according to its manifest entry. ok is false if the job has
+// no canonical image to lock.
+func lockedSeedAndServer(entry *ManifestAsset) (seed int64, server string, ok bool) {
+	if entry == nil {
+		return 0, "", false
+	}
+	switch entry.Status {
+	case manifestStatusCompleted:
+		return entry.Seed, entry.Server, true
+	case manifestStatusSelected:
+		for _, candidate := range entry.Candidates {
+			if candidate.Index == entry.Selected {
+				return candidate.Seed, candidate.Server, true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// updatePipelineLock records the jobs of a finished run in the lockfile.
+// Entries of assets outside the run are kept, unless the assets were removed
+// from the pipeline file (allJobs); assets without an output are dropped.
+func updatePipelineLock(lock *PipelineLock, jobs, allJobs []pipelineJob, manifest *PipelineManifest, servers map[string]lockServer) error {
+	if lock == nil {
+		lock = &PipelineLock{}
+	}
+	if lock.Assets == nil {
+		lock.Assets = make(map[string]*LockedAsset)
+	}
+	lock.SpecFile = pipelineFile
+	lock.BaseSeed = pipelineBaseSeed
+	lock.SeedStrategy = pipelineSeedStrategy
+
+	planned := make(map[string]bool, len(allJobs))
+	for _, job := range allJobs {
+		planned[job.Key()] = true
+	}
+	for key := range lock.Assets {
+		if !planned[key] {
+			delete(lock.Assets, key)
+		}
+	}
+
+	for _, job := range jobs {
+		key := job.Key()
+		entry := manifest.Assets[key]
+		seed, server, ok := lockedSeedAndServer(entry)
+		if !ok {
+			delete(lock.Assets, key)
+			continue
+		}
+
+		sum, err := fileSHA256(job.OutputPath)
+		if err != nil {
+			delete(lock.Assets, key)
+			continue
+		}
+
+		locked := resolveLockedAsset(job, seed, server, servers)
+		locked.SHA256 = sum
+		if entry.Hash != jobHash(job) {
+			locked.Unpinned = append(locked.Unpinned, "the kept image was generated with different settings; regenerate it to pin it")
+		}
+		lock.Assets[key] = locked
+	}
+
+	return lock.Save(pipelineOutputDir)
+}
+
+// checkPipelineLock compares how the jobs resolve now with the lockfile and
+// returns every difference. A job's seed is that of its canonical image, which
+// for a kept selection is the selected candidate's seed.
+func checkPipelineLock(lock *PipelineLock, jobs []pipelineJob, manifest *PipelineManifest, servers map[string]lockServer) []string {
+	var diffs []string
+	diff := func(key, field string, locked, current interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%s: %s is %v, locked %v", key, field, current, locked))
+	}
+
+	if lock.SeedStrategy != pipelineSeedStrategy {
+		diff("pipeline", "seed strategy", lock.SeedStrategy, pipelineSeedStrategy)
+	}
+
+	for _, job := range jobs {
+		key := job.Key()
+		locked := lock.Assets[key]
+		if locked == nil {
+			diffs = append(diffs, fmt.Sprintf("%s: not in %s", key, lockFileName))
+			continue
+		}
+
+		seed := job.Seed
+		if isKeptSelection(job, manifest) {
+			seed, _, _ = lockedSeedAndServer(manifest.Assets[key])
+		}
+		current := resolveLockedAsset(job, seed, "", nil)
+
+		if current.Prompt != locked.Prompt {
+			diff(key, "prompt", fmt.Sprintf("%q", locked.Prompt), fmt.Sprintf("%q", current.Prompt))
+		}
+		if current.NegativePrompt != locked.NegativePrompt {
+			diff(key, "negative prompt", fmt.Sprintf("%q", locked.NegativePrompt), fmt.Sprintf("%q", current.NegativePrompt))
+		}
+		if current.Seed != locked.Seed {
+			diff(key, "seed", locked.Seed, current.Seed)
+		}
+		if current.Sampler != locked.Sampler {
+			diff(key, "sampler", locked.Sampler, current.Sampler)
+		}
+		if current.Scheduler != locked.Scheduler {
+			diff(key, "scheduler", locked.Scheduler, current.Scheduler)
+		}
+		if !sameJSON(current.Postprocessing, locked.Postprocessing) {
+			currentJSON, _ := json.Marshal(current.Postprocessing)
+			lockedJSON, _ := json.Marshal(locked.Postprocessing)
+			diff(key, "postprocessing", string(lockedJSON), string(currentJSON))
+		}
+		if !reflect.DeepEqual(current.Hooks, locked.Hooks) {
+			diff(key, "asset hooks", fmt.Sprintf("%q", locked.Hooks), fmt.Sprintf("%q", current.Hooks))
+		}
+		if current.Output != locked.Output {
+			diff(key, "output", locked.Output, current.Output)
+		}
+		if !sameJSON(current.Parameters, locked.Parameters) {
+			currentJSON, _ := json.Marshal(current.Parameters)
+			lockedJSON, _ := json.Marshal(locked.Parameters)
+			diff(key, "parameters", string(lockedJSON), string(currentJSON))
+		}
+		if pipelineModel != "" && !sameModel(pipelineModel, locked.Model) {
+			diff(key, "model", locked.Model, pipelineModel)
+		}
+
+		// Any server may generate the asset, so every server must match
+		urls := make([]string, 0, len(servers))
+		for url := range servers {
+			urls = append(urls, url)
+		}
+		sort.Strings(urls)
+		for _, url := range urls {
+			server := servers[url]
+			if locked.Model != "" && server.Model != "" && !sameModel(server.Model, locked.Model) {
+				diff(key, "model on "+url, locked.Model, server.Model)
+			}
+			if locked.ModelHash != "" && server.ModelHash != locked.ModelHash {
+				diff(key, "model hash on "+url, locked.ModelHash, server.ModelHash)
+			}
+			if locked.ServerVersion != "" && server.Version != locked.ServerVersion {
+				diff(key, "server version of "+url, locked.ServerVersion, server.Version)
+			}
+		}
+	}
+
+	return diffs
+}
+
+// lockedScratchRun prepares a locked run to regenerate into a new scratch
+// directory inside the output directory, which becomes the output directory
+// for the rest of the run. It returns the jobs replanned into it and an empty
+// manifest for it, so neither the shipped outputs nor their manifest are
+// touched. Kept selections are left out, since they are not regenerated.
+func lockedScratchRun(spec *PipelineSpec, jobs []pipelineJob, manifest *PipelineManifest) ([]pipelineJob, *PipelineManifest, error) {
+	kept := make(map[string]bool)
+	for _, job := range jobs {
+		if isKeptSelection(job, manifest) {
+			kept[job.Key()] = true
+		}
+	}
+
+	dir, err := os.MkdirTemp(pipelineOutputDir, ".locked-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	pipelineOutputDir = dir
+
+	var scratchJobs []pipelineJob
+	for _, job := range filterJobs(planPipeline(spec.Assets, dir)) {
+		if !kept[job.Key()] {
+			scratchJobs = append(scratchJobs, job)
+		}
+	}
+	if !quiet && len(kept) > 0 {
+		fmt.Fprintf(os.Stderr, "Not regenerating %d kept selections\n", len(kept))
+	}
+
+	scratch := &PipelineManifest{
+		SpecFile:     manifest.SpecFile,
+		BaseSeed:     manifest.BaseSeed,
+		SeedStrategy: manifest.SeedStrategy,
+		Assets:       make(map[string]*ManifestAsset),
+	}
+	return scratchJobs, scratch, nil
+}
+
+// warnUnknownModelHashes warns once per model that the lockfile could not
+// pin by hash, since a locked run cannot detect a replaced model file
+func warnUnknownModelHashes(lock *PipelineLock, jobs []pipelineJob) {
+	warned := make(map[string]bool)
+	for _, job := range jobs {
+		locked := lock.Assets[job.Key()]
+		if locked == nil || locked.Model == "" || locked.ModelHash != "" || warned[locked.Model] {
+			continue
+		}
+		warned[locked.Model] = true
+		fmt.Fprintf(os.Stderr, "⚠ Warning: Model hash of %s is unknown; a replaced model file with the same name is not detected\n", locked.Model)
+	}
+}
+
+// compareWithLock compares the outputs of a run with the hashes in the lockfile
+// and returns the keys of assets whose output differs
+func compareWithLock(lock *PipelineLock, jobs []pipelineJob, manifest *PipelineManifest) (identical int, different []string) {
+	for _, job := range jobs {
+		locked := lock.Assets[job.Key()]
+		if locked == nil || isKeptSelection(job, manifest) {
+			continue
+		}
+		if entry := manifest.Assets[job.Key()]; entry == nil || entry.Status != manifestStatusCompleted {
+			continue
+		}
+
+		if sum, err := fileSHA256(job.OutputPath); err == nil && sum == locked.SHA256 {
+			identical++
+		} else {
+			different = append(different, job.Key())
+		}
+	}
+	return identical, different
+}
+
+// sameJSON reports whether two values encode to the same JSON, which ignores
+// the difference between integers and the floats they decode to
+func sameJSON(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// manifestFileName is the name of the manifest written to the pipeline output directory
+	manifestFileName = "pipeline-manifest.json"
+
+	manifestStatusCompleted  = "completed"
+	manifestStatusFailed     = "failed"
+	manifestStatusCandidates = "candidates"
+	manifestStatusSelected   = "selected"
+)
+
+// PipelineManifest records the outcome of every asset generated into an
+// output directory. It is updated after each asset so that it survives
+// interrupted runs, and entries from earlier runs are kept.
+type PipelineManifest struct {
+	SpecFile     string                    `json:"spec_file"`
+	BaseSeed     int64                     `json:"base_seed"`
+	SeedStrategy string                    `json:"seed_strategy"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	Assets       map[string]*ManifestAsset `json:"assets"` // Keyed by full ID path
+}
+
+// ManifestAsset is the manifest entry for a single pipeline asset
+type ManifestAsset struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Group        string                 `json:"group"`
+	Prompt       string                 `json:"prompt"`
+	Seed         int64                  `json:"seed"`
+	SeedStrategy string                 `json:"seed_strategy"`
+	Output       string                 `json:"output"` // Canonical output path, relative to the output directory
+	Status       string                 `json:"status"`
+	Error        string                 `json:"error,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+	DurationMS   int64                  `json:"duration_ms,omitempty"`
+	Candidates   []ManifestCandidate    `json:"candidates,omitempty"`
+	Selected     int                    `json:"selected,omitempty"` // 1-based index of the promoted candidate
+	Hash         string                 `json:"hash,omitempty"`     // Fingerprint of the resolved prompt and parameters
+	Server       string                 `json:"server,omitempty"`   // Server that generated the canonical image
+	GeneratedAt  time.Time              `json:"generated_at"`
+}
+
+// ManifestCandidate is a single candidate image generated for an asset
+type ManifestCandidate struct {
+	Index  int    `json:"index"`
+	Seed   int64  `json:"seed"`
+	Output string `json:"output"`           // Relative to the output directory
+	Server string `json:"server,omitempty"` // Server that generated the candidate
+}
+
+// loadPipelineManifest reads the manifest from an output directory.
+// A missing manifest is not an error; an empty manifest is returned instead.
+func loadPipelineManifest(outputDir string) (*PipelineManifest, error) {
+	manifest := &PipelineManifest{Assets: make(map[string]*ManifestAsset)}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, manifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, nil
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Assets == nil {
+		manifest.Assets = make(map[string]*ManifestAsset)
+	}
+
+	return manifest, nil
+}
+
+// Save writes the manifest to the output directory atomically
+func (m *PipelineManifest) Save(outputDir string) error {
+	m.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	// Write to file (use temp file + rename for atomicity)
+	path := filepath.Join(outputDir, manifestFileName)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath) // Clean up temp file on error
+		return fmt.Errorf("failed to rename manifest: %w", err)
+	}
+
+	return nil
+}
+
+// newManifestAsset creates the manifest entry for a job without adding it to
+// a manifest, so that it can be filled in while the job runs
+func newManifestAsset(job pipelineJob, outputDir string) *ManifestAsset {
+	return &ManifestAsset{
+		ID:           job.Asset.ID,
+		Name:         job.Asset.Name,
+		Group:        job.GroupPath,
+		Prompt:       applyPipelineStyle(job.Prompt),
+		Seed:         job.Seed,
+		SeedStrategy: pipelineSeedStrategy,
+		Output:       manifestRelPath(outputDir, job.OutputPath),
+		Hash:         jobHash(job),
+		GeneratedAt:  time.Now(),
+	}
+}
+
+// Unchanged reports whether a job was already generated successfully with the
+// same resolved prompt and parameters, according to its stored hash
+func (m *PipelineManifest) Unchanged(job pipelineJob) bool {
+	entry, ok := m.Assets[job.Key()]
+	if !ok || entry.Status == manifestStatusFailed || entry.Hash != jobHash(job) {
+		return false
+	}
+
+	// The canonical file only exists once a candidate has been selected
+	if entry.Status == manifestStatusCandidates {
+		return true
+	}
+	_, err := os.Stat(job.OutputPath)
+	return err == nil
+}
+
+// jobHash fingerprints everything that determines a job's output: the
+// resolved prompt, model, generation parameters, output path, candidate count
+// and the asset hooks that postprocess it
+func jobHash(job pipelineJob) string {
+	data, _ := json.Marshal(struct {
+		Prompt     string                 `json:"prompt"`
+		Model      string                 `json:"model"`
+		Parameters map[string]interface{} `json:"parameters"`
+		Output     string                 `json:"output"`
+		Candidates int                    `json:"candidates"`
+		Hooks      []string               `json:"hooks,omitempty"`
+	}{
+		Prompt:     applyPipelineStyle(job.Prompt),
+		Model:      pipelineModel,
+		Parameters: pipelineParameters(job.Seed),
+		Output:     filepath.ToSlash(job.OutputPath),
+		Candidates: jobCandidates(job),
+		Hooks:      pipelineHooks.assetHookStrings(),
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Find looks up an asset by its full ID path or, if unambiguous, by its bare ID
+func (m *PipelineManifest) Find(id string) (string, *ManifestAsset, error) {
+	if entry, ok := m.Assets[id]; ok {
+		return id, entry, nil
+	}
+
+	var matches []string
+	for key, entry := range m.Assets {
+		if entry.ID == id {
+			matches = append(matches, key)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil, fmt.Errorf("asset '%s' not found in manifest", id)
+	case 1:
+		return matches[0], m.Assets[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return "", nil, fmt.Errorf("asset ID '%s' is ambiguous, use the full path: %v", id, matches)
+	}
+}
+
+// manifestRelPath returns path relative to the output directory, falling back
+// to the path itself if it cannot be made relative
+func manifestRelPath(outputDir, path string) string {
+	rel, err := filepath.Rel(outputDir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
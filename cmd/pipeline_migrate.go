@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var pipelineMigrateForce bool
+
+// pipelineMigrateCmd represents the pipeline migrate command
+var pipelineMigrateCmd = &cobra.Command{
+	Use:   "migrate <legacy-file>",
+	Short: "Convert a legacy tarot pipeline file to the generic format",
+	Long: `Convert a pipeline file written in the legacy tarot format (major_arcana and
+minor_arcana sections) to the generic asset group format.
+
+Major arcana cards become a "Major Arcana" group, and each minor arcana suit
+becomes a subgroup of "Minor Arcana" with its suit_element and suit_color as
+metadata. IDs, filenames and seed offsets are the ones the pipeline command
+uses when it loads the legacy file directly, so the converted file produces
+the same images.
+
+The result is written to <legacy-file>-generic.yaml next to the input, or to
+the path given with --output.
+
+Examples:
+  # Write tarot-spec-generic.yaml
+  asset-generator pipeline migrate tarot-spec.yaml
+
+  # Choose the output file
+  asset-generator pipeline migrate tarot-spec.yaml --output deck.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPipelineMigrate,
+}
+
+func init() {
+	pipelineCmd.AddCommand(pipelineMigrateCmd)
+
+	pipelineMigrateCmd.Flags().BoolVar(&pipelineMigrateForce, "force", false, "overwrite the output file if it exists")
+}
+
+func runPipelineMigrate(cmd *cobra.Command, args []string) error {
+	inputPath := args[0]
+
+	spec, err := loadPipelineSpec(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to load pipeline: %w", err)
+	}
+	if !spec.legacy {
+		return fmt.Errorf("%s does not use the legacy tarot format", inputPath)
+	}
+
+	outputPath := viper.GetString("output")
+	if outputPath == "" {
+		ext := filepath.Ext(inputPath)
+		outputPath = strings.TrimSuffix(inputPath, ext) + "-generic" + ext
+	}
+	if _, err := os.Stat(outputPath); err == nil && !pipelineMigrateForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", outputPath)
+	}
+
+	data, err := marshalPipelineSpec(spec, inputPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "✓ Converted %d assets to the generic format\n", countAssets(spec.Assets))
+		fmt.Fprintf(os.Stderr, "  Saved to: %s\n", outputPath)
+	}
+
+	return nil
+}
+
+// marshalPipelineSpec encodes a pipeline spec as YAML in the generic format
+func marshalPipelineSpec(spec *PipelineSpec, source string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Converted from the legacy tarot format: %s\n\n", filepath.Base(source))
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(spec); err != nil {
+		return nil, fmt.Errorf("failed to encode pipeline: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode pipeline: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
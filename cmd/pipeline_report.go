@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg" // Register JPEG decoder for thumbnails
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// reportFileName is the name of the contact sheet written to the pipeline output directory
+	reportFileName = "report.html"
+
+	// reportThumbnailSize is the maximum width and height of report thumbnails
+	reportThumbnailSize = 256
+)
+
+// pipelineReportCmd represents the pipeline report command
+var pipelineReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Build an HTML contact sheet from the pipeline manifest",
+	Long: `Build a self-contained report.html in the output directory from the
+pipeline manifest written by previous runs.
+
+The report shows a thumbnail of every asset, grouped by asset group, along with
+its ID, resolved prompt, seed, generation parameters, duration and any failure
+message. Candidates are shown next to each other with the selected one marked.
+Thumbnails are embedded in the file, so it can be shared on its own.
+
+The same report is written automatically after a run with 'pipeline --report'.
+
+Examples:
+  # Rebuild the report for an output directory
+  asset-generator pipeline report --output-dir ./assets`,
+	Args: cobra.NoArgs,
+	RunE: runPipelineReport,
+}
+
+func init() {
+	pipelineCmd.AddCommand(pipelineReportCmd)
+}
+
+func runPipelineReport(cmd *cobra.Command, args []string) error {
+	manifest, err := loadPipelineManifest(pipelineOutputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if len(manifest.Assets) == 0 {
+		return fmt.Errorf("no pipeline manifest found in %s", pipelineOutputDir)
+	}
+
+	reportPath, err := writePipelineReport(pipelineOutputDir, manifest)
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "✓ Report written to: %s\n", reportPath)
+	}
+	return nil
+}
+
+// reportGroup is a group of assets as shown in the report
+type reportGroup struct {
+	Name   string
+	Assets []reportAsset
+}
+
+// reportAsset is a single manifest entry prepared for the report template
+type reportAsset struct {
+	Key        string
+	Entry      *ManifestAsset
+	Thumbnail  template.URL
+	Parameters []reportParam
+	Duration   string
+	Candidates []reportCandidate
+}
+
+type reportParam struct {
+	Name  string
+	Value string
+}
+
+type reportCandidate struct {
+	ManifestCandidate
+	Thumbnail template.URL
+	Selected  bool
+}
+
+// writePipelineReport renders the manifest as a self-contained HTML contact
+// sheet in the output directory and returns the path of the report
+func writePipelineReport(outputDir string, manifest *PipelineManifest) (string, error) {
+	keys := make([]string, 0, len(manifest.Assets))
+	for key := range manifest.Assets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := manifest.Assets[keys[i]], manifest.Assets[keys[j]]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return keys[i] < keys[j]
+	})
+
+	var groups []reportGroup
+	failed := 0
+	for _, key := range keys {
+		entry := manifest.Assets[key]
+		if entry.Status == manifestStatusFailed {
+			failed++
+		}
+
+		asset := reportAsset{
+			Key:        key,
+			Entry:      entry,
+			Parameters: reportParameters(entry.Parameters),
+		}
+		if entry.DurationMS > 0 {
+			asset.Duration = (time.Duration(entry.DurationMS) * time.Millisecond).Round(100 * time.Millisecond).String()
+		}
+		if entry.Status != manifestStatusFailed && entry.Status != manifestStatusCandidates {
+			asset.Thumbnail = reportThumbnail(filepath.Join(outputDir, filepath.FromSlash(entry.Output)))
+		}
+		for _, candidate := range entry.Candidates {
+			asset.Candidates = append(asset.Candidates, reportCandidate{
+				ManifestCandidate: candidate,
+				Thumbnail:         reportThumbnail(filepath.Join(outputDir, filepath.FromSlash(candidate.Output))),
+				Selected:          candidate.Index == entry.Selected,
+			})
+		}
+
+		if len(groups) == 0 || groups[len(groups)-1].Name != entry.Group {
+			groups = append(groups, reportGroup{Name: entry.Group})
+		}
+		groups[len(groups)-1].Assets = append(groups[len(groups)-1].Assets, asset)
+	}
+
+	var buf bytes.Buffer
+	err := reportTemplate.Execute(&buf, struct {
+		Manifest *PipelineManifest
+		Groups   []reportGroup
+		Total    int
+		Failed   int
+	}{manifest, groups, len(keys), failed})
+	if err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+
+	reportPath := filepath.Join(outputDir, reportFileName)
+	if err := os.WriteFile(reportPath, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return reportPath, nil
+}
+
+// reportParameters flattens generation parameters into sorted name/value pairs.
+// The seed is left out as it is shown separately.
+func reportParameters(params map[string]interface{}) []reportParam {
+	var result []reportParam
+	for name, value := range params {
+		if name == "seed" {
+			continue
+		}
+		text := fmt.Sprintf("%v", value)
+		if f, ok := value.(float64); ok {
+			text = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		result = append(result, reportParam{Name: name, Value: text})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// reportThumbnail returns a PNG data URL with a downscaled copy of an image,
+// or an empty URL if the image cannot be read
+func reportThumbnail(imagePath string) template.URL {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return ""
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > reportThumbnailSize || height > reportThumbnailSize {
+		if width >= height {
+			height = max(1, height*reportThumbnailSize/width)
+			width = reportThumbnailSize
+		} else {
+			width = max(1, width*reportThumbnailSize/height)
+			height = reportThumbnailSize
+		}
+		thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, bounds, draw.Src, nil)
+		img = thumb
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+
+	// Data URLs are built from our own encoder output, so they are safe to embed
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Pipeline Report{{with .Manifest.SpecFile}} - {{.}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; background: #f4f4f4; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.summary { color: #555; margin-bottom: 2em; }
+h2 { border-bottom: 2px solid #ccc; padding-bottom: 0.2em; margin-top: 2em; }
+.grid { display: flex; flex-wrap: wrap; gap: 1em; }
+.asset { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 0.8em; width: 280px; }
+.asset.failed { border-color: #d33; background: #fff4f4; }
+.thumb { width: 256px; height: 256px; display: flex; align-items: center; justify-content: center; background: #eee; margin: 0 auto 0.5em; }
+.thumb img { max-width: 256px; max-height: 256px; }
+.missing { color: #888; font-size: 0.9em; }
+.id { font-weight: bold; word-break: break-all; }
+.name { color: #555; }
+.prompt { font-size: 0.85em; margin: 0.5em 0; }
+.meta { font-size: 0.8em; color: #555; }
+.meta td { padding: 0 0.5em 0 0; vertical-align: top; }
+.status { display: inline-block; font-size: 0.75em; padding: 0.1em 0.5em; border-radius: 3px; background: #ddd; }
+.status.completed, .status.selected { background: #cfc; }
+.status.candidates { background: #ffd; }
+.status.failed { background: #fcc; }
+.error { color: #b00; font-size: 0.85em; margin-top: 0.5em; word-break: break-word; }
+.candidates { display: flex; flex-wrap: wrap; gap: 0.3em; margin-top: 0.5em; }
+.candidate { text-align: center; font-size: 0.75em; border: 2px solid transparent; }
+.candidate.selected { border-color: #2a2; }
+.candidate img { max-width: 84px; max-height: 84px; display: block; }
+</style>
+</head>
+<body>
+<h1>Pipeline Report</h1>
+<div class="summary">
+{{with .Manifest.SpecFile}}Pipeline: {{.}} &middot; {{end}}{{.Total}} assets{{if .Failed}}, {{.Failed}} failed{{end}} &middot;
+base seed {{.Manifest.BaseSeed}}{{with .Manifest.SeedStrategy}} ({{.}} strategy){{end}} &middot;
+updated {{.Manifest.UpdatedAt.Format "2006-01-02 15:04:05"}}
+</div>
+{{range .Groups}}
+<h2>{{if .Name}}{{.Name}}{{else}}(ungrouped){{end}}</h2>
+<div class="grid">
+{{range .Assets}}
+<div class="asset{{if eq .Entry.Status "failed"}} failed{{end}}" id="{{.Key}}">
+  <div class="thumb">{{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="{{.Entry.ID}}">{{else}}<span class="missing">{{if eq .Entry.Status "candidates"}}no candidate selected{{else}}no image{{end}}</span>{{end}}</div>
+  <div class="id">{{.Entry.ID}}</div>
+  {{with .Entry.Name}}<div class="name">{{.}}</div>{{end}}
+  <span class="status {{.Entry.Status}}">{{.Entry.Status}}</span>
+  <div class="prompt">{{.Entry.Prompt}}</div>
+  <table class="meta">
+    <tr><td>Seed</td><td>{{.Entry.Seed}}</td></tr>
+    {{with .Duration}}<tr><td>Duration</td><td>{{.}}</td></tr>{{end}}
+    <tr><td>Output</td><td>{{.Entry.Output}}</td></tr>
+    {{range .Parameters}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}
+  </table>
+  {{with .Entry.Error}}<div class="error">{{.}}</div>{{end}}
+  {{if .Candidates}}
+  <div class="candidates">
+    {{range .Candidates}}
+    <div class="candidate{{if .Selected}} selected{{end}}" title="seed {{.Seed}}">
+      {{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="candidate {{.Index}}">{{end}}#{{.Index}}
+    </div>
+    {{end}}
+  </div>
+  {{end}}
+</div>
+{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
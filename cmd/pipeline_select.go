@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+// pipelineSelectCmd represents the pipeline select command
+var pipelineSelectCmd = &cobra.Command{
+	Use:   "select <asset-id> <candidate>",
+	Short: "Promote a generated candidate to the canonical filename",
+	Long: `Promote one of the candidates generated with --candidates to the asset's
+canonical filename and record the choice in the pipeline manifest.
+
+The asset can be referred to by its ID or, when the ID is used in several
+groups, by its full path (e.g. characters/heroes/hero_01). Candidates are
+numbered from 1, matching the suffix of the files in candidates/<asset-id>/.
+
+Selected assets are kept by later pipeline runs instead of being
+regenerated. Delete the canonical file to regenerate the asset.
+
+Examples:
+  # Promote the third candidate of hero_01
+  asset-generator pipeline select hero_01 3 --output-dir ./assets
+
+  # Disambiguate an ID used in several groups
+  asset-generator pipeline select characters/heroes/hero_01 2`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPipelineSelect,
+}
+
+func init() {
+	pipelineCmd.AddCommand(pipelineSelectCmd)
+}
+
+func runPipelineSelect(cmd *cobra.Command, args []string) error {
+	index, err := strconv.Atoi(args[1])
+	if err != nil || index < 1 {
+		return fmt.Errorf("candidate must be a positive number, got '%s'", args[1])
+	}
+
+	manifest, err := loadPipelineManifest(pipelineOutputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	key, entry, err := manifest.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	var candidate *ManifestCandidate
+	for i := range entry.Candidates {
+		if entry.Candidates[i].Index == index {
+			candidate = &entry.Candidates[i]
+			break
+		}
+	}
+	if candidate == nil {
+		return fmt.Errorf("asset '%s' has no candidate %d (%d candidates recorded)", key, index, len(entry.Candidates))
+	}
+
+	// Copy the candidate over the canonical file
+	src := filepath.Join(pipelineOutputDir, filepath.FromSlash(candidate.Output))
+	dst := filepath.Join(pipelineOutputDir, filepath.FromSlash(entry.Output))
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read candidate: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	// Mandatory: Strip all PNG metadata, as for every other output file
+	if err := processor.StripPNGMetadata(dst); err != nil {
+		return fmt.Errorf("failed to strip PNG metadata: %w", err)
+	}
+
+	entry.Selected = index
+	entry.Seed = candidate.Seed
+	entry.Status = manifestStatusSelected
+	if entry.Parameters != nil {
+		entry.Parameters["seed"] = candidate.Seed
+	}
+
+	if err := manifest.Save(pipelineOutputDir); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "✓ Selected candidate %d for %s (seed: %d)\n", index, key, candidate.Seed)
+		fmt.Fprintf(os.Stderr, "  Saved to: %s\n", dst)
+	}
+
+	return nil
+}
@@ -0,0 +1,1006 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+func testPipelineGroups() []AssetGroup {
+	return []AssetGroup{
+		{
+			Name:       "Characters",
+			OutputDir:  "characters",
+			SeedOffset: 0,
+			Metadata:   map[string]interface{}{"style": "fantasy"},
+			Assets: []Asset{
+				{ID: "hero_01", Name: "Hero", Prompt: "hero", Filename: "hero.png"},
+				{ID: "villain_01", Name: "Villain", Prompt: "villain", Candidates: 3},
+			},
+			Subgroups: []AssetGroup{
+				{
+					Name:       "Heroes",
+					OutputDir:  "heroes",
+					SeedOffset: 100,
+					Assets: []Asset{
+						{ID: "hero_01", Name: "Nested Hero", Prompt: "nested"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPlanPipeline(t *testing.T) {
+	origSeed := pipelineBaseSeed
+	defer func() { pipelineBaseSeed = origSeed }()
+	pipelineBaseSeed = 42
+
+	jobs := planPipeline(testPipelineGroups(), "out")
+	if len(jobs) != 3 {
+		t.Fatalf("planPipeline returned %d jobs, expected 3", len(jobs))
+	}
+
+	tests := []struct {
+		key    string
+		seed   int64
+		output string
+		prompt string
+	}{
+		{"characters/hero_01", 42, filepath.Join("out", "characters", "hero.png"), "hero, fantasy"},
+		{"characters/villain_01", 43, filepath.Join("out", "characters", "villain_01.png"), "villain, fantasy"},
+		{"characters/heroes/hero_01", 142, filepath.Join("out", "characters", "heroes", "hero_01.png"), "nested, fantasy"},
+	}
+
+	for i, tt := range tests {
+		job := jobs[i]
+		if job.Key() != tt.key {
+			t.Errorf("job %d key = %q, expected %q", i, job.Key(), tt.key)
+		}
+		if job.Seed != tt.seed {
+			t.Errorf("job %s seed = %d, expected %d", tt.key, job.Seed, tt.seed)
+		}
+		if job.OutputPath != tt.output {
+			t.Errorf("job %s output = %q, expected %q", tt.key, job.OutputPath, tt.output)
+		}
+		if job.Prompt != tt.prompt {
+			t.Errorf("job %s prompt = %q, expected %q", tt.key, job.Prompt, tt.prompt)
+		}
+	}
+}
+
+func TestCandidateSeedAndPath(t *testing.T) {
+	job := pipelineJob{
+		Asset:      Asset{ID: "Villain 01"},
+		Seed:       42,
+		OutputDir:  "out",
+		OutputPath: filepath.Join("out", "villain.png"),
+	}
+	if got := candidateSeed(job, 1); got != 42 {
+		t.Errorf("candidateSeed(job, 1) = %d, expected 42", got)
+	}
+	if a, b := candidateSeed(job, 3), candidateSeed(job, 3); a != b {
+		t.Errorf("candidateSeed(job, 3) is not deterministic: %d, %d", a, b)
+	}
+
+	expected := filepath.Join("out", "candidates", "villain_01", "villain-2.png")
+	if got := candidatePath(job, 2); got != expected {
+		t.Errorf("candidatePath = %q, expected %q", got, expected)
+	}
+}
+
+func TestCandidateSeedsDoNotCollide(t *testing.T) {
+	origSeed, origStrategy := pipelineBaseSeed, pipelineSeedStrategy
+	defer func() { pipelineBaseSeed, pipelineSeedStrategy = origSeed, origStrategy }()
+	pipelineBaseSeed = 42
+
+	assets := make([]Asset, 500)
+	for i := range assets {
+		assets[i] = Asset{ID: fmt.Sprintf("asset_%03d", i), Prompt: "p"}
+	}
+	groups := []AssetGroup{
+		{Name: "A", Assets: assets},
+		{Name: "B", SeedOffset: 1000, Assets: assets},
+	}
+
+	for _, strategy := range []string{seedStrategyIndex, seedStrategyHash} {
+		pipelineSeedStrategy = strategy
+		jobs := planPipeline(groups, t.TempDir())
+
+		// Every asset's own seed is candidate 1; later candidates must not
+		// repeat any asset or candidate seed
+		seen := make(map[int64]string)
+		for _, job := range jobs {
+			seen[job.Seed] = job.Key()
+		}
+		for _, job := range jobs {
+			for n := 2; n <= 4; n++ {
+				seed := candidateSeed(job, n)
+				name := fmt.Sprintf("%s candidate %d", job.Key(), n)
+				if other, ok := seen[seed]; ok {
+					t.Fatalf("%s: seed %d of %s collides with %s", strategy, seed, name, other)
+				}
+				seen[seed] = name
+			}
+		}
+	}
+}
+
+func TestPipelineManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	manifest, err := loadPipelineManifest(dir)
+	if err != nil {
+		t.Fatalf("loadPipelineManifest on empty dir failed: %v", err)
+	}
+	if len(manifest.Assets) != 0 {
+		t.Fatalf("expected empty manifest, got %d assets", len(manifest.Assets))
+	}
+
+	for _, job := range planPipeline(testPipelineGroups(), dir) {
+		entry := newManifestAsset(job, dir)
+		entry.Status = manifestStatusCompleted
+		manifest.Assets[job.Key()] = entry
+	}
+	if err := manifest.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifestFileName)); err != nil {
+		t.Fatalf("manifest file not written: %v", err)
+	}
+
+	loaded, err := loadPipelineManifest(dir)
+	if err != nil {
+		t.Fatalf("loadPipelineManifest failed: %v", err)
+	}
+	if len(loaded.Assets) != 3 {
+		t.Fatalf("loaded manifest has %d assets, expected 3", len(loaded.Assets))
+	}
+
+	entry := loaded.Assets["characters/hero_01"]
+	if entry == nil || entry.Output != "characters/hero.png" {
+		t.Errorf("unexpected entry for characters/hero_01: %+v", entry)
+	}
+
+	// Unique IDs resolve directly, duplicated IDs need the full path
+	if key, _, err := loaded.Find("villain_01"); err != nil || key != "characters/villain_01" {
+		t.Errorf("Find(villain_01) = %q, %v", key, err)
+	}
+	if _, _, err := loaded.Find("hero_01"); err == nil {
+		t.Error("Find(hero_01) should fail for an ambiguous ID")
+	}
+	if key, _, err := loaded.Find("characters/heroes/hero_01"); err != nil || key != "characters/heroes/hero_01" {
+		t.Errorf("Find(characters/heroes/hero_01) = %q, %v", key, err)
+	}
+	if _, _, err := loaded.Find("missing"); err == nil {
+		t.Error("Find(missing) should fail")
+	}
+}
+
+func TestAssetSeedHashStrategy(t *testing.T) {
+	origSeed, origStrategy := pipelineBaseSeed, pipelineSeedStrategy
+	defer func() { pipelineBaseSeed, pipelineSeedStrategy = origSeed, origStrategy }()
+	pipelineBaseSeed = 42
+	pipelineSeedStrategy = seedStrategyHash
+
+	seeds := func(groups []AssetGroup) map[string]int64 {
+		result := make(map[string]int64)
+		for _, job := range planPipeline(groups, "out") {
+			result[job.Key()] = job.Seed
+		}
+		return result
+	}
+
+	before := seeds(testPipelineGroups())
+
+	// Inserting an asset at the start of a group must not change other seeds
+	groups := testPipelineGroups()
+	groups[0].Assets = append([]Asset{{ID: "new_01", Name: "New", Prompt: "new"}}, groups[0].Assets...)
+	after := seeds(groups)
+
+	for key, seed := range before {
+		if after[key] != seed {
+			t.Errorf("seed for %s changed from %d to %d after inserting an asset", key, seed, after[key])
+		}
+	}
+
+	// Same ID in different groups must get different seeds
+	if before["characters/hero_01"] == before["characters/heroes/hero_01"] {
+		t.Error("assets with the same ID in different groups share a seed")
+	}
+
+	// Index strategy shifts seeds when an asset is inserted
+	pipelineSeedStrategy = seedStrategyIndex
+	if got := seeds(groups)["characters/hero_01"]; got != 43 {
+		t.Errorf("index strategy seed = %d, expected 43", got)
+	}
+}
+
+func TestFilterJobs(t *testing.T) {
+	origOnly, origSkip, origTags := pipelineOnly, pipelineSkip, pipelineTags
+	defer func() { pipelineOnly, pipelineSkip, pipelineTags = origOnly, origSkip, origTags }()
+
+	groups := testPipelineGroups()
+	groups[0].OutputDir = "art/chars"
+	groups[0].Assets[1].Tags = []string{"boss"}
+	planned := planPipeline(groups, "out")
+
+	tests := []struct {
+		name string
+		only []string
+		skip []string
+		tags []string
+		want []string
+	}{
+		{"no filters", nil, nil, nil, []string{"characters/hero_01", "characters/villain_01", "characters/heroes/hero_01"}},
+		{"by ID", []string{"villain_01"}, nil, nil, []string{"characters/villain_01"}},
+		{"by full path", []string{"characters/heroes/hero_01"}, nil, nil, []string{"characters/heroes/hero_01"}},
+		{"by group path glob", []string{"characters/heroes/*"}, nil, nil, []string{"characters/heroes/hero_01"}},
+		{"by output dir glob", []string{"art/chars/heroes/*"}, nil, nil, []string{"characters/heroes/hero_01"}},
+		{"by output dir prefix", []string{"art"}, []string{"art/chars/heroes"}, nil, []string{"characters/hero_01", "characters/villain_01"}},
+		{"by group name", []string{"heroes"}, nil, nil, []string{"characters/heroes/hero_01"}},
+		{"by ID glob", []string{"*_01"}, []string{"villain_*"}, nil, []string{"characters/hero_01", "characters/heroes/hero_01"}},
+		{"skip group", nil, []string{"heroes"}, nil, []string{"characters/hero_01", "characters/villain_01"}},
+		{"by tag", nil, nil, []string{"BOSS"}, []string{"characters/villain_01"}},
+		{"no match", []string{"missing"}, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineOnly, pipelineSkip, pipelineTags = tt.only, tt.skip, tt.tags
+
+			var got []string
+			for _, job := range filterJobs(planned) {
+				got = append(got, job.Key())
+
+				// Seeds must match those of the full plan
+				for _, full := range planned {
+					if full.Key() == job.Key() && full.Seed != job.Seed {
+						t.Errorf("seed for %s changed after filtering", job.Key())
+					}
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterJobs = %v, expected %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("filterJobs = %v, expected %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBuildEnhancedPromptIsDeterministic(t *testing.T) {
+	metadata := map[string]interface{}{
+		"style":   "oil painting",
+		"element": "fire",
+		"mood":    "dramatic",
+		"count":   3, // non-string values are ignored
+	}
+
+	expected := "dragon, fire, dramatic, oil painting"
+	for i := 0; i < 20; i++ {
+		if got := buildEnhancedPrompt("dragon", metadata); got != expected {
+			t.Fatalf("buildEnhancedPrompt = %q, expected %q", got, expected)
+		}
+	}
+}
+
+func TestChangedJobs(t *testing.T) {
+	origSeed := pipelineBaseSeed
+	defer func() { pipelineBaseSeed = origSeed }()
+	pipelineBaseSeed = 42
+
+	dir := t.TempDir()
+	manifest, _ := loadPipelineManifest(dir)
+	jobs := planPipeline(testPipelineGroups(), dir)
+
+	if got := len(changedJobs(jobs, manifest)); got != len(jobs) {
+		t.Fatalf("expected all %d jobs to be changed with an empty manifest, got %d", len(jobs), got)
+	}
+
+	// Record every job as generated
+	for _, job := range jobs {
+		entry := newManifestAsset(job, dir)
+		entry.Status = manifestStatusCompleted
+		manifest.Assets[job.Key()] = entry
+		os.MkdirAll(filepath.Dir(job.OutputPath), 0755)
+		os.WriteFile(job.OutputPath, []byte("png"), 0644)
+	}
+	if got := changedJobs(jobs, manifest); len(got) != 0 {
+		t.Fatalf("expected no changed jobs, got %d", len(got))
+	}
+
+	// Editing a prompt only invalidates that asset
+	groups := testPipelineGroups()
+	groups[0].Assets[1].Prompt = "villain with a cape"
+	changed := changedJobs(planPipeline(groups, dir), manifest)
+	if len(changed) != 1 || changed[0].Key() != "characters/villain_01" {
+		t.Errorf("expected only characters/villain_01 to change, got %d jobs", len(changed))
+	}
+
+	// Changing a shared parameter invalidates everything
+	origSteps := pipelineSteps
+	defer func() { pipelineSteps = origSteps }()
+	pipelineSteps = origSteps + 1
+	if got := len(changedJobs(jobs, manifest)); got != len(jobs) {
+		t.Errorf("expected all jobs to change after a parameter change, got %d", got)
+	}
+	pipelineSteps = origSteps
+
+	// Missing output files and failures are regenerated
+	os.Remove(jobs[0].OutputPath)
+	manifest.Assets[jobs[2].Key()].Status = manifestStatusFailed
+	if got := len(changedJobs(jobs, manifest)); got != 2 {
+		t.Errorf("expected 2 changed jobs, got %d", got)
+	}
+}
+
+// newTestGenerationServer starts a mock server that generates 64x48 PNGs.
+// With failFirst set, its first generation fails and it goes offline. running
+// counts generations in progress across servers and maxRunning the most seen.
+func newTestGenerationServer(t *testing.T, lockPath string, failFirst bool, running, maxRunning *int32) (*httptest.Server, *atomic.Bool) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+
+	var down atomic.Bool
+	var generated int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "offline", http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case r.URL.Path == "/API/GetNewSession":
+			fmt.Fprintf(w, `{"session_id": "session-%s"}`, r.Host)
+		case r.URL.Path == "/API/GenerateText2Image":
+			if failFirst {
+				down.Store(true)
+				http.Error(w, "offline", http.StatusServiceUnavailable)
+				return
+			}
+			// The lock is only written once the run is over
+			if _, err := os.Stat(lockPath); err == nil {
+				t.Errorf("lockfile written while assets were still generating")
+			}
+			n := atomic.AddInt32(running, 1)
+			defer atomic.AddInt32(running, -1)
+			for {
+				seen := atomic.LoadInt32(maxRunning)
+				if n <= seen || atomic.CompareAndSwapInt32(maxRunning, seen, n) {
+					break
+				}
+			}
+			time.Sleep(150 * time.Millisecond)
+			fmt.Fprintf(w, `{"images": ["View/local/raw/%d.png"]}`, atomic.AddInt32(&generated, 1))
+		case strings.HasPrefix(r.URL.Path, "/View/"):
+			w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &down
+}
+
+func TestProcessJobsServerPool(t *testing.T) {
+	origDir, origPool, origQuiet, origContinue, origStderr := pipelineOutputDir, pipelinePool, quiet, pipelineContinueError, stderr
+	defer func() {
+		pipelineOutputDir, pipelinePool, quiet, pipelineContinueError, stderr = origDir, origPool, origQuiet, origContinue, origStderr
+	}()
+	stderr = io.Discard
+
+	// Clients keep their state file in the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	outputDir := filepath.Join(dir, "out")
+	pipelineOutputDir, quiet, pipelineContinueError = outputDir, true, false
+	lockPath := filepath.Join(outputDir, lockFileName)
+
+	var running, maxRunning int32
+	gpu1, _ := newTestGenerationServer(t, lockPath, false, &running, &maxRunning)
+	gpu2, _ := newTestGenerationServer(t, lockPath, false, &running, &maxRunning)
+	flaky, flakyDown := newTestGenerationServer(t, lockPath, true, &running, &maxRunning)
+	pipelinePool, err = client.NewServerPool(&client.Config{Servers: []client.ServerConfig{
+		{URL: gpu1.URL, Weight: 1, Concurrency: 2},
+		{URL: gpu2.URL, Weight: 1, Concurrency: 2},
+		{URL: flaky.URL, Weight: 4, Concurrency: 2},
+	}})
+	if err != nil {
+		t.Fatalf("NewServerPool() error = %v", err)
+	}
+
+	group := AssetGroup{Name: "Icons", OutputDir: "icons"}
+	for i := 1; i <= 12; i++ {
+		group.Assets = append(group.Assets, Asset{ID: fmt.Sprintf("icon_%02d", i), Name: fmt.Sprintf("Icon %d", i), Prompt: "an icon"})
+	}
+	jobs := planPipeline([]AssetGroup{group}, outputDir)
+	manifest, _ := loadPipelineManifest(outputDir)
+
+	completed, failed, _, err := processJobs(context.Background(), jobs, manifest)
+	if err != nil || completed != len(jobs) || failed != 0 {
+		t.Fatalf("processJobs() = %d completed, %d failed, %v; expected all %d completed", completed, failed, err, len(jobs))
+	}
+	if !flakyDown.Load() {
+		t.Error("the flaky server never got a generation, so nothing failed over")
+	}
+	if maxRunning < 2 {
+		t.Errorf("at most %d generations ran at once, expected concurrent generations", maxRunning)
+	}
+
+	// Every finished asset was saved to the manifest on disk, on the server
+	// that generated it
+	saved, err := loadPipelineManifest(outputDir)
+	if err != nil {
+		t.Fatalf("loadPipelineManifest() error = %v", err)
+	}
+	for _, job := range jobs {
+		entry := saved.Assets[job.Key()]
+		if entry == nil || entry.Status != manifestStatusCompleted {
+			t.Fatalf("%s not saved as completed: %+v", job.Key(), entry)
+		}
+		if entry.Server != gpu1.URL && entry.Server != gpu2.URL {
+			t.Errorf("%s generated on %s, expected a failover to a healthy server", job.Key(), entry.Server)
+		}
+		if _, err := os.Stat(job.OutputPath); err != nil {
+			t.Errorf("%s has no output: %v", job.Key(), err)
+		}
+	}
+
+	// The lock is written from the finished manifest
+	if err := updatePipelineLock(nil, jobs, jobs, manifest, nil); err != nil {
+		t.Fatalf("updatePipelineLock() error = %v", err)
+	}
+	lock, err := loadPipelineLock(outputDir)
+	if err != nil || lock == nil {
+		t.Fatalf("loadPipelineLock() = %v, %v", lock, err)
+	}
+	for _, job := range jobs {
+		locked := lock.Assets[job.Key()]
+		if locked == nil || locked.Server != saved.Assets[job.Key()].Server || locked.SHA256 == "" {
+			t.Errorf("%s locked as %+v, expected the manifest's server and a hash", job.Key(), locked)
+		}
+	}
+
+	// Every client removed its finished generations from the shared state file
+	data, err := os.ReadFile(filepath.Join(dir, ".asset-generator-state.json"))
+	if err != nil {
+		t.Fatalf("state file missing: %v", err)
+	}
+	if strings.Contains(string(data), `"id"`) {
+		t.Errorf("state file still lists generations: %s", data)
+	}
+}
+
+func TestPipelineLock(t *testing.T) {
+	origSeed, origDir, origModel := pipelineBaseSeed, pipelineOutputDir, pipelineModel
+	defer func() { pipelineBaseSeed, pipelineOutputDir, pipelineModel = origSeed, origDir, origModel }()
+
+	dir := t.TempDir()
+	pipelineBaseSeed, pipelineOutputDir, pipelineModel = 42, dir, "flux1-dev"
+
+	manifest, _ := loadPipelineManifest(dir)
+	jobs := planPipeline(testPipelineGroups(), dir)
+	for _, job := range jobs {
+		entry := newManifestAsset(job, dir)
+		entry.Status = manifestStatusCompleted
+		manifest.Assets[job.Key()] = entry
+		entry.Server = "http://gpu1:7801"
+		os.MkdirAll(filepath.Dir(job.OutputPath), 0755)
+		os.WriteFile(job.OutputPath, []byte("png "+job.Key()), 0644)
+	}
+
+	servers := map[string]lockServer{
+		"http://gpu1:7801": {Version: "0.9.6", Model: "flux1-dev.safetensors", ModelHash: "0xabc"},
+	}
+	if err := updatePipelineLock(nil, jobs, jobs, manifest, servers); err != nil {
+		t.Fatalf("updatePipelineLock failed: %v", err)
+	}
+
+	lock, err := loadPipelineLock(dir)
+	if err != nil || lock == nil {
+		t.Fatalf("loadPipelineLock = %v, %v", lock, err)
+	}
+	hero := lock.Assets["characters/hero_01"]
+	if hero == nil {
+		t.Fatalf("characters/hero_01 missing from lock: %+v", lock.Assets)
+	}
+	if hero.Seed != 42 || hero.Model != "flux1-dev.safetensors" || hero.ModelHash != "0xabc" || hero.ServerVersion != "0.9.6" || hero.SHA256 == "" {
+		t.Errorf("unexpected lock entry: %+v", hero)
+	}
+	if len(hero.Unpinned) != 0 {
+		t.Errorf("fully pinned asset has unpinned notes: %v", hero.Unpinned)
+	}
+
+	// The same settings resolve identically, even after a JSON round trip
+	if diffs := checkPipelineLock(lock, jobs, manifest, servers); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+	if identical, different := compareWithLock(lock, jobs, manifest); identical != len(jobs) || len(different) != 0 {
+		t.Errorf("compareWithLock = %d identical, %v different", identical, different)
+	}
+
+	// A changed parameter, model hash or output is reported
+	origSteps := pipelineSteps
+	defer func() { pipelineSteps = origSteps }()
+	pipelineSteps = origSteps + 1
+	if diffs := checkPipelineLock(lock, jobs[:1], manifest, servers); len(diffs) != 1 || !strings.Contains(diffs[0], "parameters") {
+		t.Errorf("expected a parameters difference, got %v", diffs)
+	}
+	pipelineSteps = origSteps
+
+	// Postprocessing and asset hooks change the output file, so they are pinned too
+	origDownscale, origFormat, origHooks := pipelineDownscaleWidth, pipelineImageFormat, pipelineHooks
+	defer func() {
+		pipelineDownscaleWidth, pipelineImageFormat, pipelineHooks = origDownscale, origFormat, origHooks
+	}()
+	pipelineDownscaleWidth, pipelineImageFormat = 256, "webp"
+	if diffs := checkPipelineLock(lock, jobs[:1], manifest, servers); len(diffs) != 1 || !strings.Contains(diffs[0], "postprocessing") {
+		t.Errorf("expected a postprocessing difference, got %v", diffs)
+	}
+	pipelineDownscaleWidth, pipelineImageFormat = origDownscale, origFormat
+	pipelineHooks = PipelineHooks{AfterAsset: []HookCommand{{Shell: "optipng {{.Output}}"}}}
+	if diffs := checkPipelineLock(lock, jobs[:1], manifest, servers); len(diffs) != 1 || !strings.Contains(diffs[0], "asset hooks") {
+		t.Errorf("expected an asset hooks difference, got %v", diffs)
+	}
+	pipelineHooks = origHooks
+
+	servers["http://gpu1:7801"] = lockServer{Version: "0.9.7", Model: "flux1-dev.safetensors", ModelHash: "0xdef"}
+	if diffs := checkPipelineLock(lock, jobs[:1], manifest, servers); len(diffs) != 2 {
+		t.Errorf("expected model hash and server version differences, got %v", diffs)
+	}
+
+	os.WriteFile(jobs[0].OutputPath, []byte("different"), 0644)
+	if _, different := compareWithLock(lock, jobs, manifest); len(different) != 1 || different[0] != jobs[0].Key() {
+		t.Errorf("expected %s to differ, got %v", jobs[0].Key(), different)
+	}
+
+	// Assets generated on an unknown server explain why they are not pinned
+	pipelineModel = ""
+	locked := resolveLockedAsset(jobs[0], 42, "", servers)
+	if len(locked.Unpinned) != 2 {
+		t.Errorf("expected unknown server and default model notes, got %v", locked.Unpinned)
+	}
+}
+
+func TestLockedScratchRun(t *testing.T) {
+	origSeed, origDir := pipelineBaseSeed, pipelineOutputDir
+	defer func() { pipelineBaseSeed, pipelineOutputDir = origSeed, origDir }()
+
+	dir := t.TempDir()
+	pipelineBaseSeed, pipelineOutputDir = 42, dir
+
+	spec := &PipelineSpec{Assets: testPipelineGroups()}
+	manifest, _ := loadPipelineManifest(dir)
+	jobs := planPipeline(spec.Assets, dir)
+
+	// The first asset keeps a selected candidate
+	os.MkdirAll(filepath.Dir(jobs[0].OutputPath), 0755)
+	os.WriteFile(jobs[0].OutputPath, []byte("shipped"), 0644)
+	manifest.Assets[jobs[0].Key()] = &ManifestAsset{Status: manifestStatusSelected, Selected: 1}
+
+	scratchJobs, scratch, err := lockedScratchRun(spec, jobs, manifest)
+	if err != nil {
+		t.Fatalf("lockedScratchRun failed: %v", err)
+	}
+	if filepath.Dir(pipelineOutputDir) != dir {
+		t.Fatalf("scratch directory %s is not inside %s", pipelineOutputDir, dir)
+	}
+	if len(scratchJobs) != len(jobs)-1 {
+		t.Fatalf("expected %d jobs without the kept selection, got %d", len(jobs)-1, len(scratchJobs))
+	}
+	for i, job := range scratchJobs {
+		if !strings.HasPrefix(job.OutputPath, pipelineOutputDir+string(filepath.Separator)) {
+			t.Errorf("job %s writes outside the scratch directory: %s", job.Key(), job.OutputPath)
+		}
+		// Seeds and relative outputs are those of the shipped run
+		shipped := jobs[i+1]
+		if job.Seed != shipped.Seed || manifestRelPath(pipelineOutputDir, job.OutputPath) != manifestRelPath(dir, shipped.OutputPath) {
+			t.Errorf("job %s resolves differently in the scratch directory", job.Key())
+		}
+	}
+	if len(scratch.Assets) != 0 || scratch.BaseSeed != manifest.BaseSeed {
+		t.Errorf("unexpected scratch manifest: %+v", scratch)
+	}
+	if data, _ := os.ReadFile(jobs[0].OutputPath); string(data) != "shipped" {
+		t.Errorf("shipped output was changed: %q", data)
+	}
+}
+
+func TestWritePipelineReport(t *testing.T) {
+	dir := t.TempDir()
+
+	// A real image so a thumbnail can be embedded
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	file, err := os.Create(filepath.Join(dir, "hero.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(file, img)
+	file.Close()
+
+	manifest := &PipelineManifest{
+		SpecFile: "spec.yaml",
+		BaseSeed: 42,
+		Assets: map[string]*ManifestAsset{
+			"characters/hero_01": {
+				ID: "hero_01", Group: "characters", Prompt: "hero <b>bold</b>", Seed: 42,
+				Output: "hero.png", Status: manifestStatusCompleted, DurationMS: 1500,
+				Parameters: map[string]interface{}{"steps": float64(40), "seed": float64(42)},
+			},
+			"characters/villain_01": {
+				ID: "villain_01", Group: "characters", Seed: 43,
+				Output: "villain.png", Status: manifestStatusFailed, Error: "server timeout",
+			},
+		},
+	}
+
+	reportPath, err := writePipelineReport(dir, manifest)
+	if err != nil {
+		t.Fatalf("writePipelineReport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	html := string(data)
+
+	for _, want := range []string{
+		`src="data:image/png;base64,`,
+		"hero &lt;b&gt;bold&lt;/b&gt;",
+		"server timeout",
+		"1.5s",
+		"<td>steps</td><td>40</td>",
+		"2 assets, 1 failed",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	if strings.Count(html, "data:image/png;base64,") != 1 {
+		t.Errorf("expected exactly one embedded thumbnail")
+	}
+}
+
+func TestLoadLegacyTarotSpec(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Suits are listed out of the conventional order to check that file order is kept
+	legacy := write("legacy.yaml", `
+major_arcana:
+  - number: 0
+    name: The Fool
+    prompt: "young traveler"
+  - number: 1
+    name: The Magician
+    prompt: "magician"
+minor_arcana:
+  cups:
+    suit_element: water
+    suit_color: blue
+    cards:
+      - rank: Ace
+        prompt: "chalice"
+  wands:
+    suit_element: fire
+    cards:
+      - rank: Ace
+        prompt: "wand"
+      - rank: Two
+        name: Dominion
+        prompt: "two wands"
+`)
+
+	spec, err := loadPipelineSpec(legacy)
+	if err != nil {
+		t.Fatalf("loadPipelineSpec failed: %v", err)
+	}
+	if !spec.legacy {
+		t.Error("spec should be marked as legacy")
+	}
+	if got := countAssets(spec.Assets); got != 5 {
+		t.Fatalf("expected 5 assets, got %d", got)
+	}
+
+	origSeed := pipelineBaseSeed
+	defer func() { pipelineBaseSeed = origSeed }()
+	pipelineBaseSeed = 0
+
+	tests := []struct {
+		key    string
+		name   string
+		seed   int64
+		output string
+		prompt string
+	}{
+		{"major_arcana/00", "The Fool", 0, filepath.Join("out", "major-arcana", "00-the_fool.png"), "young traveler"},
+		{"major_arcana/01", "The Magician", 1, filepath.Join("out", "major-arcana", "01-the_magician.png"), "magician"},
+		{"minor_arcana/cups/ace_of_cups", "Ace of Cups", 100, filepath.Join("out", "minor-arcana", "cups", "01-ace_of_cups.png"), "chalice, blue, water"},
+		{"minor_arcana/wands/ace_of_wands", "Ace of Wands", 120, filepath.Join("out", "minor-arcana", "wands", "01-ace_of_wands.png"), "wand, fire"},
+		{"minor_arcana/wands/dominion", "Dominion", 121, filepath.Join("out", "minor-arcana", "wands", "02-dominion.png"), "two wands, fire"},
+	}
+
+	jobs := planPipeline(spec.Assets, "out")
+	if len(jobs) != len(tests) {
+		t.Fatalf("planned %d jobs, expected %d", len(jobs), len(tests))
+	}
+	for i, tt := range tests {
+		job := jobs[i]
+		if job.Key() != tt.key || job.Asset.Name != tt.name || job.Seed != tt.seed || job.OutputPath != tt.output || job.Prompt != tt.prompt {
+			t.Errorf("job %d = {%s %q %d %s %q}, expected %+v", i, job.Key(), job.Asset.Name, job.Seed, job.OutputPath, job.Prompt, tt)
+		}
+	}
+
+	// The migrated file must plan identically
+	data, err := marshalPipelineSpec(spec, legacy)
+	if err != nil {
+		t.Fatalf("marshalPipelineSpec failed: %v", err)
+	}
+	migrated, err := loadPipelineSpec(write("migrated.yaml", string(data)))
+	if err != nil {
+		t.Fatalf("loading migrated spec failed: %v", err)
+	}
+	if migrated.legacy {
+		t.Error("migrated spec should not be marked as legacy")
+	}
+	for i, job := range planPipeline(migrated.Assets, "out") {
+		if job.Key() != jobs[i].Key() || job.Seed != jobs[i].Seed || job.OutputPath != jobs[i].OutputPath || job.Prompt != jobs[i].Prompt {
+			t.Errorf("migrated job %d differs: %s vs %s", i, job.Key(), jobs[i].Key())
+		}
+	}
+
+	// Mixing both formats is an error
+	mixed := write("mixed.yaml", "assets:\n  - name: Icons\n    assets: []\nmajor_arcana:\n  - number: 0\n    name: The Fool\n")
+	if _, err := loadPipelineSpec(mixed); err == nil {
+		t.Error("expected an error for a file mixing both formats")
+	}
+}
+
+func TestMigrateLegacyTarotFixture(t *testing.T) {
+	origSeed := pipelineBaseSeed
+	defer func() { pipelineBaseSeed = origSeed }()
+	pipelineBaseSeed = 42
+
+	legacy, err := loadPipelineSpec(filepath.Join("testdata", "legacy-tarot.yaml"))
+	if err != nil {
+		t.Fatalf("loading the legacy fixture failed: %v", err)
+	}
+	if !legacy.legacy {
+		t.Fatal("fixture should be detected as the legacy format")
+	}
+	generic, err := loadPipelineSpec(filepath.Join("testdata", "legacy-tarot-generic.yaml"))
+	if err != nil {
+		t.Fatalf("loading the generic fixture failed: %v", err)
+	}
+
+	// Migrate the fixture the way 'pipeline migrate' does and load the result
+	data, err := marshalPipelineSpec(legacy, "legacy-tarot.yaml")
+	if err != nil {
+		t.Fatalf("marshalPipelineSpec failed: %v", err)
+	}
+	migratedPath := filepath.Join(t.TempDir(), "legacy-tarot-generic.yaml")
+	if err := os.WriteFile(migratedPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := loadPipelineSpec(migratedPath)
+	if err != nil {
+		t.Fatalf("loading the migrated spec failed: %v", err)
+	}
+
+	want := planPipeline(generic.Assets, "out")
+	if len(want) != 7 {
+		t.Fatalf("generic fixture planned %d jobs, expected 7", len(want))
+	}
+	for name, spec := range map[string]*PipelineSpec{"legacy": legacy, "migrated": migrated} {
+		if got := planPipeline(spec.Assets, "out"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s plan differs from the generic fixture:\n got %+v\nwant %+v", name, got, want)
+		}
+	}
+}
+func TestPipelineHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use POSIX shell commands")
+	}
+
+	var spec PipelineSpec
+	err := yaml.Unmarshal([]byte(`
+hooks:
+  after_asset:
+    - 'echo "$ASSET_ID $ASSET_SEED $ASSET_META_SUIT_ELEMENT $ASSET_STATUS" > {{quote .OutputDir}}/env.txt'
+    - [cp, "{{.OutputDir}}/env.txt", "{{.OutputDir}}/{{.ID}}-{{.Seed}}.txt"]
+  after_group:
+    - 'exit 3'
+`), &spec)
+	if err != nil {
+		t.Fatalf("failed to parse hooks: %v", err)
+	}
+	if len(spec.Hooks.AfterAsset) != 2 || spec.Hooks.AfterAsset[1].Args == nil {
+		t.Fatalf("unexpected hooks: %+v", spec.Hooks)
+	}
+	if err := spec.Hooks.validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	job := pipelineJob{
+		Asset:     Asset{ID: "ace_of_wands"},
+		Seed:      7,
+		OutputDir: dir,
+		Metadata:  map[string]interface{}{"suit_element": "fire"},
+	}
+	hc := assetHookContext(hookAfterAsset, job, &ManifestAsset{Status: manifestStatusCompleted})
+	if err := runHooks(context.Background(), spec.Hooks.AfterAsset, hc); err != nil {
+		t.Fatalf("after_asset hooks failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "ace_of_wands-7.txt"))
+	if err != nil {
+		t.Fatalf("templated command did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "ace_of_wands 7 fire completed" {
+		t.Errorf("hook environment = %q", got)
+	}
+
+	// A nonzero exit is reported as an error
+	err = runHooks(context.Background(), spec.Hooks.AfterGroup, hookContext{Event: hookAfterGroup})
+	if err == nil || !strings.Contains(err.Error(), "after_group") {
+		t.Errorf("expected after_group failure, got %v", err)
+	}
+
+	// Template errors are caught before running
+	bad := PipelineHooks{BeforeAsset: []HookCommand{{Shell: "echo {{.ID"}}}
+	if err := bad.validate(); err == nil {
+		t.Error("expected a template parse error")
+	}
+}
+
+func TestAssetHookContextCandidates(t *testing.T) {
+	dir := t.TempDir()
+	job := pipelineJob{
+		Asset:      Asset{ID: "hero"},
+		OutputDir:  dir,
+		OutputPath: filepath.Join(dir, "hero.png"),
+	}
+
+	// Before generation the output path is where the asset will be written
+	if hc := assetHookContext(hookBeforeAsset, job, nil); hc.Output != job.OutputPath {
+		t.Errorf("before_asset Output = %q, expected %q", hc.Output, job.OutputPath)
+	}
+
+	// Candidates leave the canonical file unwritten
+	entry := &ManifestAsset{
+		Status:     manifestStatusCandidates,
+		Candidates: []ManifestCandidate{{Index: 1}, {Index: 2}},
+	}
+	hc := assetHookContext(hookAfterAsset, job, entry)
+	if hc.Output != "" {
+		t.Errorf("after_asset Output = %q, expected none without the file", hc.Output)
+	}
+	want := []string{candidatePath(job, 1), candidatePath(job, 2)}
+	if fmt.Sprint(hc.Candidates) != fmt.Sprint(want) {
+		t.Errorf("Candidates = %v, expected %v", hc.Candidates, want)
+	}
+	env := strings.Join(hc.env(), "\n")
+	if !strings.Contains(env, "ASSET_CANDIDATES="+strings.Join(want, "\n")) {
+		t.Errorf("environment lacks ASSET_CANDIDATES:\n%s", env)
+	}
+	if !strings.Contains(env, "ASSET_OUTPUT=\n") {
+		t.Errorf("ASSET_OUTPUT should be empty without the file:\n%s", env)
+	}
+
+	// Once the file exists, it is passed on
+	if err := os.WriteFile(job.OutputPath, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry = &ManifestAsset{Status: manifestStatusCompleted}
+	if hc := assetHookContext(hookAfterAsset, job, entry); hc.Output != job.OutputPath || len(hc.Candidates) != 0 {
+		t.Errorf("after_asset Output = %q, Candidates = %v, expected the output file only", hc.Output, hc.Candidates)
+	}
+}
+
+func TestTimingStoreEstimate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timings.json")
+
+	store, err := loadTimingStore(path)
+	if err != nil {
+		t.Fatalf("loadTimingStore on missing file failed: %v", err)
+	}
+	if _, ok := store.Estimate("flux", 20, 512, 512); ok {
+		t.Error("expected no estimate without samples")
+	}
+
+	store.Record("flux", 20, 512, 512, 10*time.Second)
+	store.Record("flux", 20, 512, 512, 20*time.Second)
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := loadTimingStore(path)
+	if err != nil {
+		t.Fatalf("loadTimingStore failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		model  string
+		steps  int
+		width  int
+		height int
+		want   time.Duration
+	}{
+		{"exact match averages", "flux", 20, 512, 512, 15 * time.Second},
+		{"double steps", "flux", 40, 512, 512, 30 * time.Second},
+		{"quadruple pixels", "flux", 20, 1024, 1024, 60 * time.Second},
+		{"unknown model uses all samples", "sdxl", 20, 512, 512, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		got, ok := loaded.Estimate(tt.model, tt.steps, tt.width, tt.height)
+		if !ok || got != tt.want {
+			t.Errorf("%s: Estimate = %v, %v, expected %v", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRunETA(t *testing.T) {
+	eta := &runETA{estimates: []time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second}, remaining: 30 * time.Second}
+
+	if got := eta.Remaining(); got != 30*time.Second {
+		t.Errorf("initial Remaining = %v, expected 30s", got)
+	}
+
+	// The first job took twice as long as estimated, so the rest should too
+	eta.Done(0, 20*time.Second)
+	if got := eta.Remaining(); got != 40*time.Second {
+		t.Errorf("Remaining after slow job = %v, expected 40s", got)
+	}
+
+	// Skipped jobs do not affect the correction
+	eta.Done(1, 0)
+	if got := eta.Remaining(); got != 20*time.Second {
+		t.Errorf("Remaining after skipped job = %v, expected 20s", got)
+	}
+
+	var none *runETA
+	none.Done(0, time.Second)
+	if none.String() != "" {
+		t.Error("nil runETA should format as an empty string")
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait after the last change to the pipeline
+// file before regenerating, so that editors writing in several steps
+// trigger a single pass
+const watchDebounce = 500 * time.Millisecond
+
+// watchPipeline regenerates changed assets whenever the pipeline file is saved.
+// Only assets whose resolved prompt or parameters differ from the hash stored
+// in the manifest are regenerated. Failures never stop the watch.
+func watchPipeline(ctx context.Context, manifest *PipelineManifest) error {
+	specPath, err := filepath.Abs(pipelineFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve pipeline file path: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory rather than the file itself: many editors save by
+	// writing a new file and renaming it over the original
+	if err := watcher.Add(filepath.Dir(specPath)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(specPath), err)
+	}
+
+	// Failed assets are retried on the next change instead of ending the watch
+	pipelineContinueError = true
+
+	runWatchPass(ctx, manifest)
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Watching %s for changes (press Ctrl+C to stop)...\n\n", pipelineFile)
+	}
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != specPath {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				debounce.Reset(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "⚠ Warning: File watcher error: %v\n", err)
+
+		case <-debounce.C:
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Change detected in %s\n", pipelineFile)
+			}
+			runWatchPass(ctx, manifest)
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Watching for changes...\n\n")
+			}
+		}
+	}
+}
+
+// runWatchPass reloads the pipeline file and regenerates the assets that
+// changed since they were last generated
+func runWatchPass(ctx context.Context, manifest *PipelineManifest) {
+	spec, err := loadPipelineSpec(pipelineFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to load pipeline: %v\n\n", err)
+		return
+	}
+	if err := resolveSeedStrategy(spec); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n\n", err)
+		return
+	}
+	if err := spec.Hooks.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n\n", err)
+		return
+	}
+	pipelineHooks = spec.Hooks
+
+	jobs := changedJobs(filterJobs(planPipeline(spec.Assets, pipelineOutputDir)), manifest)
+	if len(jobs) == 0 {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "All assets are up to date\n")
+		}
+		return
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Regenerating %d changed asset(s)\n\n", len(jobs))
+	}
+
+	start := time.Now()
+	completed, failed, kept, err := processJobs(ctx, jobs, manifest)
+	events.Done(totalsPayload{Total: len(jobs), Completed: completed, Failed: failed, Skipped: kept}, start, err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n", err)
+	}
+	if err == nil {
+		if err := runAfterPipelineHooks(ctx, completed, failed); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n", err)
+		}
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Pass complete: %d generated, %d kept, %d failed\n", completed, kept, failed)
+	}
+
+	if pipelineReport {
+		if reportPath, err := writePipelineReport(pipelineOutputDir, manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to write report: %v\n", err)
+		} else if !quiet {
+			fmt.Fprintf(os.Stderr, "Report updated: %s\n", reportPath)
+		}
+	}
+}
+
+// changedJobs returns the jobs whose stored hash no longer matches
+func changedJobs(jobs []pipelineJob, manifest *PipelineManifest) []pipelineJob {
+	var changed []pipelineJob
+	for _, job := range jobs {
+		if !manifest.Unchanged(job) {
+			changed = append(changed, job)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+)
+
+// stderr receives the progress messages of commands. While a live progress
+// display runs it points at the display, which keeps its bars below them.
+var stderr io.Writer = os.Stderr
+
+const (
+	progressBarWidth   = 24
+	progressLabelWidth = 24
+	progressRedraw     = 200 * time.Millisecond
+	progressLogStep    = 0.25 // Plain logs report progress every quarter
+)
+
+// progressDisplay shows the progress of running generations on stderr: a bar
+// per generation redrawn in place on a terminal, or log lines otherwise
+type progressDisplay struct {
+	out  io.Writer
+	live bool // Draw bars in place (stderr is a terminal)
+
+	mu      sync.Mutex
+	tasks   []*progressTask // Running generations, in start order
+	drawn   int             // Lines of bars currently on screen
+	partial []byte          // Incomplete line written through Write
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// newProgressDisplay returns a display on stderr, or nil in quiet mode. A nil
+// display and its tasks do nothing.
+func newProgressDisplay() *progressDisplay {
+	if quiet {
+		return nil
+	}
+	d := &progressDisplay{out: os.Stderr, live: isTerminal(os.Stderr)}
+	if d.live {
+		d.stop = make(chan struct{})
+		d.stopped = make(chan struct{})
+		stderr = d
+		go d.run()
+	}
+	return d
+}
+
+// isTerminal reports whether f is an interactive terminal that understands
+// cursor movement
+func isTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// run redraws the bars periodically, so elapsed times and ETAs keep moving
+func (d *progressDisplay) run() {
+	ticker := time.NewTicker(progressRedraw)
+	defer ticker.Stop()
+	defer close(d.stopped)
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.mu.Lock()
+			d.redraw()
+			d.mu.Unlock()
+		}
+	}
+}
+
+// Stop clears the bars and sends messages to stderr directly again
+func (d *progressDisplay) Stop() {
+	if d == nil {
+		return
+	}
+	if d.live {
+		close(d.stop)
+		<-d.stopped
+		stderr = os.Stderr
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	if len(d.partial) > 0 {
+		d.out.Write(d.partial)
+		d.partial = nil
+	}
+	d.tasks = nil
+}
+
+// Write prints complete lines above the bars
+func (d *progressDisplay) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.partial = append(d.partial, p...)
+	end := bytes.LastIndexByte(d.partial, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	d.clear()
+	if _, err := d.out.Write(d.partial[:end+1]); err != nil {
+		return 0, err
+	}
+	d.partial = append([]byte(nil), d.partial[end+1:]...)
+	d.draw()
+	return len(p), nil
+}
+
+// clear removes the bars from the screen; mu must be held
+func (d *progressDisplay) clear() {
+	if d.drawn > 0 {
+		fmt.Fprintf(d.out, "\033[%dF\033[J", d.drawn)
+		d.drawn = 0
+	}
+}
+
+// draw writes a line per running task; mu must be held
+func (d *progressDisplay) draw() {
+	if !d.live || len(d.tasks) == 0 {
+		return
+	}
+	var b strings.Builder
+	now := time.Now()
+	for _, t := range d.tasks {
+		b.WriteString(t.line(now))
+		b.WriteByte('\n')
+	}
+	io.WriteString(d.out, b.String())
+	d.drawn = len(d.tasks)
+}
+
+// redraw replaces the bars on screen; mu must be held
+func (d *progressDisplay) redraw() {
+	if d.live {
+		d.clear()
+		d.draw()
+	}
+}
+
+// Start adds a running generation to the display
+func (d *progressDisplay) Start(label string) *progressTask {
+	if d == nil {
+		return nil
+	}
+	t := &progressTask{display: d, label: label, start: time.Now()}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tasks = append(d.tasks, t)
+	d.redraw()
+	return t
+}
+
+// progressTask is the progress of one generation on a display
+type progressTask struct {
+	display *progressDisplay
+	label   string
+	start   time.Time
+
+	// Guarded by display.mu
+	progress      float64
+	status        string                // Status text of the plain callback
+	update        client.ProgressUpdate // Latest update from the server
+	detailed      bool                  // Whether the server sent updates
+	rateStart     time.Time             // When progress started moving, for the ETA
+	rateProgress  float64               // Progress at rateStart
+	loggedPhase   string                // Plain mode: phase last logged
+	loggedQuarter int                   // Plain mode: quarter of progress last logged
+}
+
+// Attach reports the progress of a request to the task, keeping any
+// ProgressUpdateCallback already set (e.g. for previews)
+func (t *progressTask) Attach(req *client.GenerationRequest) {
+	if t == nil {
+		return
+	}
+	req.ProgressCallback = t.setProgress
+	previous := req.ProgressUpdateCallback
+	req.ProgressUpdateCallback = func(update client.ProgressUpdate) {
+		t.setUpdate(update)
+		if previous != nil {
+			previous(update)
+		}
+	}
+}
+
+// Done removes the task from the display
+func (t *progressTask) Done() {
+	if t == nil {
+		return
+	}
+	d := t.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, task := range d.tasks {
+		if task == t {
+			d.tasks = append(d.tasks[:i], d.tasks[i+1:]...)
+			break
+		}
+	}
+	d.redraw()
+}
+
+// setProgress handles the plain ProgressCallback, which is all servers that
+// don't report progress provide (simulated progress)
+func (t *progressTask) setProgress(progress float64, status string) {
+	t.display.mu.Lock()
+	defer t.display.mu.Unlock()
+	if t.detailed {
+		return // The server's updates carry the same progress
+	}
+	t.status = status
+	t.advance(progress)
+}
+
+// setUpdate handles the detailed progress reported by the server
+func (t *progressTask) setUpdate(update client.ProgressUpdate) {
+	t.display.mu.Lock()
+	defer t.display.mu.Unlock()
+	t.detailed = true
+	t.update = update
+	t.advance(update.Progress)
+}
+
+// advance records new progress; display.mu must be held
+func (t *progressTask) advance(progress float64) {
+	if progress >= 1 {
+		return // Completion is reported by the command itself
+	}
+	if progress > t.progress {
+		if t.rateStart.IsZero() {
+			t.rateStart, t.rateProgress = time.Now(), t.progress
+		}
+		t.progress = progress
+	}
+	if !t.display.live {
+		t.log(time.Now())
+	}
+}
+
+// phase returns the phase to show
+func (t *progressTask) phase() string {
+	if t.detailed && t.update.Phase != "" {
+		return t.update.Phase
+	}
+	if t.progress > 0 {
+		return client.PhaseGenerating
+	}
+	return ""
+}
+
+// eta estimates the time left from the rate progress has moved at
+func (t *progressTask) eta(now time.Time) (time.Duration, bool) {
+	moved := t.progress - t.rateProgress
+	if t.rateStart.IsZero() || moved < 0.02 {
+		return 0, false
+	}
+	elapsed := now.Sub(t.rateStart)
+	return time.Duration(float64(elapsed) * (1 - t.progress) / moved), true
+}
+
+// detail describes the phase, step and ETA
+func (t *progressTask) detail(now time.Time) string {
+	switch t.phase() {
+	case client.PhaseQueued:
+		return "queued"
+	case client.PhaseLoadingModel:
+		return "loading model"
+	case client.PhaseGenerating:
+		var parts []string
+		if t.update.Steps > 0 {
+			parts = append(parts, fmt.Sprintf("step %d/%d", t.update.Step, t.update.Steps))
+		}
+		if eta, ok := t.eta(now); ok {
+			parts = append(parts, "ETA "+formatEstimate(eta.Round(time.Second)))
+		}
+		if len(parts) == 0 {
+			return "generating"
+		}
+		return strings.Join(parts, ", ")
+	}
+	return "starting"
+}
+
+// line renders the task's bar
+func (t *progressTask) line(now time.Time) string {
+	filled := int(t.progress * progressBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	elapsed := now.Sub(t.start).Round(time.Second)
+	return fmt.Sprintf("  %s %s %3.0f%%  %s (%s)", padLabel(t.label), bar, t.progress*100, t.detail(now), elapsed)
+}
+
+// log writes a line when the phase changes or progress passes a quarter;
+// display.mu must be held
+func (t *progressTask) log(now time.Time) {
+	phase := t.phase()
+	quarter := int(t.progress / progressLogStep)
+	if phase == t.loggedPhase && quarter <= t.loggedQuarter {
+		return
+	}
+	t.loggedPhase, t.loggedQuarter = phase, quarter
+	if phase == "" {
+		return
+	}
+	fmt.Fprintf(t.display.out, "  %s: %.0f%% %s\n", t.label, t.progress*100, t.detail(now))
+}
+
+// padLabel fits a label to the label column
+func padLabel(label string) string {
+	if utf8.RuneCountInString(label) > progressLabelWidth {
+		runes := []rune(label)
+		return string(runes[:progressLabelWidth-1]) + "…"
+	}
+	return label + strings.Repeat(" ", progressLabelWidth-utf8.RuneCountInString(label))
+}
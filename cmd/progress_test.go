@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+)
+
+func TestProgressTaskLine(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		task     progressTask
+		contains []string
+	}{
+		{
+			name:     "starting",
+			task:     progressTask{label: "hero", start: now},
+			contains: []string{"hero ", "░░░░", "  0%", "starting", "(0s)"},
+		},
+		{
+			name:     "queued",
+			task:     progressTask{label: "hero", start: now.Add(-5 * time.Second), detailed: true, update: client.ProgressUpdate{Phase: client.PhaseQueued}},
+			contains: []string{"queued", "(5s)"},
+		},
+		{
+			name:     "loading model",
+			task:     progressTask{label: "hero", start: now, detailed: true, update: client.ProgressUpdate{Phase: client.PhaseLoadingModel}},
+			contains: []string{"loading model"},
+		},
+		{
+			name: "generating with steps and ETA",
+			task: progressTask{
+				label: "hero", start: now.Add(-40 * time.Second), progress: 0.5, detailed: true,
+				update:    client.ProgressUpdate{Phase: client.PhaseGenerating, Progress: 0.5, Step: 15, Steps: 30},
+				rateStart: now.Add(-30 * time.Second),
+			},
+			contains: []string{"████████████░░░░░░░░░░░░", " 50%", "step 15/30, ETA 30s", "(40s)"},
+		},
+		{
+			name:     "simulated progress",
+			task:     progressTask{label: "a very long asset name that does not fit", start: now, progress: 0.25, status: "Generating..."},
+			contains: []string{"a very long asset name …", " 25%", "generating"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.task.line(now)
+			for _, want := range tt.contains {
+				if !strings.Contains(line, want) {
+					t.Errorf("line %q does not contain %q", line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProgressDisplayPlain(t *testing.T) {
+	var out bytes.Buffer
+	d := &progressDisplay{out: &out}
+
+	task := d.Start("hero")
+	req := &client.GenerationRequest{}
+	task.Attach(req)
+
+	req.ProgressCallback(0, "Starting generation...")
+	req.ProgressUpdateCallback(client.ProgressUpdate{Phase: client.PhaseQueued})
+	req.ProgressUpdateCallback(client.ProgressUpdate{Phase: client.PhaseQueued})
+	for step := 1; step <= 10; step++ {
+		progress := float64(step) / 10
+		req.ProgressUpdateCallback(client.ProgressUpdate{Phase: client.PhaseGenerating, Progress: progress, Step: step, Steps: 10})
+		req.ProgressCallback(progress, "")
+	}
+	task.Done()
+	d.Stop()
+
+	// Phase changes and each quarter are logged, completion is left to the command
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{"hero: 0% queued", "hero: 10% step 1/10", "hero: 30% step 3/10", "hero: 50% step 5/10", "hero: 80% step 8/10"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i := range want {
+		if !strings.Contains(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want it to contain %q", i, lines[i], want[i])
+		}
+	}
+	if strings.Contains(out.String(), "\033[") {
+		t.Error("plain output contains terminal escape sequences")
+	}
+}
+
+func TestProgressDisplayLive(t *testing.T) {
+	var out bytes.Buffer
+	d := &progressDisplay{out: &out, live: true}
+
+	first := d.Start("hero")
+	second := d.Start("villain")
+	if d.drawn != 2 {
+		t.Fatalf("drawn = %d lines, expected a bar per task", d.drawn)
+	}
+
+	// Messages are printed above the bars, which are redrawn below them
+	out.Reset()
+	fmt.Fprint(d, "[1/2] Generating: ")
+	if out.Len() != 0 {
+		t.Errorf("incomplete line written early: %q", out.String())
+	}
+	fmt.Fprint(d, "hero\n")
+	got := out.String()
+	if !strings.HasPrefix(got, "\033[2F\033[J[1/2] Generating: hero\n") {
+		t.Errorf("message not written above the bars: %q", got)
+	}
+	if !strings.Contains(got, "hero ") || !strings.Contains(got, "villain ") {
+		t.Errorf("bars not redrawn after the message: %q", got)
+	}
+
+	first.Done()
+	if d.drawn != 1 {
+		t.Errorf("drawn = %d after a task finished, expected 1", d.drawn)
+	}
+	second.Done()
+
+	out.Reset()
+	d.clear()
+	if d.drawn != 0 || out.Len() != 0 {
+		t.Errorf("bars left on screen: %d lines, %q", d.drawn, out.String())
+	}
+}
+
+func TestProgressDisplayNil(t *testing.T) {
+	// Quiet mode has no display; its tasks must be safe to use
+	var d *progressDisplay
+	task := d.Start("hero")
+	req := &client.GenerationRequest{}
+	task.Attach(req)
+	task.Done()
+	d.Stop()
+	if req.ProgressCallback != nil {
+		t.Error("nil task set a progress callback")
+	}
+}
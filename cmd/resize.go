@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resizeWidth      int
+	resizeHeight     int
+	resizeMode       string
+	resizeAnchor     string
+	resizeBackground string
+	resizeNoUpscale  bool
+	resizeFilter     string
+	resizeLinear     bool
+	resizeQuality    int
+	resizeFormat     string
+	resizeOutput     string
+	resizeInPlace    bool
+)
+
+// resizeCmd represents the resize command
+var resizeCmd = &cobra.Command{
+	Use:   "resize [image-file...]",
+	Short: "Resize images to an exact box, scaling up or down",
+	Long: `Resize one or more images to a target size, scaling up or down.
+
+Unlike downscale, resize always produces the requested dimensions when both
+--width and --height are given, fitting the image to the box with a mode:
+
+  fit    Scale to fit inside the box and pad the rest (letterbox) (default)
+  fill   Scale to cover the box and crop the overflow (alias: cover)
+  exact  Stretch to the box, ignoring the aspect ratio
+
+--anchor chooses the part kept when cropping and where the image sits when
+padding: center (default), top, bottom, left, right, top-left, top-right,
+bottom-left or bottom-right. Padding is transparent unless --background sets
+a color. With only one dimension the other follows the aspect ratio.
+
+Transparent pixels never darken the edges next to them. --linear blends in
+linear light rather than sRGB, which keeps thin highlights and outlines at
+their true brightness.
+
+Examples:
+  # Exact 256x256 icons from non-square generations, cropping the overflow
+  asset-generator resize icon.png --width 256 --height 256 --mode fill
+
+  # Keep the top of portraits when cropping to a square
+  asset-generator resize portrait.png -w 512 -l 512 --mode fill --anchor top
+
+  # Letterbox into 1920x1080 on black
+  asset-generator resize art.png -w 1920 -l 1080 --background black
+
+  # Upscale pixel art 4x without blurring
+  asset-generator resize sprite.png --width 256 --filter nearest
+
+  # Stretch to a texture size, in place
+  asset-generator resize texture.png -w 512 -l 512 --mode exact --in-place
+
+  # Never enlarge small images; pad them to the box instead
+  asset-generator resize *.png -w 1024 -l 1024 --no-upscale --in-place
+
+Output files are written next to their inputs as name_resized.ext unless
+--output or --in-place is given. The output format follows the extension,
+or --format. JPEG output, which cannot hold transparency, is flattened onto
+white.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runResize,
+}
+
+func init() {
+	rootCmd.AddCommand(resizeCmd)
+
+	resizeCmd.Flags().IntVarP(&resizeWidth, "width", "w", 0, "target width in pixels (0=auto from height)")
+	resizeCmd.Flags().IntVarP(&resizeHeight, "height", "l", 0, "target height in pixels (0=auto from width)")
+	resizeCmd.Flags().StringVar(&resizeMode, "mode", "fit", "how to fit the box: fit, fill (cover), exact")
+	resizeCmd.Flags().StringVar(&resizeAnchor, "anchor", "center", "alignment when cropping or padding: center, top, bottom, left, right, top-left, ...")
+	resizeCmd.Flags().StringVar(&resizeBackground, "background", "transparent", "padding color, as a name or #rrggbb")
+	resizeCmd.Flags().BoolVar(&resizeNoUpscale, "no-upscale", false, "never enlarge images; pad smaller ones instead")
+	resizeCmd.Flags().StringVar(&resizeFilter, "filter", "lanczos", "resampling filter: lanczos, bilinear, nearest")
+	resizeCmd.Flags().BoolVar(&resizeLinear, "linear", false, "resample in linear light instead of sRGB (slower, truer brightness)")
+	resizeCmd.Flags().IntVar(&resizeQuality, "quality", 90, "JPEG quality (1-100)")
+	resizeCmd.Flags().StringVar(&resizeFormat, "format", "", "output format: png, jpeg, webp, gif, bmp, tiff (default: from the output extension)")
+	resizeCmd.Flags().StringVarP(&resizeOutput, "output", "o", "", "output file path (single file mode only)")
+	resizeCmd.Flags().BoolVar(&resizeInPlace, "in-place", false, "replace original file(s) with the resized version")
+}
+
+func runResize(cmd *cobra.Command, args []string) error {
+	opts, err := parseResizeOptions(resizeWidth, resizeHeight, resizeMode, resizeAnchor, resizeBackground, resizeFilter, resizeQuality, resizeFormat)
+	if err != nil {
+		return err
+	}
+	opts.NoUpscale = resizeNoUpscale
+	opts.Linear = resizeLinear
+
+	// Validate output flag usage
+	if resizeOutput != "" && len(args) > 1 {
+		return fmt.Errorf("--output can only be used with a single input file")
+	}
+	if resizeOutput != "" && resizeInPlace {
+		return fmt.Errorf("cannot specify both --output and --in-place")
+	}
+
+	processedCount := 0
+	errorCount := 0
+
+	for _, inputPath := range args {
+		if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", inputPath)
+			errorCount++
+			continue
+		}
+
+		outputPath := resizeOutputPath(inputPath, resizeOutput, opts.Format, resizeInPlace)
+
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Resizing: %s -> %s (%dx%d, mode: %s, anchor: %s)\n",
+				inputPath, outputPath, resizeWidth, resizeHeight, opts.Mode, opts.Anchor)
+		}
+
+		if err := processor.ResizeImage(inputPath, outputPath, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error resizing %s: %v\n", inputPath, err)
+			errorCount++
+			continue
+		}
+		if resizeInPlace && outputPath != inputPath {
+			if err := os.Remove(inputPath); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Warning: Failed to remove %s: %v\n", inputPath, err)
+			}
+		}
+
+		processedCount++
+		if !quiet {
+			width, height, err := processor.GetImageDimensions(outputPath)
+			if err == nil {
+				fmt.Fprintf(os.Stderr, "✓ Resized: %s (%dx%d)\n", outputPath, width, height)
+			} else {
+				fmt.Fprintf(os.Stderr, "✓ Resized: %s\n", outputPath)
+			}
+		}
+	}
+
+	// Summary
+	if !quiet && len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "\nResize complete: %d succeeded, %d failed\n", processedCount, errorCount)
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("failed to resize %d image(s)", errorCount)
+	}
+
+	return nil
+}
+
+// parseResizeOptions validates the size flags and converts the mode, anchor,
+// background, filter, quality and format flags
+func parseResizeOptions(width, height int, mode, anchor, background, filter string, quality int, format string) (processor.ResizeOptions, error) {
+	opts := processor.ResizeOptions{Width: width, Height: height, JPEGQuality: quality}
+	if width < 0 || height < 0 {
+		return opts, fmt.Errorf("width and height cannot be negative")
+	}
+	if width == 0 && height == 0 {
+		return opts, fmt.Errorf("at least one of --width or --height is required")
+	}
+	if quality < 1 || quality > 100 {
+		return opts, fmt.Errorf("quality must be between 1 and 100")
+	}
+
+	var err error
+	if opts.Mode, err = processor.ParseResizeMode(mode); err != nil {
+		return opts, err
+	}
+	if opts.Anchor, err = processor.ParseAnchor(anchor); err != nil {
+		return opts, err
+	}
+	if opts.Background, err = processor.ParseColor(background); err != nil {
+		return opts, err
+	}
+	if opts.Filter, err = processor.ParseResizeFilter(filter); err != nil {
+		return opts, err
+	}
+	if format != "" {
+		if opts.Format, err = processor.ParseFormat(format); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// resizeOutputPath returns the file an input is resized to: the input itself
+// in place (with the extension of a new format, replacing the original), the
+// --output path, or name_resized.ext next to the input
+func resizeOutputPath(inputPath, output, format string, inPlace bool) string {
+	switch {
+	case inPlace:
+		if format != "" {
+			return processor.ReplaceExtension(inputPath, format)
+		}
+		return inputPath
+	case output != "":
+		return output
+	}
+	ext := filepath.Ext(inputPath)
+	if format != "" {
+		ext = processor.Extension(format)
+	}
+	return fmt.Sprintf("%s_resized%s", strings.TrimSuffix(inputPath, filepath.Ext(inputPath)), ext)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+)
+
+func TestParseResizeOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		mode       string
+		anchor     string
+		filter     string
+		quality    int
+		format     string
+		wantMode   processor.ResizeMode
+		wantFormat string
+		wantErr    bool
+	}{
+		{name: "defaults", width: 256, height: 256, mode: "fit", wantMode: processor.ResizeFit},
+		{name: "cover alias", width: 256, height: 256, mode: "cover", wantMode: processor.ResizeFill},
+		{name: "width only", width: 256, mode: "exact", wantMode: processor.ResizeExact},
+		{name: "height only", height: 256, mode: "fill", wantMode: processor.ResizeFill},
+		{name: "jpg format", width: 256, mode: "fit", format: "jpg", wantMode: processor.ResizeFit, wantFormat: "jpeg"},
+		{name: "no size", mode: "fit", wantErr: true},
+		{name: "negative width", width: -1, height: 256, mode: "fit", wantErr: true},
+		{name: "negative height", width: 256, height: -1, mode: "fit", wantErr: true},
+		{name: "bad mode", width: 256, mode: "squash", wantErr: true},
+		{name: "bad anchor", width: 256, mode: "fit", anchor: "middle", wantErr: true},
+		{name: "bad filter", width: 256, mode: "fit", filter: "bicubic", wantErr: true},
+		{name: "bad quality", width: 256, mode: "fit", quality: 101, wantErr: true},
+		{name: "bad format", width: 256, mode: "fit", format: "svg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anchor, filter, quality := tt.anchor, tt.filter, tt.quality
+			if anchor == "" {
+				anchor = "center"
+			}
+			if filter == "" {
+				filter = "lanczos"
+			}
+			if quality == 0 {
+				quality = 90
+			}
+			opts, err := parseResizeOptions(tt.width, tt.height, tt.mode, anchor, "transparent", filter, quality, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseResizeOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if opts.Mode != tt.wantMode {
+				t.Errorf("parseResizeOptions() mode = %q, want %q", opts.Mode, tt.wantMode)
+			}
+			if opts.Format != tt.wantFormat {
+				t.Errorf("parseResizeOptions() format = %q, want %q", opts.Format, tt.wantFormat)
+			}
+			if opts.Width != tt.width || opts.Height != tt.height {
+				t.Errorf("parseResizeOptions() size = %dx%d, want %dx%d", opts.Width, opts.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestResizeOutputPath(t *testing.T) {
+	input := filepath.Join("art", "icon.png")
+
+	tests := []struct {
+		name    string
+		output  string
+		format  string
+		inPlace bool
+		want    string
+	}{
+		{name: "next to input", want: filepath.Join("art", "icon_resized.png")},
+		{name: "next to input with format", format: "jpeg", want: filepath.Join("art", "icon_resized.jpg")},
+		{name: "explicit output", output: "out.webp", format: "webp", want: "out.webp"},
+		{name: "in place", inPlace: true, want: input},
+		{name: "in place with format", format: "webp", inPlace: true, want: filepath.Join("art", "icon.webp")},
+		{name: "in place with same format", format: "png", inPlace: true, want: input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resizeOutputPath(input, tt.output, tt.format, tt.inPlace); got != tt.want {
+				t.Errorf("resizeOutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunResizeOutputFlags(t *testing.T) {
+	origWidth, origOutput, origInPlace := resizeWidth, resizeOutput, resizeInPlace
+	defer func() { resizeWidth, resizeOutput, resizeInPlace = origWidth, origOutput, origInPlace }()
+	resizeWidth = 256
+
+	resizeOutput, resizeInPlace = "out.png", false
+	if err := runResize(resizeCmd, []string{"a.png", "b.png"}); err == nil {
+		t.Error("runResize accepted --output with several inputs")
+	}
+	resizeOutput, resizeInPlace = "out.png", true
+	if err := runResize(resizeCmd, []string{"a.png"}); err == nil {
+		t.Error("runResize accepted --output with --in-place")
+	}
+}
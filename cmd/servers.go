@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/opd-ai/asset-generator/pkg/client"
+	"github.com/spf13/viper"
+)
+
+// serverConfigs returns the servers to generate on: those given with
+// --server (as "URL[,weight=N][,concurrency=N]"), else the "servers" list of
+// the config file. An empty result means the single server set by --api-url.
+func serverConfigs(specs []string) ([]client.ServerConfig, error) {
+	if len(specs) > 0 {
+		servers := make([]client.ServerConfig, 0, len(specs))
+		for _, spec := range specs {
+			server, err := client.ParseServerSpec(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --server '%s': %w", spec, err)
+			}
+			servers = append(servers, server)
+		}
+		return servers, nil
+	}
+
+	var servers []client.ServerConfig
+	if err := viper.UnmarshalKey("servers", &servers); err != nil {
+		return nil, fmt.Errorf("invalid servers in config: %w", err)
+	}
+	return servers, nil
+}
+
+// newServerPool creates a pool of the servers given with --server or in the
+// config file and checks which of them are online
+func newServerPool(ctx context.Context, specs []string) (*client.ServerPool, error) {
+	servers, err := serverConfigs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := client.NewServerPool(&client.Config{
+		BaseURL: viper.GetString("api-url"),
+		APIKey:  viper.GetString("api-key"),
+		Verbose: verbose,
+		Servers: servers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure servers: %w", err)
+	}
+
+	pool.OnFailover = func(server string, err error) {
+		fmt.Fprintf(stderr, "  ⚠ Warning: Server %s went offline (%v), retrying on another server\n", server, err)
+	}
+
+	// A single server is not checked up front, as before: errors surface
+	// with the first generation
+	if pool.Len() == 1 {
+		return pool, nil
+	}
+
+	healthy, err := pool.CheckHealth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("none of the %d servers is reachable: %w", pool.Len(), err)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Servers: %d/%d online, %d concurrent generations\n", healthy, pool.Len(), pool.Capacity())
+		for _, state := range pool.States() {
+			status := "online"
+			if !state.Healthy {
+				status = "offline (retrying during the run)"
+			}
+			fmt.Fprintf(os.Stderr, "  %s (weight %d, concurrency %d): %s\n", state.URL, state.Weight, state.Concurrency, status)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	return pool, nil
+}
+
+// printServerSummary prints the work done by each server of a multi-server run
+func printServerSummary(pool *client.ServerPool) {
+	if pool == nil || pool.Len() == 1 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Servers:\n")
+	for _, state := range pool.States() {
+		fmt.Fprintf(os.Stderr, "  %s: %d generated", state.URL, state.Completed)
+		if state.Failed > 0 {
+			fmt.Fprintf(os.Stderr, ", %d failed", state.Failed)
+		}
+		if !state.Healthy {
+			fmt.Fprintf(os.Stderr, " (offline)")
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/opd-ai/asset-generator/pkg/client"
 	"github.com/opd-ai/asset-generator/pkg/output"
@@ -135,6 +136,12 @@ func formatStatusTable(status *client.ServerStatus) string {
 			result += fmt.Sprintf("    Session ID:    %s\n", gen.SessionID)
 			result += fmt.Sprintf("    Status:        %s\n", colorizeStatus(gen.Status))
 			result += fmt.Sprintf("    Progress:      %.1f%%\n", gen.Progress*100)
+			if gen.Phase != "" {
+				result += fmt.Sprintf("    Phase:         %s\n", strings.ReplaceAll(gen.Phase, "_", " "))
+			}
+			if gen.Steps > 0 {
+				result += fmt.Sprintf("    Step:          %d/%d\n", gen.Step, gen.Steps)
+			}
 			result += fmt.Sprintf("    Duration:      %s\n", gen.Duration)
 			result += fmt.Sprintf("\n")
 		}
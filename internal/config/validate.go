@@ -18,6 +18,11 @@ func ValidateConfig() error {
 		}
 	}
 
+	// Validate generation servers
+	if err := validateServers(); err != nil {
+		return err
+	}
+
 	// Validate output format
 	format := viper.GetString("format")
 	if !isValidFormat(format) {
@@ -27,6 +32,37 @@ func ValidateConfig() error {
 	return nil
 }
 
+// validateServers validates the "servers" list used to spread pipeline
+// generations across several servers
+func validateServers() error {
+	if !viper.IsSet("servers") {
+		return nil
+	}
+
+	var servers []struct {
+		URL         string
+		Weight      int
+		Concurrency int
+	}
+	if err := viper.UnmarshalKey("servers", &servers); err != nil {
+		return fmt.Errorf("invalid servers: %w", err)
+	}
+
+	for i, server := range servers {
+		if err := validateURL(server.URL); err != nil {
+			return fmt.Errorf("invalid url of server %d: %w", i+1, err)
+		}
+		if server.Weight < 0 {
+			return fmt.Errorf("invalid weight of server %d: %d (must not be negative)", i+1, server.Weight)
+		}
+		if server.Concurrency < 0 {
+			return fmt.Errorf("invalid concurrency of server %d: %d (must not be negative)", i+1, server.Concurrency)
+		}
+	}
+
+	return nil
+}
+
 // validateURL validates a URL
 func validateURL(urlStr string) error {
 	parsedURL, err := url.Parse(urlStr)
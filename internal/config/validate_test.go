@@ -66,6 +66,36 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid servers",
+			setup: func() {
+				viper.Reset()
+				viper.Set("format", "table")
+				viper.Set("servers", []map[string]interface{}{
+					{"url": "http://gpu1:7801", "weight": 2, "concurrency": 2},
+					{"url": "http://gpu2:7801"},
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid server URL",
+			setup: func() {
+				viper.Reset()
+				viper.Set("format", "table")
+				viper.Set("servers", []map[string]interface{}{{"url": "gpu1:7801"}})
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative server weight",
+			setup: func() {
+				viper.Reset()
+				viper.Set("format", "table")
+				viper.Set("servers", []map[string]interface{}{{"url": "http://gpu1:7801", "weight": -1}})
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty config",
 			setup: func() {
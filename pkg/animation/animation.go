@@ -0,0 +1,160 @@
+package animation
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+)
+
+// DefaultDelay is the time each frame is shown unless set otherwise
+const DefaultDelay = 100 * time.Millisecond
+
+// Options configures assembling images into an animation
+type Options struct {
+	// Frame width and height in pixels. When both are 0, frames get the
+	// largest width and height of the images, and smaller images are padded
+	// without scaling. When one is 0 it follows the first image's aspect
+	// ratio.
+	Width  int
+	Height int
+	// How images are fitted to the frame size (default: fit)
+	Mode processor.ResizeMode
+	// Where images are aligned when cropped or padded (default: center)
+	Anchor processor.Anchor
+	// Color of the padding around fitted images (default: transparent)
+	Background color.Color
+	// Time each frame is shown (default: DefaultDelay)
+	Delay time.Duration
+	// Delays of the individual frames, overriding Delay; must be empty or
+	// have one entry per image
+	Delays []time.Duration
+	// Number of times the animation plays (0 = forever)
+	LoopCount int
+}
+
+// Animation is a sequence of frames of the same size
+type Animation struct {
+	Frames []*image.NRGBA
+	// Time each frame is shown, one entry per frame
+	Delays []time.Duration
+	// Number of times the animation plays (0 = forever)
+	LoopCount int
+}
+
+// New assembles images into an animation, giving every frame the same size
+func New(images []image.Image, opts Options) (*Animation, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no frames given")
+	}
+	if opts.Width < 0 || opts.Height < 0 {
+		return nil, fmt.Errorf("frame size cannot be negative")
+	}
+	if opts.LoopCount < 0 {
+		return nil, fmt.Errorf("loop count cannot be negative")
+	}
+	if len(opts.Delays) > 0 && len(opts.Delays) != len(images) {
+		return nil, fmt.Errorf("got %d delays for %d frames", len(opts.Delays), len(images))
+	}
+
+	resize := processor.ResizeOptions{
+		Width:      opts.Width,
+		Height:     opts.Height,
+		Mode:       opts.Mode,
+		Anchor:     opts.Anchor,
+		Background: opts.Background,
+	}
+	if resize.Width == 0 && resize.Height == 0 {
+		// Without a size, pad every image to the largest one
+		for _, img := range images {
+			size := img.Bounds().Size()
+			resize.Width, resize.Height = max(resize.Width, size.X), max(resize.Height, size.Y)
+		}
+		resize.Mode, resize.NoUpscale = processor.ResizeFit, true
+	} else if resize.Width == 0 || resize.Height == 0 {
+		// Every frame gets the size the first one is resized to
+		first, err := processor.ResizeStep{Options: resize}.Apply(images[0])
+		if err != nil {
+			return nil, fmt.Errorf("frame 1: %w", err)
+		}
+		resize.Width, resize.Height = first.Bounds().Dx(), first.Bounds().Dy()
+	}
+
+	a := &Animation{
+		Frames:    make([]*image.NRGBA, len(images)),
+		Delays:    make([]time.Duration, len(images)),
+		LoopCount: opts.LoopCount,
+	}
+	for i, img := range images {
+		frame, err := processor.ResizeStep{Options: resize}.Apply(img)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i+1, err)
+		}
+		a.Frames[i] = toNRGBA(frame)
+
+		a.Delays[i] = opts.Delay
+		if len(opts.Delays) > 0 {
+			a.Delays[i] = opts.Delays[i]
+		}
+		if a.Delays[i] <= 0 {
+			a.Delays[i] = DefaultDelay
+		}
+	}
+	return a, nil
+}
+
+// Bounds returns the bounds shared by all frames
+func (a *Animation) Bounds() image.Rectangle {
+	return a.Frames[0].Rect
+}
+
+// Strip lays the frames out as a sprite strip, left to right and then top
+// to bottom in rows of the given number of columns (0 = a single row)
+func (a *Animation) Strip(columns int) *image.NRGBA {
+	if columns <= 0 || columns > len(a.Frames) {
+		columns = len(a.Frames)
+	}
+	rows := (len(a.Frames) + columns - 1) / columns
+	size := a.Bounds().Size()
+
+	strip := image.NewNRGBA(image.Rect(0, 0, columns*size.X, rows*size.Y))
+	for i, frame := range a.Frames {
+		at := image.Pt(i%columns*size.X, i/columns*size.Y)
+		draw.Draw(strip, frame.Rect.Add(at), frame, frame.Rect.Min, draw.Src)
+	}
+	return strip
+}
+
+// toNRGBA returns img as an *image.NRGBA at the origin, converting it if
+// needed
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
+
+// Load reads the frames of an animation from image files, in order
+func Load(paths []string) ([]image.Image, error) {
+	images := make([]image.Image, len(paths))
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		images[i] = img
+	}
+	return images, nil
+}
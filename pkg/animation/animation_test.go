@@ -0,0 +1,134 @@
+package animation
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+)
+
+// solidFrame returns a width×height image of color c
+func solidFrame(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for p := 0; p < len(img.Pix); p += 4 {
+		img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+var (
+	red   = color.NRGBA{255, 0, 0, 255}
+	green = color.NRGBA{0, 255, 0, 255}
+	blue  = color.NRGBA{0, 0, 255, 255}
+)
+
+func TestNew(t *testing.T) {
+	images := []image.Image{solidFrame(40, 20, red), solidFrame(20, 30, green)}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		wantSize image.Point
+	}{
+		{name: "largest frame", wantSize: image.Pt(40, 30)},
+		{name: "fixed size", opts: Options{Width: 16, Height: 16}, wantSize: image.Pt(16, 16)},
+		{name: "width only", opts: Options{Width: 20}, wantSize: image.Pt(20, 10)},
+		{name: "fill", opts: Options{Width: 10, Height: 10, Mode: processor.ResizeFill}, wantSize: image.Pt(10, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(images, tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for i, frame := range a.Frames {
+				if frame.Rect != (image.Rectangle{Max: tt.wantSize}) {
+					t.Errorf("frame %d bounds = %v, want size %v", i, frame.Rect, tt.wantSize)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPadsWithoutScaling(t *testing.T) {
+	a, err := New([]image.Image{solidFrame(40, 20, red), solidFrame(20, 30, green)}, Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The first frame is centered vertically, the second horizontally
+	if got := a.Frames[0].NRGBAAt(20, 0); got.A != 0 {
+		t.Errorf("padding above frame 1 = %v, want transparent", got)
+	}
+	if got := a.Frames[0].NRGBAAt(20, 15); got != red {
+		t.Errorf("center of frame 1 = %v, want red", got)
+	}
+	if got := a.Frames[1].NRGBAAt(0, 15); got.A != 0 {
+		t.Errorf("padding left of frame 2 = %v, want transparent", got)
+	}
+	if got := a.Frames[1].NRGBAAt(10, 0); got != green {
+		t.Errorf("top of frame 2 = %v, want green", got)
+	}
+}
+
+func TestNewDelays(t *testing.T) {
+	images := []image.Image{solidFrame(4, 4, red), solidFrame(4, 4, green)}
+
+	a, err := New(images, Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if a.Delays[0] != DefaultDelay || a.Delays[1] != DefaultDelay {
+		t.Errorf("default delays = %v", a.Delays)
+	}
+
+	a, err = New(images, Options{Delay: 50 * time.Millisecond, Delays: []time.Duration{0, 300 * time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if a.Delays[0] != DefaultDelay || a.Delays[1] != 300*time.Millisecond {
+		t.Errorf("per-frame delays = %v", a.Delays)
+	}
+
+	if _, err := New(images, Options{Delays: []time.Duration{time.Second}}); err == nil {
+		t.Error("New() with too few delays succeeded, want error")
+	}
+	if _, err := New(nil, Options{}); err == nil {
+		t.Error("New() without frames succeeded, want error")
+	}
+	if _, err := New(images, Options{LoopCount: -1}); err == nil {
+		t.Error("New() with a negative loop count succeeded, want error")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	a, err := New([]image.Image{solidFrame(4, 3, red), solidFrame(4, 3, green), solidFrame(4, 3, blue)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		columns  int
+		wantSize image.Point
+		// Where the third, blue frame starts
+		wantBlue image.Point
+	}{
+		{columns: 0, wantSize: image.Pt(12, 3), wantBlue: image.Pt(8, 0)},
+		{columns: 2, wantSize: image.Pt(8, 6), wantBlue: image.Pt(0, 3)},
+		{columns: 1, wantSize: image.Pt(4, 9), wantBlue: image.Pt(0, 6)},
+		{columns: 5, wantSize: image.Pt(12, 3), wantBlue: image.Pt(8, 0)},
+	}
+
+	for _, tt := range tests {
+		strip := a.Strip(tt.columns)
+		if strip.Rect.Size() != tt.wantSize {
+			t.Errorf("Strip(%d) size = %v, want %v", tt.columns, strip.Rect.Size(), tt.wantSize)
+			continue
+		}
+		if got := strip.NRGBAAt(tt.wantBlue.X, tt.wantBlue.Y); got != blue {
+			t.Errorf("Strip(%d) at %v = %v, want blue", tt.columns, tt.wantBlue, got)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package animation
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"time"
+)
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// EncodeAPNG writes the animation as an animated PNG with full 8-bit RGBA
+// frames. Viewers without APNG support show the first frame.
+func (a *Animation) EncodeAPNG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	size := a.Bounds().Size()
+
+	bw.Write(pngSignature)
+
+	// 8-bit RGBA, deflate, adaptive filtering, no interlacing
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(size.X))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(size.Y))
+	ihdr[8], ihdr[9] = 8, 6
+	writeChunk(bw, "IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(a.Frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(a.LoopCount))
+	writeChunk(bw, "acTL", actl)
+
+	// fcTL and fdAT chunks share one sequence
+	var seq uint32
+	for i, frame := range a.Frames {
+		num, den := apngDelay(a.Delays[i])
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(size.X))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(size.Y))
+		// Frames cover the whole canvas at offset 0,0 and replace it
+		// (dispose none, blend source)
+		binary.BigEndian.PutUint16(fctl[20:], num)
+		binary.BigEndian.PutUint16(fctl[22:], den)
+		writeChunk(bw, "fcTL", fctl)
+		seq++
+
+		data, err := compressFrame(frame)
+		if err != nil {
+			return fmt.Errorf("failed to encode frame %d: %w", i+1, err)
+		}
+		if i == 0 {
+			writeChunk(bw, "IDAT", data)
+			continue
+		}
+		fdat := make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, seq)
+		writeChunk(bw, "fdAT", append(fdat, data...))
+		seq++
+	}
+
+	writeChunk(bw, "IEND", nil)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write APNG: %w", err)
+	}
+	return nil
+}
+
+// apngDelay converts a delay to the fraction of a second APNG stores
+func apngDelay(d time.Duration) (uint16, uint16) {
+	ms := d.Milliseconds()
+	if ms <= 65535 {
+		return uint16(ms), 1000
+	}
+	return uint16(min(d/(10*time.Millisecond), 65535)), 100
+}
+
+// writeChunk writes a PNG chunk: its length, type, data and CRC
+func writeChunk(w io.Writer, name string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], name)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	w.Write(header[:])
+	w.Write(data)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// compressFrame returns the zlib-compressed, filtered scanlines of an RGBA
+// frame. Each row uses the filter with the smallest sum of absolute
+// differences, the heuristic libpng and image/png use.
+func compressFrame(frame *image.NRGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := frame.Rect.Dx() * 4
+	prior := make([]byte, stride)
+	filtered := make([][]byte, 5)
+	for f := range filtered {
+		filtered[f] = make([]byte, 1+stride)
+		filtered[f][0] = byte(f)
+	}
+
+	for y := 0; y < frame.Rect.Dy(); y++ {
+		row := frame.Pix[y*frame.Stride : y*frame.Stride+stride]
+		best, bestSum := 0, -1
+		for f := range filtered {
+			out := filtered[f][1:]
+			sum := 0
+			for i := range row {
+				var left, upLeft byte
+				if i >= 4 {
+					left, upLeft = row[i-4], prior[i-4]
+				}
+				out[i] = row[i] - predict(f, left, prior[i], upLeft)
+				sum += abs8(out[i])
+			}
+			if bestSum < 0 || sum < bestSum {
+				best, bestSum = f, sum
+			}
+		}
+		if _, err := zw.Write(filtered[best]); err != nil {
+			return nil, err
+		}
+		prior = row
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// predict returns the value PNG filter f predicts from the left, up and
+// upper-left bytes
+func predict(f int, left, up, upLeft byte) byte {
+	switch f {
+	case 1: // Sub
+		return left
+	case 2: // Up
+		return up
+	case 3: // Average
+		return byte((int(left) + int(up)) / 2)
+	case 4: // Paeth
+		p := int(left) + int(up) - int(upLeft)
+		pa, pb, pc := absInt(p-int(left)), absInt(p-int(up)), absInt(p-int(upLeft))
+		if pa <= pb && pa <= pc {
+			return left
+		}
+		if pb <= pc {
+			return up
+		}
+		return upLeft
+	default: // None
+		return 0
+	}
+}
+
+// abs8 returns the magnitude of a filtered byte read as a signed value
+func abs8(b byte) int {
+	if b < 128 {
+		return int(b)
+	}
+	return 256 - int(b)
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,122 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// readChunks splits a PNG file into its chunks, checking their CRCs
+func readChunks(t *testing.T, data []byte) []pngChunk {
+	t.Helper()
+	if !bytes.HasPrefix(data, pngSignature) {
+		t.Fatal("missing PNG signature")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk
+	for len(data) > 0 {
+		n := binary.BigEndian.Uint32(data)
+		c := pngChunk{name: string(data[4:8]), data: data[8 : 8+n]}
+		if crc := binary.BigEndian.Uint32(data[8+n:]); crc != crc32.ChecksumIEEE(data[4:8+n]) {
+			t.Errorf("%s chunk has a bad CRC", c.name)
+		}
+		chunks = append(chunks, c)
+		data = data[12+n:]
+	}
+	return chunks
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	second := solidFrame(6, 4, green)
+	second.SetNRGBA(1, 1, color.NRGBA{255, 255, 0, 128})
+	a, err := New([]image.Image{solidFrame(6, 4, red), second, solidFrame(6, 4, blue)}, Options{
+		Delays:    []time.Duration{40 * time.Millisecond, 80 * time.Millisecond, 120 * time.Second},
+		LoopCount: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.EncodeAPNG(&buf); err != nil {
+		t.Fatalf("EncodeAPNG() error = %v", err)
+	}
+
+	// Viewers without APNG support show the first frame
+	first, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(first.At(2, 2)); got != red {
+		t.Errorf("default image = %v, want red", got)
+	}
+
+	chunks := readChunks(t, buf.Bytes())
+	var names []string
+	var ihdr []byte
+	var frames [][]byte
+	var delays [][2]uint16
+	seq := uint32(0)
+	for _, c := range chunks {
+		names = append(names, c.name)
+		switch c.name {
+		case "IHDR":
+			ihdr = c.data
+		case "acTL":
+			if n, plays := binary.BigEndian.Uint32(c.data), binary.BigEndian.Uint32(c.data[4:]); n != 3 || plays != 2 {
+				t.Errorf("acTL = %d frames, %d plays; want 3 frames, 2 plays", n, plays)
+			}
+		case "fcTL", "fdAT":
+			if got := binary.BigEndian.Uint32(c.data); got != seq {
+				t.Errorf("%s sequence number = %d, want %d", c.name, got, seq)
+			}
+			seq++
+			if c.name == "fcTL" {
+				delays = append(delays, [2]uint16{binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])})
+			} else {
+				frames = append(frames, c.data[4:])
+			}
+		case "IDAT":
+			frames = append(frames, c.data)
+		}
+	}
+	want := "IHDR acTL fcTL IDAT fcTL fdAT fcTL fdAT IEND"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("chunks = %s, want %s", got, want)
+	}
+	if delays[0] != [2]uint16{40, 1000} || delays[2] != [2]uint16{12000, 100} {
+		t.Errorf("delays = %v", delays)
+	}
+
+	// Every frame decodes as a PNG of its own
+	for i, data := range frames {
+		var frame bytes.Buffer
+		frame.Write(pngSignature)
+		writeChunk(&frame, "IHDR", ihdr)
+		writeChunk(&frame, "IDAT", data)
+		writeChunk(&frame, "IEND", nil)
+		img, err := png.Decode(&frame)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i+1, err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 6; x++ {
+				if got, want := color.NRGBAModel.Convert(img.At(x, y)), a.Frames[i].NRGBAAt(x, y); got != want {
+					t.Fatalf("frame %d pixel (%d,%d) = %v, want %v", i+1, x, y, got, want)
+				}
+			}
+		}
+	}
+}
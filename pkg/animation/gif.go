@@ -0,0 +1,266 @@
+package animation
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"sort"
+	"time"
+)
+
+// GIFOptions configures GIF encoding
+type GIFOptions struct {
+	// Size of the palette shared by all frames, including the transparent
+	// color (2-256, default: 256)
+	Colors int
+	// NoDither maps every pixel to its nearest palette color instead of
+	// spreading the error with Floyd-Steinberg dithering
+	NoDither bool
+}
+
+// EncodeGIF writes the animation as an animated GIF. The frames share one
+// palette chosen by median cut over all of them. GIF has no partial
+// transparency, so pixels less than half opaque become transparent and the
+// others opaque.
+func (a *Animation) EncodeGIF(w io.Writer, opts GIFOptions) error {
+	if opts.Colors == 0 {
+		opts.Colors = 256
+	}
+	if opts.Colors < 2 || opts.Colors > 256 {
+		return fmt.Errorf("GIF colors must be between 2 and 256, got %d", opts.Colors)
+	}
+
+	// Make every pixel fully opaque or fully transparent
+	frames := make([]*image.NRGBA, len(a.Frames))
+	transparent := false
+	for i, frame := range a.Frames {
+		frames[i] = image.NewNRGBA(frame.Rect)
+		for p := 0; p < len(frame.Pix); p += 4 {
+			if frame.Pix[p+3] >= 128 {
+				copy(frames[i].Pix[p:p+3], frame.Pix[p:p+3])
+				frames[i].Pix[p+3] = 255
+			} else {
+				transparent = true
+			}
+		}
+	}
+
+	var pal color.Palette
+	if transparent {
+		pal = append(color.Palette{color.NRGBA{}}, medianCut(frames, opts.Colors-1)...)
+	} else {
+		pal = medianCut(frames, opts.Colors)
+	}
+
+	// Transparent frames must clear the previous frame before they are drawn
+	disposal := byte(gif.DisposalNone)
+	if transparent {
+		disposal = gif.DisposalBackground
+	}
+
+	g := &gif.GIF{
+		LoopCount: gifLoopCount(a.LoopCount),
+		Config:    image.Config{ColorModel: pal, Width: a.Bounds().Dx(), Height: a.Bounds().Dy()},
+	}
+	for i, frame := range frames {
+		g.Image = append(g.Image, quantizeFrame(frame, pal, transparent, !opts.NoDither))
+		g.Delay = append(g.Delay, gifDelay(a.Delays[i]))
+		g.Disposal = append(g.Disposal, disposal)
+	}
+
+	if err := gif.EncodeAll(w, g); err != nil {
+		return fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return nil
+}
+
+// quantizeFrame maps the pixels of a frame to a palette that starts with the
+// transparent color when transparent is set. Opaque pixels are matched
+// against the opaque colors only, with Floyd-Steinberg dithering if dither is
+// set; transparent pixels take the transparent color and neither receive nor
+// pass on dithering error, so dark edges next to transparent areas never
+// dither into holes.
+func quantizeFrame(frame *image.NRGBA, pal color.Palette, transparent, dither bool) *image.Paletted {
+	first := 0
+	if transparent {
+		first = 1
+	}
+	colors := make([][3]int32, len(pal)-first)
+	for i, c := range pal[first:] {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		colors[i] = [3]int32{int32(n.R), int32(n.G), int32(n.B)}
+	}
+
+	paletted := image.NewPaletted(frame.Rect, pal)
+	w, h := frame.Rect.Dx(), frame.Rect.Dy()
+	// Errors carried into the current and next row, in sixteenths, with a
+	// pixel of margin at either end
+	cur, next := make([][3]int32, w+2), make([][3]int32, w+2)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := y*frame.Stride + x*4
+			if frame.Pix[p+3] == 0 {
+				continue // Index 0, the transparent color
+			}
+
+			var want [3]int32
+			for c := range want {
+				want[c] = int32(frame.Pix[p+c])
+				if dither {
+					want[c] = min(255, max(0, want[c]+cur[x+1][c]/16))
+				}
+			}
+			best, bestDist := 0, int32(-1)
+			for i, rgb := range colors {
+				dr, dg, db := want[0]-rgb[0], want[1]-rgb[1], want[2]-rgb[2]
+				if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+					best, bestDist = i, d
+				}
+			}
+			paletted.Pix[y*paletted.Stride+x] = uint8(first + best)
+
+			if dither {
+				for c := range want {
+					e := want[c] - colors[best][c]
+					cur[x+2][c] += 7 * e
+					next[x][c] += 3 * e
+					next[x+1][c] += 5 * e
+					next[x+2][c] += e
+				}
+			}
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return paletted
+}
+
+// gifLoopCount converts a number of plays (0 = forever) to the GIF loop
+// count, which counts the repeats after the first play (-1 = none)
+func gifLoopCount(plays int) int {
+	switch plays {
+	case 0:
+		return 0
+	case 1:
+		return -1
+	default:
+		return plays - 1
+	}
+}
+
+// gifDelay converts a delay to GIF's hundredths of a second, at least one
+func gifDelay(d time.Duration) int {
+	return max(1, int((d+5*time.Millisecond)/(10*time.Millisecond)))
+}
+
+// colorBucket is a 5-bit color of the histogram medianCut reduces, with
+// the number of pixels in it and the sum of their full colors
+type colorBucket struct {
+	rgb   [3]uint8
+	count int
+	sum   [3]int
+}
+
+// medianCut chooses up to n colors representing the opaque pixels of the
+// frames. Colors are counted at 5 bits per channel, then the box of colors
+// with the widest channel range is repeatedly split at its pixel median;
+// each box contributes the average color of its pixels.
+func medianCut(frames []*image.NRGBA, n int) color.Palette {
+	histogram := make(map[[3]uint8]*colorBucket)
+	for _, frame := range frames {
+		for p := 0; p < len(frame.Pix); p += 4 {
+			if frame.Pix[p+3] == 0 {
+				continue
+			}
+			rgb := [3]uint8{frame.Pix[p] >> 3, frame.Pix[p+1] >> 3, frame.Pix[p+2] >> 3}
+			b := histogram[rgb]
+			if b == nil {
+				b = &colorBucket{rgb: rgb}
+				histogram[rgb] = b
+			}
+			b.count++
+			for c := range b.sum {
+				b.sum[c] += int(frame.Pix[p+c])
+			}
+		}
+	}
+	if len(histogram) == 0 {
+		return color.Palette{color.NRGBA{A: 255}}
+	}
+
+	buckets := make([]colorBucket, 0, len(histogram))
+	for _, b := range histogram {
+		buckets = append(buckets, *b)
+	}
+	// Sort for a deterministic palette, since map order is random
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i].rgb, buckets[j].rgb
+		return a[0] < b[0] || a[0] == b[0] && (a[1] < b[1] || a[1] == b[1] && a[2] < b[2])
+	})
+
+	boxes := [][]colorBucket{buckets}
+	for len(boxes) < n {
+		// Split the box with the widest channel range
+		widest, channel, spread := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, s := widestChannel(box); s > spread {
+				widest, channel, spread = i, c, s
+			}
+		}
+		if widest < 0 {
+			break // Every box holds a single color
+		}
+
+		box := boxes[widest]
+		sort.SliceStable(box, func(i, j int) bool { return box[i].rgb[channel] < box[j].rgb[channel] })
+		total := 0
+		for _, b := range box {
+			total += b.count
+		}
+		split, seen := 1, box[0].count
+		for split < len(box)-1 && seen+box[split].count <= total/2 {
+			seen += box[split].count
+			split++
+		}
+		boxes[widest] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	pal := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		var sum [3]int
+		total := 0
+		for _, b := range box {
+			for c := range sum {
+				sum[c] += b.sum[c]
+			}
+			total += b.count
+		}
+		pal[i] = color.NRGBA{uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), 255}
+	}
+	return pal
+}
+
+// widestChannel returns the channel with the largest range in a box of
+// colors, and that range
+func widestChannel(box []colorBucket) (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	var hi [3]uint8
+	for _, b := range box {
+		for c := range lo {
+			lo[c], hi[c] = min(lo[c], b.rgb[c]), max(hi[c], b.rgb[c])
+		}
+	}
+	channel := 0
+	for c := 1; c < 3; c++ {
+		if hi[c]-lo[c] > hi[channel]-lo[channel] {
+			channel = c
+		}
+	}
+	return channel, int(hi[channel] - lo[channel])
+}
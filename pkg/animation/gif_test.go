@@ -0,0 +1,181 @@
+package animation
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+func TestEncodeGIF(t *testing.T) {
+	// A red frame with a transparent corner and an opaque blue frame
+	first := solidFrame(8, 8, red)
+	first.SetNRGBA(0, 0, color.NRGBA{})
+	a, err := New([]image.Image{first, solidFrame(8, 8, blue)}, Options{
+		Delays:    []time.Duration{50 * time.Millisecond, 200 * time.Millisecond},
+		LoopCount: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.EncodeGIF(&buf, GIFOptions{}); err != nil {
+		t.Fatalf("EncodeGIF() error = %v", err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+
+	if len(g.Image) != 2 {
+		t.Fatalf("got %d frames, want 2", len(g.Image))
+	}
+	if g.Delay[0] != 5 || g.Delay[1] != 20 {
+		t.Errorf("delays = %v, want [5 20]", g.Delay)
+	}
+	if g.LoopCount != 2 {
+		t.Errorf("loop count = %d, want 2 (three plays)", g.LoopCount)
+	}
+	if g.Disposal[0] != gif.DisposalBackground {
+		t.Errorf("disposal = %d, want background for transparent frames", g.Disposal[0])
+	}
+
+	if _, _, _, alpha := g.Image[0].At(0, 0).RGBA(); alpha != 0 {
+		t.Errorf("transparent corner has alpha %d", alpha)
+	}
+	if got := color.NRGBAModel.Convert(g.Image[0].At(4, 4)); got != red {
+		t.Errorf("frame 1 = %v, want red", got)
+	}
+	if got := color.NRGBAModel.Convert(g.Image[1].At(4, 4)); got != blue {
+		t.Errorf("frame 2 = %v, want blue", got)
+	}
+}
+
+func TestEncodeGIFColors(t *testing.T) {
+	// A gradient needs more colors than the palette has
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 4))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 4; y++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 4), uint8(255 - x*4), 128, 255})
+		}
+	}
+	a, err := New([]image.Image{img}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []GIFOptions{{Colors: 8}, {Colors: 8, NoDither: true}} {
+		var buf bytes.Buffer
+		if err := a.EncodeGIF(&buf, opts); err != nil {
+			t.Fatalf("EncodeGIF(%+v) error = %v", opts, err)
+		}
+		g, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatalf("invalid GIF: %v", err)
+		}
+		if n := len(g.Image[0].Palette); n > 8 {
+			t.Errorf("EncodeGIF(%+v) palette has %d colors, want at most 8", opts, n)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := a.EncodeGIF(&buf, GIFOptions{Colors: 300}); err == nil {
+		t.Error("EncodeGIF() with 300 colors succeeded, want error")
+	}
+}
+
+func TestEncodeGIFKeepsDarkPixelsOpaque(t *testing.T) {
+	// With two colors the palette holds the transparent color and one light
+	// gray, which is further from black than the transparent color is
+	img := solidFrame(10, 10, color.NRGBA{255, 255, 255, 255})
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	for x := 0; x < 10; x++ {
+		img.SetNRGBA(x, 5, color.NRGBA{0, 0, 0, 255})
+	}
+	a, err := New([]image.Image{img}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []GIFOptions{{Colors: 2}, {Colors: 2, NoDither: true}} {
+		var buf bytes.Buffer
+		if err := a.EncodeGIF(&buf, opts); err != nil {
+			t.Fatalf("EncodeGIF(%+v) error = %v", opts, err)
+		}
+		g, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatalf("invalid GIF: %v", err)
+		}
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				_, _, _, alpha := g.Image[0].At(x, y).RGBA()
+				if want := x != 0 || y != 0; want != (alpha != 0) {
+					t.Fatalf("EncodeGIF(%+v) pixel (%d,%d) has alpha %d, want opaque %v", opts, x, y, alpha, want)
+				}
+			}
+		}
+	}
+}
+
+func TestQuantizeFrameSkipsTransparentPixels(t *testing.T) {
+	pal := color.Palette{color.NRGBA{}, color.NRGBA{0, 0, 0, 255}, color.NRGBA{255, 255, 255, 255}}
+
+	// A gray row that dithers to black and white, a row of holes, then a
+	// black row: no error may reach the black row through the holes
+	frame := image.NewNRGBA(image.Rect(0, 0, 8, 3))
+	for x := 0; x < 8; x++ {
+		frame.SetNRGBA(x, 0, color.NRGBA{100, 100, 100, 255})
+		frame.SetNRGBA(x, 2, color.NRGBA{0, 0, 0, 255})
+	}
+
+	for _, dither := range []bool{true, false} {
+		paletted := quantizeFrame(frame, pal, true, dither)
+		for x := 0; x < 8; x++ {
+			if i := paletted.ColorIndexAt(x, 0); i == 0 {
+				t.Errorf("dither=%v: gray pixel %d got the transparent color", dither, x)
+			}
+			if i := paletted.ColorIndexAt(x, 1); i != 0 {
+				t.Errorf("dither=%v: hole %d got color %d, want transparent", dither, x, i)
+			}
+			if i := paletted.ColorIndexAt(x, 2); i != 1 {
+				t.Errorf("dither=%v: black pixel %d got color %d, want black", dither, x, i)
+			}
+		}
+	}
+
+	// Dithering mixes black and white for the gray row
+	row := quantizeFrame(frame, pal, true, true).Pix[:8]
+	if !bytes.Contains(row, []byte{1}) || !bytes.Contains(row, []byte{2}) {
+		t.Errorf("dithered gray row = %v, want black and white", row)
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	frames := []*image.NRGBA{solidFrame(4, 4, red), solidFrame(2, 2, blue)}
+
+	pal := medianCut(frames, 16)
+	if len(pal) != 2 {
+		t.Fatalf("palette = %v, want two colors", pal)
+	}
+	for _, want := range []color.NRGBA{red, blue} {
+		if got := pal[pal.Index(want)]; got != want {
+			t.Errorf("palette color for %v = %v", want, got)
+		}
+	}
+
+	if pal := medianCut(frames, 1); len(pal) != 1 {
+		t.Errorf("medianCut(1) = %v, want one color", pal)
+	}
+}
+
+func TestGIFLoopCount(t *testing.T) {
+	tests := map[int]int{0: 0, 1: -1, 2: 1, 5: 4}
+	for plays, want := range tests {
+		if got := gifLoopCount(plays); got != want {
+			t.Errorf("gifLoopCount(%d) = %d, want %d", plays, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
+)
+
+// Sprite is an image to pack into an atlas
+type Sprite struct {
+	// Name identifies the frame in the metadata, usually its file name
+	Name  string
+	Image image.Image
+}
+
+// Options configures packing sprites into an atlas
+type Options struct {
+	// Largest atlas width and height in pixels (default: 4096)
+	MaxWidth  int
+	MaxHeight int
+	// Transparent pixels between sprites
+	Padding int
+	// Pixels by which the edges of every sprite are repeated outwards, so
+	// that texture filtering never samples neighbouring sprites
+	Extrude int
+	// Trim removes transparent borders from sprites; the metadata records
+	// where the trimmed frame sits in the original sprite
+	Trim bool
+	// Pixels with alpha at or below this value count as transparent when
+	// trimming (default: 0)
+	TrimThreshold uint8
+	// PowerOfTwo rounds the atlas size up to powers of two
+	PowerOfTwo bool
+	// Square makes the atlas as high as it is wide
+	Square bool
+}
+
+// Frame is the position of one sprite in an atlas
+type Frame struct {
+	Name string
+	// Rect is the sprite's area of the atlas image
+	Rect image.Rectangle
+	// Trimmed reports whether transparent borders were removed
+	Trimmed bool
+	// Source is the area of the original sprite that Rect holds; it is the
+	// whole sprite unless trimmed
+	Source image.Rectangle
+	// SourceSize is the size of the original sprite
+	SourceSize image.Point
+}
+
+// Atlas is a sheet of packed sprites and their frames, in the order the
+// sprites were given
+type Atlas struct {
+	Image  *image.NRGBA
+	Frames []Frame
+}
+
+// Load reads sprites from image files, naming each by its path in names
+func Load(paths, names []string) ([]Sprite, error) {
+	if len(paths) != len(names) {
+		return nil, fmt.Errorf("got %d names for %d images", len(names), len(paths))
+	}
+	sprites := make([]Sprite, len(paths))
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		sprites[i] = Sprite{Name: names[i], Image: img}
+	}
+	return sprites, nil
+}
+
+// Pack packs sprites into a single atlas image as small as the options
+// allow, using the MaxRects bin packing algorithm
+func Pack(sprites []Sprite, opts Options) (*Atlas, error) {
+	if len(sprites) == 0 {
+		return nil, fmt.Errorf("no sprites to pack")
+	}
+	if opts.Padding < 0 || opts.Extrude < 0 {
+		return nil, fmt.Errorf("padding and extrusion cannot be negative")
+	}
+	if opts.MaxWidth == 0 {
+		opts.MaxWidth = 4096
+	}
+	if opts.MaxHeight == 0 {
+		opts.MaxHeight = 4096
+	}
+	if opts.MaxWidth < 0 || opts.MaxHeight < 0 {
+		return nil, fmt.Errorf("maximum atlas size cannot be negative")
+	}
+
+	frames := make([]Frame, len(sprites))
+	cells := make([]*image.NRGBA, len(sprites))
+	sizes := make([]image.Point, len(sprites))
+	seen := make(map[string]bool, len(sprites))
+	for i, sprite := range sprites {
+		if seen[sprite.Name] {
+			return nil, fmt.Errorf("duplicate sprite name '%s'", sprite.Name)
+		}
+		seen[sprite.Name] = true
+
+		cell, frame, err := prepareSprite(sprite, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sprite.Name, err)
+		}
+		cells[i], frames[i] = cell, frame
+		sizes[i] = cell.Rect.Size().Add(image.Pt(opts.Padding, opts.Padding))
+	}
+
+	positions, size, err := layout(sizes, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := image.NewNRGBA(image.Rectangle{Max: size})
+	for i, cell := range cells {
+		at := positions[i]
+		draw.Draw(sheet, cell.Rect.Sub(cell.Rect.Min).Add(at), cell, cell.Rect.Min, draw.Src)
+		frames[i].Rect = frames[i].Rect.Add(at.Add(image.Pt(opts.Extrude, opts.Extrude)))
+	}
+	return &Atlas{Image: sheet, Frames: frames}, nil
+}
+
+// prepareSprite trims and extrudes a sprite, returning the pixels to place
+// in the atlas and its frame with Rect relative to them
+func prepareSprite(sprite Sprite, opts Options) (*image.NRGBA, Frame, error) {
+	bounds := sprite.Image.Bounds()
+	if bounds.Empty() {
+		return nil, Frame{}, fmt.Errorf("image is empty")
+	}
+	img := image.NewNRGBA(image.Rectangle{Max: bounds.Size()})
+	draw.Draw(img, img.Rect, sprite.Image, bounds.Min, draw.Src)
+
+	frame := Frame{Name: sprite.Name, Source: img.Rect, SourceSize: img.Rect.Size()}
+	if opts.Trim {
+		frame.Source = trimBounds(img, opts.TrimThreshold)
+		frame.Trimmed = !frame.Source.Eq(img.Rect)
+		img = img.SubImage(frame.Source).(*image.NRGBA)
+	}
+	frame.Rect = image.Rectangle{Max: frame.Source.Size()}
+
+	if opts.Extrude > 0 {
+		extruded, err := processor.PadStep{Options: processor.PadOptions{
+			Margin: opts.Extrude,
+			Fill:   processor.PadFillEdge,
+		}}.Apply(img)
+		if err != nil {
+			return nil, Frame{}, err
+		}
+		img = extruded.(*image.NRGBA)
+	}
+	return img, frame, nil
+}
+
+// trimBounds returns the smallest rectangle holding every pixel of img more
+// opaque than threshold. Fully transparent images keep their top-left pixel,
+// so that every frame has a size.
+func trimBounds(img *image.NRGBA, threshold uint8) image.Rectangle {
+	var bounds image.Rectangle
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, y):]
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if row[(x-img.Rect.Min.X)*4+3] > threshold {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if bounds.Empty() {
+		return image.Rectangle{Min: img.Rect.Min, Max: img.Rect.Min.Add(image.Pt(1, 1))}
+	}
+	return bounds
+}
+
+// maxLayoutWidths bounds the number of bin widths layout tries
+const maxLayoutWidths = 64
+
+// layout places cells of the given sizes, which include the padding after
+// each cell, returning their positions and the atlas size. It packs into
+// bins of increasing width and keeps the layout with the smallest atlas.
+func layout(sizes []image.Point, opts Options) ([]image.Point, image.Point, error) {
+	// Place large cells first
+	order := make([]int, len(sizes))
+	area, widest, tallest := 0, 0, 0
+	for i, s := range sizes {
+		order[i] = i
+		area += s.X * s.Y
+		widest, tallest = max(widest, s.X), max(tallest, s.Y)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := sizes[order[a]], sizes[order[b]]
+		if max(sa.X, sa.Y) != max(sb.X, sb.Y) {
+			return max(sa.X, sa.Y) > max(sb.X, sb.Y)
+		}
+		return sa.X*sa.Y > sb.X*sb.Y
+	})
+
+	// The padding after the last row and column is not part of the atlas
+	binWidth, binHeight := opts.MaxWidth+opts.Padding, opts.MaxHeight+opts.Padding
+	if widest > binWidth || tallest > binHeight {
+		return nil, image.Point{}, fmt.Errorf("a sprite of %dx%d does not fit in a %dx%d atlas",
+			widest-opts.Padding, tallest-opts.Padding, opts.MaxWidth, opts.MaxHeight)
+	}
+
+	var best []image.Point
+	var bestSize image.Point
+	start := max(widest, int(math.Sqrt(float64(area))))
+	step := max(1, start/16)
+	for n, width := 0, min(start, binWidth); n < maxLayoutWidths; n, width = n+1, min(width+step, binWidth) {
+		positions, used, ok := packBin(sizes, order, width, binHeight)
+		if ok {
+			size, fits := atlasSize(used.Sub(image.Pt(opts.Padding, opts.Padding)), opts)
+			if fits && (best == nil || betterSize(size, bestSize)) {
+				best, bestSize = positions, size
+			}
+			// Wider bins cannot lower a single row
+			if used.Y == tallest {
+				break
+			}
+		}
+		if width == binWidth {
+			break
+		}
+	}
+	if best == nil {
+		return nil, image.Point{}, fmt.Errorf("sprites do not fit in a %dx%d atlas", opts.MaxWidth, opts.MaxHeight)
+	}
+	return best, bestSize, nil
+}
+
+// packBin packs cells in order into a width×height bin, returning their
+// positions and the size of the area used
+func packBin(sizes []image.Point, order []int, width, height int) ([]image.Point, image.Point, bool) {
+	bin := newMaxRects(width, height)
+	positions := make([]image.Point, len(sizes))
+	var used image.Point
+	for _, i := range order {
+		r, ok := bin.insert(sizes[i].X, sizes[i].Y)
+		if !ok {
+			return nil, image.Point{}, false
+		}
+		positions[i] = r.Min
+		used = image.Pt(max(used.X, r.Max.X), max(used.Y, r.Max.Y))
+	}
+	return positions, used, true
+}
+
+// atlasSize applies the power of two and square options to the area used by
+// the sprites, reporting whether the result is within the maximum size
+func atlasSize(used image.Point, opts Options) (image.Point, bool) {
+	size := used
+	if opts.PowerOfTwo {
+		size = image.Pt(nextPowerOfTwo(size.X), nextPowerOfTwo(size.Y))
+	}
+	if opts.Square {
+		size.X = max(size.X, size.Y)
+		size.Y = size.X
+	}
+	return size, size.X <= opts.MaxWidth && size.Y <= opts.MaxHeight
+}
+
+// betterSize prefers the smaller area, then the squarer atlas
+func betterSize(a, b image.Point) bool {
+	if a.X*a.Y != b.X*b.Y {
+		return a.X*a.Y < b.X*b.Y
+	}
+	return max(a.X, a.Y) < max(b.X, b.Y)
+}
+
+// nextPowerOfTwo returns the smallest power of two of at least n
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
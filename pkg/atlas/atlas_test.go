@@ -0,0 +1,158 @@
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// solidSprite returns a width×height sprite of color c with a transparent
+// border of the given size
+func solidSprite(name string, width, height, border int, c color.NRGBA) Sprite {
+	img := image.NewNRGBA(image.Rect(0, 0, width+2*border, height+2*border))
+	for y := border; y < border+height; y++ {
+		for x := border; x < border+width; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return Sprite{Name: name, Image: img}
+}
+
+func TestPack(t *testing.T) {
+	var sprites []Sprite
+	for i, s := range []image.Point{{64, 64}, {32, 48}, {100, 20}, {16, 16}, {48, 48}, {30, 70}} {
+		c := color.NRGBA{uint8(40 * i), 200, uint8(255 - 40*i), 255}
+		sprites = append(sprites, solidSprite(fmt.Sprintf("sprite_%d.png", i), s.X, s.Y, 0, c))
+	}
+
+	for _, opts := range []Options{{}, {Padding: 2}, {Padding: 1, Extrude: 2}, {PowerOfTwo: true}, {Square: true}} {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			a, err := Pack(sprites, opts)
+			if err != nil {
+				t.Fatalf("Pack() error = %v", err)
+			}
+			if len(a.Frames) != len(sprites) {
+				t.Fatalf("got %d frames, want %d", len(a.Frames), len(sprites))
+			}
+
+			for i, f := range a.Frames {
+				if f.Name != sprites[i].Name {
+					t.Errorf("frame %d is %s, want %s", i, f.Name, sprites[i].Name)
+				}
+				if f.Rect.Size() != sprites[i].Image.Bounds().Size() {
+					t.Errorf("%s is %v, want %v", f.Name, f.Rect.Size(), sprites[i].Image.Bounds().Size())
+				}
+				if !f.Rect.In(a.Image.Rect) {
+					t.Errorf("%s at %v is outside the atlas %v", f.Name, f.Rect, a.Image.Rect)
+				}
+				// Frames keep the padding and extrusion apart
+				gap := opts.Padding + 2*opts.Extrude
+				for _, other := range a.Frames[:i] {
+					if f.Rect.Inset(-gap).Overlaps(other.Rect) && f.Rect.Overlaps(other.Rect.Inset(-gap)) {
+						t.Errorf("%s at %v is within %dpx of %s at %v", f.Name, f.Rect, gap, other.Name, other.Rect)
+					}
+				}
+				if got := a.Image.NRGBAAt(f.Rect.Min.X, f.Rect.Min.Y); got != sprites[i].Image.At(0, 0) {
+					t.Errorf("%s corner = %v, want %v", f.Name, got, sprites[i].Image.At(0, 0))
+				}
+			}
+
+			size := a.Image.Rect.Size()
+			if opts.PowerOfTwo && (size.X&(size.X-1) != 0 || size.Y&(size.Y-1) != 0) {
+				t.Errorf("atlas size %v is not a power of two", size)
+			}
+			if opts.Square && size.X != size.Y {
+				t.Errorf("atlas size %v is not square", size)
+			}
+		})
+	}
+}
+
+func TestPackTrim(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	a, err := Pack([]Sprite{
+		solidSprite("trimmed", 10, 6, 3, red),
+		solidSprite("empty", 0, 0, 4, red),
+	}, Options{Trim: true})
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+
+	f := a.Frames[0]
+	if !f.Trimmed || f.Rect.Size() != image.Pt(10, 6) || f.Source != image.Rect(3, 3, 13, 9) || f.SourceSize != image.Pt(16, 12) {
+		t.Errorf("trimmed frame = %+v", f)
+	}
+	// Fully transparent sprites keep a single pixel
+	if f := a.Frames[1]; f.Rect.Size() != image.Pt(1, 1) || f.SourceSize != image.Pt(8, 8) {
+		t.Errorf("empty frame = %+v", f)
+	}
+}
+
+func TestPackExtrude(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	a, err := Pack([]Sprite{solidSprite("red", 4, 4, 0, red)}, Options{Extrude: 2})
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if a.Image.Rect.Size() != image.Pt(8, 8) {
+		t.Fatalf("atlas size = %v, want 8x8", a.Image.Rect.Size())
+	}
+	if a.Frames[0].Rect != image.Rect(2, 2, 6, 6) {
+		t.Errorf("frame = %v, want (2,2)-(6,6)", a.Frames[0].Rect)
+	}
+	// The extruded border repeats the sprite's edge pixels
+	if got := a.Image.NRGBAAt(0, 0); got != red {
+		t.Errorf("extruded corner = %v, want red", got)
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	tests := []struct {
+		name    string
+		sprites []Sprite
+		opts    Options
+	}{
+		{name: "no sprites"},
+		{name: "duplicate names", sprites: []Sprite{solidSprite("a", 4, 4, 0, red), solidSprite("a", 4, 4, 0, red)}},
+		{name: "sprite too large", sprites: []Sprite{solidSprite("a", 40, 4, 0, red)}, opts: Options{MaxWidth: 32}},
+		{name: "sprites do not fit", sprites: []Sprite{solidSprite("a", 32, 32, 0, red), solidSprite("b", 32, 32, 0, red)}, opts: Options{MaxWidth: 32, MaxHeight: 32}},
+		{name: "negative padding", sprites: []Sprite{solidSprite("a", 4, 4, 0, red)}, opts: Options{Padding: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Pack(tt.sprites, tt.opts); err == nil {
+				t.Error("Pack() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sprite.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, solidSprite("", 3, 2, 0, color.NRGBA{A: 255}).Image); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	sprites, err := Load([]string{path}, []string{"sprite.png"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if sprites[0].Name != "sprite.png" || sprites[0].Image.Bounds().Size() != image.Pt(3, 2) {
+		t.Errorf("Load() = %+v", sprites[0])
+	}
+
+	if _, err := Load([]string{path + ".missing"}, []string{"missing"}); err == nil {
+		t.Error("Load() of a missing file succeeded, want error")
+	}
+}
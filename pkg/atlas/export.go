@@ -0,0 +1,182 @@
+package atlas
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format is a frame metadata format
+type Format string
+
+const (
+	// FormatJSONHash is TexturePacker's JSON (Hash), with frames keyed by name
+	FormatJSONHash Format = "json-hash"
+	// FormatJSONArray is TexturePacker's JSON (Array), with a list of frames
+	FormatJSONArray Format = "json-array"
+	// FormatPhaser is the Phaser 3 multi-atlas JSON format
+	FormatPhaser Format = "phaser"
+	// FormatCSV is one row per frame with a header row
+	FormatCSV Format = "csv"
+)
+
+// Formats lists the supported metadata formats
+var Formats = []Format{FormatJSONHash, FormatJSONArray, FormatPhaser, FormatCSV}
+
+// ParseFormat parses a metadata format name such as "json-hash" or "csv"
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(name))); format {
+	case FormatJSONHash, "json", "hash":
+		return FormatJSONHash, nil
+	case FormatJSONArray, "array":
+		return FormatJSONArray, nil
+	case FormatPhaser, "phaser3":
+		return FormatPhaser, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("invalid metadata format '%s' (valid options: json-hash, json-array, phaser, csv)", name)
+	}
+}
+
+// MetadataPath returns the path of the metadata file for an atlas image:
+// atlas.json for json-hash, atlas.array.json, atlas.phaser.json or atlas.csv
+func MetadataPath(imagePath string, format Format) string {
+	base := strings.TrimSuffix(imagePath, filepath.Ext(imagePath))
+	switch format {
+	case FormatJSONArray:
+		return base + ".array.json"
+	case FormatPhaser:
+		return base + ".phaser.json"
+	case FormatCSV:
+		return base + ".csv"
+	default:
+		return base + ".json"
+	}
+}
+
+// jsonSize, jsonRect and jsonFrame follow TexturePacker's JSON layout,
+// which Phaser, PixiJS and most engines read
+type jsonSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type jsonRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type jsonFrame struct {
+	Filename         string   `json:"filename,omitempty"`
+	Frame            jsonRect `json:"frame"`
+	Rotated          bool     `json:"rotated"`
+	Trimmed          bool     `json:"trimmed"`
+	SpriteSourceSize jsonRect `json:"spriteSourceSize"`
+	SourceSize       jsonSize `json:"sourceSize"`
+}
+
+type jsonMeta struct {
+	App    string   `json:"app"`
+	Image  string   `json:"image,omitempty"`
+	Format string   `json:"format,omitempty"`
+	Size   jsonSize `json:"size"`
+	Scale  string   `json:"scale,omitempty"`
+}
+
+// WriteMetadata writes the frames of an atlas in a metadata format.
+// imageName is the atlas image file as referenced from the metadata, usually
+// its base name.
+func WriteMetadata(w io.Writer, a *Atlas, format Format, imageName string) error {
+	size := jsonSize{W: a.Image.Rect.Dx(), H: a.Image.Rect.Dy()}
+	meta := jsonMeta{App: "asset-generator", Image: imageName, Format: "RGBA8888", Size: size, Scale: "1"}
+
+	var doc interface{}
+	switch format {
+	case FormatJSONHash:
+		frames := make(map[string]jsonFrame, len(a.Frames))
+		for _, f := range a.Frames {
+			frames[f.Name] = newJSONFrame(f, "")
+		}
+		doc = struct {
+			Frames map[string]jsonFrame `json:"frames"`
+			Meta   jsonMeta             `json:"meta"`
+		}{frames, meta}
+	case FormatJSONArray:
+		doc = struct {
+			Frames []jsonFrame `json:"frames"`
+			Meta   jsonMeta    `json:"meta"`
+		}{namedFrames(a), meta}
+	case FormatPhaser:
+		type texture struct {
+			Image  string      `json:"image"`
+			Format string      `json:"format"`
+			Size   jsonSize    `json:"size"`
+			Scale  int         `json:"scale"`
+			Frames []jsonFrame `json:"frames"`
+		}
+		doc = struct {
+			Textures []texture `json:"textures"`
+			Meta     jsonMeta  `json:"meta"`
+		}{
+			Textures: []texture{{Image: imageName, Format: "RGBA8888", Size: size, Scale: 1, Frames: namedFrames(a)}},
+			Meta:     jsonMeta{App: "asset-generator", Size: size},
+		}
+	case FormatCSV:
+		return writeCSV(w, a)
+	default:
+		return fmt.Errorf("invalid metadata format '%s'", format)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// newJSONFrame converts a frame to TexturePacker's layout
+func newJSONFrame(f Frame, filename string) jsonFrame {
+	return jsonFrame{
+		Filename:         filename,
+		Frame:            jsonRect{X: f.Rect.Min.X, Y: f.Rect.Min.Y, W: f.Rect.Dx(), H: f.Rect.Dy()},
+		Trimmed:          f.Trimmed,
+		SpriteSourceSize: jsonRect{X: f.Source.Min.X, Y: f.Source.Min.Y, W: f.Source.Dx(), H: f.Source.Dy()},
+		SourceSize:       jsonSize{W: f.SourceSize.X, H: f.SourceSize.Y},
+	}
+}
+
+// namedFrames lists the frames of an atlas with their names as filename
+func namedFrames(a *Atlas) []jsonFrame {
+	frames := make([]jsonFrame, len(a.Frames))
+	for i, f := range a.Frames {
+		frames[i] = newJSONFrame(f, f.Name)
+	}
+	return frames
+}
+
+// writeCSV writes one row per frame: its name, position and size in the
+// atlas, whether it was trimmed, and the trimmed area of the original sprite
+// with the original size
+func writeCSV(w io.Writer, a *Atlas) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "x", "y", "width", "height", "trimmed",
+		"source_x", "source_y", "source_width", "source_height", "original_width", "original_height"})
+	for _, f := range a.Frames {
+		cw.Write([]string{
+			f.Name,
+			strconv.Itoa(f.Rect.Min.X), strconv.Itoa(f.Rect.Min.Y),
+			strconv.Itoa(f.Rect.Dx()), strconv.Itoa(f.Rect.Dy()),
+			strconv.FormatBool(f.Trimmed),
+			strconv.Itoa(f.Source.Min.X), strconv.Itoa(f.Source.Min.Y),
+			strconv.Itoa(f.Source.Dx()), strconv.Itoa(f.Source.Dy()),
+			strconv.Itoa(f.SourceSize.X), strconv.Itoa(f.SourceSize.Y),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
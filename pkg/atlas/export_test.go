@@ -0,0 +1,140 @@
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"strings"
+	"testing"
+)
+
+func testAtlas() *Atlas {
+	return &Atlas{
+		Image: image.NewNRGBA(image.Rect(0, 0, 64, 32)),
+		Frames: []Frame{
+			{Name: "hero.png", Rect: image.Rect(0, 0, 32, 32), Source: image.Rect(0, 0, 32, 32), SourceSize: image.Pt(32, 32)},
+			{Name: "coin.png", Rect: image.Rect(34, 0, 44, 12), Trimmed: true, Source: image.Rect(3, 2, 13, 14), SourceSize: image.Pt(16, 16)},
+		},
+	}
+}
+
+func TestWriteMetadataJSONHash(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMetadata(&buf, testAtlas(), FormatJSONHash, "sheet.png"); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+
+	var doc struct {
+		Frames map[string]jsonFrame `json:"frames"`
+		Meta   jsonMeta             `json:"meta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	coin := doc.Frames["coin.png"]
+	if coin.Frame != (jsonRect{34, 0, 10, 12}) || !coin.Trimmed || coin.SpriteSourceSize != (jsonRect{3, 2, 10, 12}) || coin.SourceSize != (jsonSize{16, 16}) {
+		t.Errorf("coin frame = %+v", coin)
+	}
+	if doc.Meta.Image != "sheet.png" || doc.Meta.Size != (jsonSize{64, 32}) {
+		t.Errorf("meta = %+v", doc.Meta)
+	}
+}
+
+func TestWriteMetadataJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMetadata(&buf, testAtlas(), FormatJSONArray, "sheet.png"); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+
+	var doc struct {
+		Frames []jsonFrame `json:"frames"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Frames) != 2 || doc.Frames[0].Filename != "hero.png" || doc.Frames[1].Filename != "coin.png" {
+		t.Errorf("frames = %+v, want hero.png and coin.png in order", doc.Frames)
+	}
+}
+
+func TestWriteMetadataPhaser(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMetadata(&buf, testAtlas(), FormatPhaser, "sheet.png"); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+
+	var doc struct {
+		Textures []struct {
+			Image  string      `json:"image"`
+			Size   jsonSize    `json:"size"`
+			Frames []jsonFrame `json:"frames"`
+		} `json:"textures"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Textures) != 1 || doc.Textures[0].Image != "sheet.png" || len(doc.Textures[0].Frames) != 2 {
+		t.Fatalf("textures = %+v", doc.Textures)
+	}
+	if doc.Textures[0].Size != (jsonSize{64, 32}) {
+		t.Errorf("size = %+v, want 64x32", doc.Textures[0].Size)
+	}
+}
+
+func TestWriteMetadataCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMetadata(&buf, testAtlas(), FormatCSV, "sheet.png"); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"name,x,y,width,height,trimmed,source_x,source_y,source_width,source_height,original_width,original_height",
+		"hero.png,0,0,32,32,false,0,0,32,32,32,32",
+		"coin.png,34,0,10,12,true,3,2,10,12,16,16",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), strings.Join(want, "\n"))
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{input: "json-hash", want: FormatJSONHash},
+		{input: "json", want: FormatJSONHash},
+		{input: "JSON-Array", want: FormatJSONArray},
+		{input: "phaser3", want: FormatPhaser},
+		{input: "csv", want: FormatCSV},
+		{input: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataPath(t *testing.T) {
+	tests := map[Format]string{
+		FormatJSONHash:  "out/sheet.json",
+		FormatJSONArray: "out/sheet.array.json",
+		FormatPhaser:    "out/sheet.phaser.json",
+		FormatCSV:       "out/sheet.csv",
+	}
+	for format, want := range tests {
+		if got := MetadataPath("out/sheet.png", format); got != want {
+			t.Errorf("MetadataPath(%s) = %s, want %s", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package atlas
+
+import "image"
+
+// maxRects is a rectangle bin packer using the MaxRects algorithm with the
+// best short side fit heuristic. It keeps every maximal free rectangle of the
+// bin, so free space on either side of a placed rectangle stays usable.
+type maxRects struct {
+	free []image.Rectangle
+}
+
+// newMaxRects returns an empty bin of the given size
+func newMaxRects(width, height int) *maxRects {
+	return &maxRects{free: []image.Rectangle{image.Rect(0, 0, width, height)}}
+}
+
+// insert places a width×height rectangle in the bin, returning false when
+// it does not fit anywhere
+func (m *maxRects) insert(width, height int) (image.Rectangle, bool) {
+	best := -1
+	bestShort, bestLong := 0, 0
+	for i, r := range m.free {
+		if r.Dx() < width || r.Dy() < height {
+			continue
+		}
+		leftoverX, leftoverY := r.Dx()-width, r.Dy()-height
+		short, long := min(leftoverX, leftoverY), max(leftoverX, leftoverY)
+		if best < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+	if best < 0 {
+		return image.Rectangle{}, false
+	}
+
+	placed := image.Rect(0, 0, width, height).Add(m.free[best].Min)
+	m.place(placed)
+	return placed, true
+}
+
+// place splits every free rectangle overlapping placed into the up to four
+// maximal rectangles around it, then drops free rectangles contained in
+// others
+func (m *maxRects) place(placed image.Rectangle) {
+	free := m.free[:0:0]
+	for _, r := range m.free {
+		if !r.Overlaps(placed) {
+			free = append(free, r)
+			continue
+		}
+		if placed.Min.X > r.Min.X {
+			free = append(free, image.Rect(r.Min.X, r.Min.Y, placed.Min.X, r.Max.Y))
+		}
+		if placed.Max.X < r.Max.X {
+			free = append(free, image.Rect(placed.Max.X, r.Min.Y, r.Max.X, r.Max.Y))
+		}
+		if placed.Min.Y > r.Min.Y {
+			free = append(free, image.Rect(r.Min.X, r.Min.Y, r.Max.X, placed.Min.Y))
+		}
+		if placed.Max.Y < r.Max.Y {
+			free = append(free, image.Rect(r.Min.X, placed.Max.Y, r.Max.X, r.Max.Y))
+		}
+	}
+
+	// Prune rectangles contained in another; of identical ones, keep the first
+	m.free = make([]image.Rectangle, 0, len(free))
+	for i, r := range free {
+		contained := false
+		for j, other := range free {
+			if i != j && r.In(other) && (!r.Eq(other) || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			m.free = append(m.free, r)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package atlas
+
+import (
+	"image"
+	"testing"
+)
+
+func TestMaxRectsInsert(t *testing.T) {
+	bin := newMaxRects(64, 64)
+	sizes := []image.Point{{32, 32}, {32, 16}, {16, 16}, {16, 16}, {32, 32}, {32, 32}}
+
+	var placed []image.Rectangle
+	for _, s := range sizes {
+		r, ok := bin.insert(s.X, s.Y)
+		if !ok {
+			t.Fatalf("insert(%v) did not fit after %v", s, placed)
+		}
+		if r.Size() != s {
+			t.Errorf("insert(%v) placed %v", s, r)
+		}
+		if !r.In(image.Rect(0, 0, 64, 64)) {
+			t.Errorf("%v is outside the bin", r)
+		}
+		for _, other := range placed {
+			if r.Overlaps(other) {
+				t.Errorf("%v overlaps %v", r, other)
+			}
+		}
+		placed = append(placed, r)
+	}
+
+	// The bin is now full
+	if r, ok := bin.insert(1, 1); ok {
+		t.Errorf("insert into a full bin placed %v", r)
+	}
+}
+
+func TestMaxRectsTooLarge(t *testing.T) {
+	bin := newMaxRects(32, 32)
+	if _, ok := bin.insert(33, 1); ok {
+		t.Error("insert wider than the bin succeeded")
+	}
+	if _, ok := bin.insert(32, 32); !ok {
+		t.Error("insert of the bin size failed")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -35,8 +36,12 @@ type PersistedSession struct {
 	ID        string    `json:"id"`
 	Status    string    `json:"status"`
 	Progress  float64   `json:"progress"`
+	Phase     string    `json:"phase,omitempty"`
+	Step      int       `json:"step,omitempty"`
+	Steps     int       `json:"steps,omitempty"`
 	StartTime time.Time `json:"start_time"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Server    string    `json:"server,omitempty"` // URL of the server running the generation
 }
 
 // Config holds the client configuration
@@ -44,6 +49,9 @@ type Config struct {
 	BaseURL string
 	APIKey  string
 	Verbose bool
+	// Servers lists the servers a ServerPool distributes work across.
+	// When empty, BaseURL is the only server.
+	Servers []ServerConfig
 }
 
 // AssetClient is the main client for interacting with asset generation APIs
@@ -52,9 +60,10 @@ type AssetClient struct {
 	httpClient    *http.Client
 	wsConn        *websocket.Conn // Reserved for future WebSocket implementation
 	mu            sync.RWMutex
-	sessions      map[string]*GenerationSession
-	sessionID     string // Current session ID for API calls
-	stateFilePath string // Path to the persistent state file
+	sessions      map[string]*GenerationSession // Tracked generations, by generation key
+	sessionID     string                        // Current session ID for API calls
+	stateFilePath string                        // Path to the persistent state file
+	generations   int                           // Number of generations started, for their keys
 }
 
 // ProgressCallback is called with progress updates during generation
@@ -67,6 +76,12 @@ type GenerationRequest struct {
 	Parameters       map[string]interface{} `json:"parameters"`
 	SessionID        string                 `json:"session_id,omitempty"`
 	ProgressCallback ProgressCallback       `json:"-"` // Not serialized, used for progress updates
+	// ProgressUpdateCallback receives the full progress reported by the server,
+	// including the step and preview image
+	ProgressUpdateCallback func(ProgressUpdate) `json:"-"`
+	// SessionCallback is called with the session ID before the generation
+	// is submitted
+	SessionCallback func(sessionID string) `json:"-"`
 }
 
 // GenerationResult represents the result of a generation
@@ -83,8 +98,12 @@ type GenerationSession struct {
 	ID        string
 	Status    string
 	Progress  float64
+	Phase     string // Phase reported by the server, if known
+	Step      int    // Current sampling step, if known
+	Steps     int    // Total sampling steps, if known
 	StartTime time.Time
 	Result    *GenerationResult
+	Server    string // URL of the server running the generation
 }
 
 // Model represents an asset generation model
@@ -94,6 +113,7 @@ type Model struct {
 	Description string `json:"description"`
 	Version     string `json:"version"`
 	Loaded      bool   `json:"loaded"`
+	Hash        string `json:"hash,omitempty"` // Content hash reported by the server, if known
 }
 
 // NewAssetClient creates a new asset generation API client
@@ -128,6 +148,11 @@ func NewAssetClient(config *Config) (*AssetClient, error) {
 	return client, nil
 }
 
+// BaseURL returns the URL of the server the client talks to
+func (c *AssetClient) BaseURL() string {
+	return c.config.BaseURL
+}
+
 // GetNewSession gets a new session ID from the asset generation API
 func (c *AssetClient) GetNewSession(ctx context.Context) (string, error) {
 	endpoint := fmt.Sprintf("%s/API/GetNewSession", c.config.BaseURL)
@@ -185,24 +210,15 @@ func (c *AssetClient) GenerateImage(ctx context.Context, req *GenerationRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-
-	// Create local session tracking
-	session := &GenerationSession{
-		ID:        sessionID,
-		Status:    "pending",
-		Progress:  0,
-		StartTime: time.Now(),
+	if req.SessionCallback != nil {
+		req.SessionCallback(sessionID)
 	}
 
-	c.mu.Lock()
-	c.sessions[sessionID] = session
-	c.mu.Unlock()
-
-	// Save initial state to file
-	c.saveStateToFile()
+	// Create local session tracking and save it to the state file
+	key, session := c.trackGeneration(sessionID, "pending")
 
 	// Ensure session cleanup on function exit (success or error)
-	defer c.removeSessionState(sessionID)
+	defer c.removeSessionState(key)
 
 	// Make HTTP request to generate endpoint
 	endpoint := fmt.Sprintf("%s/API/GenerateText2Image", c.config.BaseURL)
@@ -304,28 +320,16 @@ func (c *AssetClient) GenerateImage(ctx context.Context, req *GenerationRequest)
 		c.saveStateToFile()
 	}
 
-	// Start progress simulation in background for HTTP requests
-	// Since we're using HTTP (not WebSocket), we simulate progress
-	var progressDone chan bool
-	if req.ProgressCallback != nil {
-		progressDone = make(chan bool, 1)
-		go c.simulateProgress(sessionID, req.ProgressCallback, progressDone)
-	}
+	// Poll the server for progress while the HTTP request blocks
+	stopProgress := c.startProgress(ctx, key, sessionID, req)
 
 	resp, err := c.httpClient.Do(httpReq)
+	stopProgress()
 	if err != nil {
-		if progressDone != nil {
-			progressDone <- true // Stop progress simulation
-		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Stop progress simulation
-	if progressDone != nil {
-		progressDone <- true
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
@@ -403,6 +407,9 @@ func (c *AssetClient) GenerateImageWS(ctx context.Context, req *GenerationReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
+	if req.SessionCallback != nil {
+		req.SessionCallback(sessionID)
+	}
 
 	// Build WebSocket URL (convert http:// to ws:// or https:// to wss://)
 	// SwarmUI WebSocket endpoint: ws://host/API/GenerateText2ImageWS
@@ -461,134 +468,162 @@ func (c *AssetClient) GenerateImageWS(ctx context.Context, req *GenerationReques
 		return nil, fmt.Errorf("failed to send WebSocket request: %w", err)
 	}
 
-	// Create session tracking
-	session := &GenerationSession{
-		ID:        sessionID,
-		Status:    "generating",
-		Progress:  0.0,
-		StartTime: time.Now(),
-	}
-
-	c.mu.Lock()
-	c.sessions[sessionID] = session
-	c.mu.Unlock()
-
-	// Save initial state to file
-	c.saveStateToFile()
+	// Create session tracking and save it to the state file
+	key, session := c.trackGeneration(sessionID, "generating")
 
 	// Ensure session cleanup on function exit
-	defer c.removeSessionState(sessionID)
+	defer c.removeSessionState(key)
+
+	// Close the connection on cancellation to unblock reads
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stopWatch:
+		}
+	}()
 
 	// Listen for progress updates
 	// SwarmUI sends multiple JSON messages over the WebSocket connection:
-	// 1. Progress updates: {"progress": 0.45, "status": "generating"}
-	// 2. Final result: {"images": ["path/to/image.png"], "info": {...}}
-	var finalResult *GenerationResult
+	// 1. Status: {"status": {"waiting_gens": 0, "live_gens": 1, ...}}
+	// 2. Progress with previews: {"gen_progress": {"batch_index": "0", "overall_percent": 0.4,
+	//    "current_percent": 0.6, "preview": "data:image/jpeg;base64,..."}}
+	// 3. One message per finished image: {"image": "View/...", "batch_index": "0"}
+	//    (older servers send all images at once: {"images": [...]})
+	// The server closes the connection when the generation is complete.
+	steps := requestSteps(req)
+	var last ProgressUpdate
+	var imagePaths []string
+	var metadata map[string]interface{}
 	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			// Read message from WebSocket
-			var msg map[string]interface{}
-			if err := conn.ReadJSON(&msg); err != nil {
-				// Check if it's a normal close (generation complete)
-				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// A normal close after images were sent ends the generation
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				if len(imagePaths) > 0 {
 					break
 				}
-				return nil, fmt.Errorf("WebSocket read error: %w", err)
+				return nil, fmt.Errorf("WebSocket closed without returning images")
 			}
+			return nil, fmt.Errorf("WebSocket read error: %w", err)
+		}
 
-			// Handle error messages from SwarmUI
-			if errMsg, ok := msg["error"].(string); ok && errMsg != "" {
-				// Handle session expiration - retry with new session (same as HTTP behavior)
-				if errID, hasErrID := msg["error_id"].(string); hasErrID && errID == "invalid_session_id" {
-					c.mu.Lock()
-					oldSessionID := c.sessionID
-					c.sessionID = ""
-					c.mu.Unlock()
-
-					// Only retry if we had a cached session (prevents infinite recursion)
-					if oldSessionID != "" {
-						return c.GenerateImageWS(ctx, req)
-					}
-				}
-				return nil, fmt.Errorf("SwarmUI error: %s", errMsg)
+		var msg struct {
+			progressMessage
+			Image   json.RawMessage        `json:"image,omitempty"`
+			Images  []string               `json:"images,omitempty"`
+			Info    map[string]interface{} `json:"info,omitempty"`
+			Error   string                 `json:"error,omitempty"`
+			ErrorID string                 `json:"error_id,omitempty"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			if c.config.Verbose {
+				fmt.Printf("Ignoring WebSocket message: %v\n", err)
 			}
+			continue
+		}
 
-			// Parse real-time progress updates from SwarmUI
-			// Unlike simulated progress, these reflect actual generation state
-			if progress, ok := msg["progress"].(float64); ok {
+		// Handle error messages from SwarmUI
+		if msg.Error != "" {
+			// Handle session expiration - retry with new session (same as HTTP behavior)
+			if msg.ErrorID == "invalid_session_id" {
 				c.mu.Lock()
-				session.Progress = progress
+				oldSessionID := c.sessionID
+				c.sessionID = ""
 				c.mu.Unlock()
 
-				// Persist progress to file
-				c.saveStateToFile()
-
-				if req.ProgressCallback != nil {
-					status := "Generating..."
-					if statusStr, ok := msg["status"].(string); ok {
-						status = statusStr
-					}
-					req.ProgressCallback(progress, status)
+				// Only retry if we had a cached session (prevents infinite recursion)
+				if oldSessionID != "" {
+					return c.GenerateImageWS(ctx, req)
 				}
 			}
+			return nil, fmt.Errorf("SwarmUI error: %s", msg.Error)
+		}
 
-			// Check for completion (images field indicates generation is complete)
-			// This is the final message from SwarmUI containing the result
-			if images, ok := msg["images"].([]interface{}); ok && len(images) > 0 {
-				// Convert images to string slice
-				imagePaths := make([]string, len(images))
-				for i, img := range images {
-					if imgStr, ok := img.(string); ok {
-						imagePaths[i] = imgStr
-					}
-				}
-
-				// Extract metadata if present
-				metadata := make(map[string]interface{})
-				if info, ok := msg["info"].(map[string]interface{}); ok {
-					metadata = info
-				}
-
-				finalResult = &GenerationResult{
-					SessionID:  sessionID,
-					ImagePaths: imagePaths,
-					Metadata:   metadata,
-					Status:     "completed",
-					CreatedAt:  time.Now(),
-				}
-
-				// Update session
-				c.mu.Lock()
-				session.Status = "completed"
-				session.Progress = 1.0
-				session.Result = finalResult
-				c.mu.Unlock()
+		// Real-time progress and previews from SwarmUI
+		// Unlike simulated progress, these reflect actual generation state
+		if update, ok := msg.update(steps); ok {
+			c.reportProgress(key, req, update, &last)
+		}
 
-				// Report completion
-				if req.ProgressCallback != nil {
-					req.ProgressCallback(1.0, "Generation completed")
+		// Collect finished images; the image may be a path or an object with one
+		if len(msg.Image) > 0 {
+			var image string
+			if json.Unmarshal(msg.Image, &image) != nil {
+				var withPath struct {
+					Image string `json:"image"`
 				}
-
-				break
+				json.Unmarshal(msg.Image, &withPath)
+				image = withPath.Image
+			}
+			if image != "" {
+				imagePaths = append(imagePaths, image)
 			}
 		}
+		if msg.Info != nil {
+			metadata = msg.Info
+		}
 
-		// Break if we got final result
-		if finalResult != nil {
+		// All images at once is the final message on older servers
+		if len(msg.Images) > 0 {
+			imagePaths = append(imagePaths, msg.Images...)
 			break
 		}
 	}
 
-	if finalResult == nil {
-		return nil, fmt.Errorf("WebSocket closed without returning images")
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	finalResult := &GenerationResult{
+		SessionID:  sessionID,
+		ImagePaths: imagePaths,
+		Metadata:   metadata,
+		Status:     "completed",
+		CreatedAt:  time.Now(),
+	}
+
+	// Update session
+	c.mu.Lock()
+	session.Status = "completed"
+	session.Progress = 1.0
+	session.Result = finalResult
+	c.mu.Unlock()
+
+	// Report completion
+	if req.ProgressCallback != nil {
+		req.ProgressCallback(1.0, "Generation completed")
 	}
 
 	return finalResult, nil
 }
 
+// trackGeneration starts tracking a generation on the server session
+// sessionID, saves it to the state file and returns its key in c.sessions.
+// Concurrent generations of one client share the server session, so each
+// gets a key of its own.
+func (c *AssetClient) trackGeneration(sessionID, status string) (string, *GenerationSession) {
+	session := &GenerationSession{
+		ID:        sessionID,
+		Status:    status,
+		StartTime: time.Now(),
+		Server:    c.config.BaseURL,
+	}
+
+	c.mu.Lock()
+	c.generations++
+	key := fmt.Sprintf("%s-%d", sessionID, c.generations)
+	c.sessions[key] = session
+	c.mu.Unlock()
+
+	c.saveStateToFile()
+	return key, session
+}
+
 // cleanupSession removes a session from memory to prevent memory leaks
 func (c *AssetClient) cleanupSession(sessionID string) {
 	c.mu.Lock()
@@ -639,7 +674,14 @@ func (c *AssetClient) loadStateFromFile() {
 				ID:        ps.ID,
 				Status:    ps.Status,
 				Progress:  ps.Progress,
+				Phase:     ps.Phase,
+				Step:      ps.Step,
+				Steps:     ps.Steps,
 				StartTime: ps.StartTime,
+				Server:    ps.Server,
+			}
+			if ps.Server == "" {
+				c.sessions[id].Server = c.config.BaseURL
 			}
 		}
 	}
@@ -649,29 +691,58 @@ func (c *AssetClient) loadStateFromFile() {
 	}
 }
 
-// saveStateToFile persists current generation sessions to the state file
+// stateFileMu serializes updates of the state file, which is shared by every
+// client of the process (e.g. the clients of a ServerPool)
+var stateFileMu sync.Mutex
+
+// saveStateToFile persists current generation sessions to the state file.
+// Each client writes only the sessions of its own server and keeps those of
+// the other servers in the file, so the clients of a ServerPool can share it.
 func (c *AssetClient) saveStateToFile() error {
+	// Snapshot this client's active sessions without holding c.mu during file IO
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	state := persistedState{
-		Sessions:  make(map[string]*PersistedSession),
-		UpdatedAt: time.Now(),
-	}
-
-	// Convert in-memory sessions to persistable format
+	own := make(map[string]*PersistedSession)
 	for id, session := range c.sessions {
+		if session.Server != c.config.BaseURL {
+			continue // Loaded from the file; another client owns it
+		}
 		// Only persist active sessions
 		if session.Status == "generating" || session.Status == "starting" || session.Status == "pending" {
-			state.Sessions[id] = &PersistedSession{
+			own[id] = &PersistedSession{
 				ID:        session.ID,
 				Status:    session.Status,
 				Progress:  session.Progress,
+				Phase:     session.Phase,
+				Step:      session.Step,
+				Steps:     session.Steps,
 				StartTime: session.StartTime,
 				UpdatedAt: time.Now(),
+				Server:    session.Server,
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	stateFileMu.Lock()
+	defer stateFileMu.Unlock()
+
+	state := persistedState{
+		Sessions:  own,
+		UpdatedAt: time.Now(),
+	}
+
+	// Keep the sessions other servers' clients saved
+	if data, err := os.ReadFile(c.stateFilePath); err == nil {
+		var saved persistedState
+		if json.Unmarshal(data, &saved) == nil {
+			for id, ps := range saved.Sessions {
+				if ps.Server != "" && ps.Server != c.config.BaseURL {
+					state.Sessions[id] = ps
+				}
 			}
 		}
 	}
+	saved := len(own)
 
 	// Marshal to JSON
 	data, err := json.MarshalIndent(state, "", "  ")
@@ -690,8 +761,8 @@ func (c *AssetClient) saveStateToFile() error {
 		return fmt.Errorf("failed to rename state file: %w", err)
 	}
 
-	if c.config.Verbose && len(state.Sessions) > 0 {
-		fmt.Printf("Saved %d session(s) to state file\n", len(state.Sessions))
+	if c.config.Verbose && saved > 0 {
+		fmt.Printf("Saved %d session(s) to state file\n", saved)
 	}
 
 	return nil
@@ -699,6 +770,9 @@ func (c *AssetClient) saveStateToFile() error {
 
 // cleanupOldPersistedSessions removes old sessions from the state file
 func (c *AssetClient) cleanupOldPersistedSessions() {
+	stateFileMu.Lock()
+	defer stateFileMu.Unlock()
+
 	data, err := os.ReadFile(c.stateFilePath)
 	if err != nil {
 		return // File doesn't exist or can't be read - nothing to clean
@@ -737,9 +811,9 @@ func (c *AssetClient) cleanupOldPersistedSessions() {
 }
 
 // updateSessionState updates a session in both memory and persistent state
-func (c *AssetClient) updateSessionState(sessionID, status string, progress float64) error {
+func (c *AssetClient) updateSessionState(key, status string, progress float64) error {
 	c.mu.Lock()
-	if session, exists := c.sessions[sessionID]; exists {
+	if session, exists := c.sessions[key]; exists {
 		session.Status = status
 		session.Progress = progress
 	}
@@ -750,9 +824,9 @@ func (c *AssetClient) updateSessionState(sessionID, status string, progress floa
 }
 
 // removeSessionState removes a session from both memory and persistent state
-func (c *AssetClient) removeSessionState(sessionID string) error {
+func (c *AssetClient) removeSessionState(key string) error {
 	c.mu.Lock()
-	delete(c.sessions, sessionID)
+	delete(c.sessions, key)
 	c.mu.Unlock()
 
 	// Persist to file
@@ -1173,30 +1247,23 @@ func (c *AssetClient) InterruptAll(ctx context.Context) error {
 	return nil
 }
 
-// simulateProgress provides progress updates for HTTP-based generation
-// This is a temporary solution until WebSocket support is implemented
-// TODO: Replace with actual WebSocket implementation using GenerateText2ImageWS endpoint
-func (c *AssetClient) simulateProgress(sessionID string, callback ProgressCallback, done chan bool) {
-	ticker := time.NewTicker(500 * time.Millisecond) // Update every 500ms
+// simulateProgress provides progress updates for HTTP-based generation on
+// servers that don't report progress (see pollProgress)
+func (c *AssetClient) simulateProgress(key string, callback ProgressCallback, done <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(simulatedProgressTick) // Update every 500ms
 	defer ticker.Stop()
 
-	progress := 0.1   // Start at 10%
-	increment := 0.05 // Increase by 5% each tick
-
 	for {
 		select {
 		case <-done:
 			return
 		case <-ticker.C:
-			progress += increment
-			if progress > 0.9 { // Cap at 90% until completion
-				progress = 0.9
-				increment = 0.01 // Slow down near completion
-			}
+			progress := simulatedProgress(time.Since(start))
 
 			// Update session progress
 			c.mu.Lock()
-			if session, exists := c.sessions[sessionID]; exists {
+			if session, exists := c.sessions[key]; exists {
 				session.Progress = progress
 				session.Status = "generating"
 			}
@@ -1208,6 +1275,16 @@ func (c *AssetClient) simulateProgress(sessionID string, callback ProgressCallba
 	}
 }
 
+// simulatedProgressTick is how often simulated progress advances
+const simulatedProgressTick = 500 * time.Millisecond
+
+// simulatedProgress estimates the progress of a generation that has run for
+// elapsed: 10% to start with, then 5% more each tick, capped at 90% until
+// the generation completes
+func simulatedProgress(elapsed time.Duration) float64 {
+	return math.Min(0.1+0.05*float64(elapsed/simulatedProgressTick), 0.9)
+}
+
 // DownloadOptions holds options for downloading images
 type DownloadOptions struct {
 	OutputDir        string                 // Directory to save images
@@ -1215,18 +1292,51 @@ type DownloadOptions struct {
 	Metadata         map[string]interface{} // Metadata for template variables
 
 	// Postprocessing options - applied locally after download
-	// Auto-crop (runs first, before downscaling)
-	AutoCrop               bool  // Enable automatic cropping of whitespace borders
-	AutoCropThreshold      uint8 // Whitespace detection threshold (0-255, default: 250)
-	AutoCropTolerance      uint8 // Tolerance for near-white colors (0-255, default: 10)
-	AutoCropPreserveAspect bool  // Preserve original aspect ratio when cropping
+	// Background removal (runs first, so auto-crop trims the cleared area).
+	// Images are saved as PNG unless Format names another format with
+	// transparency.
+	RemoveBackground          bool   // Make the flat background transparent
+	RemoveBackgroundMode      string // "flood" (default, from the borders) or "key" (everywhere)
+	RemoveBackgroundColor     string // Background color (default: sampled from the corners)
+	RemoveBackgroundTolerance uint8  // Largest per-channel difference from the color (default: 10)
+	RemoveBackgroundFeather   uint8  // Soft edge width beyond the tolerance (0 = hard edges)
+
+	// Auto-crop (runs before downscaling)
+	AutoCrop               bool    // Enable automatic cropping of whitespace borders
+	AutoCropThreshold      uint8   // Whitespace detection threshold (0-255, default: 250)
+	AutoCropTolerance      uint8   // Tolerance for near-white colors (0-255, default: 10)
+	AutoCropPreserveAspect bool    // Preserve original aspect ratio when cropping
+	AutoCropMode           string  // Background to crop: "white" (default), "auto", "color", "alpha"
+	AutoCropColor          string  // Background color; selects "color" mode unless another mode is set
+	AutoCropPadding        int     // Margin in pixels kept around the content
+	AutoCropPaddingPercent float64 // Margin kept as a percentage of the larger content side
+
+	// Padding (runs after auto-crop, before downscaling); enabled when a
+	// size, aspect ratio or margin is set
+	PadWidth      int    // Minimum canvas width
+	PadHeight     int    // Minimum canvas height
+	PadAspect     string // Canvas aspect ratio, such as "1:1" or "16:9"
+	PadMargin     int    // Margin in pixels added on every side
+	PadAnchor     string // Where the image is placed: "center" (default), "top", "bottom-left", ...
+	PadFill       string // "color" (default), "edge" or "mirror"
+	PadBackground string // Fill color for the color fill (default: transparent)
 
 	// Downscaling (runs after auto-crop if enabled)
 	DownscaleWidth      int     // Target width for downscaling (0 means auto-calculate from height)
 	DownscaleHeight     int     // Target height for downscaling (0 means auto-calculate from width)
 	DownscalePercentage float64 // Scale by percentage (1-100, takes precedence over Width/Height if > 0)
 	DownscaleFilter     string  // Downscaling algorithm: "lanczos" (default), "bilinear", "nearest"
+	DownscaleLinear     bool    // Resample in linear light instead of sRGB
 	JPEGQuality         int     // JPEG quality for downscaled images (1-100, default: 90)
+
+	// Output format: png, jpeg, webp, gif, bmp or tiff. Images are converted
+	// and their extension changed to match; empty keeps the format of the
+	// filename's extension.
+	Format string
+
+	// Callbacks, called for each image once it is saved
+	DownloadedCallback    func(path string)                      // Every image
+	PostprocessedCallback func(path string, operations []string) // Images that were postprocessed, with the operations applied
 }
 
 // DownloadImages downloads generated images from the server and saves them to the specified directory.
@@ -1259,6 +1369,14 @@ func (c *AssetClient) DownloadImagesWithOptions(ctx context.Context, imagePaths
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	chain, err := postprocessChain(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Steps reported for postprocessed images
+	steps := chain.Names()
+
 	savedPaths := make([]string, 0, len(imagePaths))
 	var downloadErrors []error
 
@@ -1285,31 +1403,48 @@ func (c *AssetClient) DownloadImagesWithOptions(ctx context.Context, imagePaths
 			filename = originalFilename
 		}
 
-		// Create output file path
+		// Create output file path; the raw download keeps the original extension
+		rawPath := fmt.Sprintf("%s/%s", outputDir, filename)
+		if chain.Format != "" {
+			filename = processor.ReplaceExtension(filename, chain.Format)
+		}
 		outputPath := fmt.Sprintf("%s/%s", outputDir, filename)
 
-		// Download the image
-		if err := c.downloadFile(ctx, imageURL, outputPath); err != nil {
+		// Download the image into memory
+		data, err := c.fetchFile(ctx, imageURL)
+		if err != nil {
 			downloadErrors = append(downloadErrors, fmt.Errorf("failed to download image %d (%s): %w", i+1, filename, err))
 			continue
 		}
 
-		// Apply postprocessing pipeline (order matters: crop first, then downscale)
-
-		// Step 1: Auto-crop if enabled (removes whitespace before downscaling)
-		if opts.AutoCrop {
-			if err := c.applyAutoCrop(outputPath, opts); err != nil {
-				downloadErrors = append(downloadErrors, fmt.Errorf("failed to auto-crop image %d (%s): %w", i+1, filename, err))
-				continue
+		// Postprocess and save it, stripping PNG metadata. JPEG output is
+		// flattened, which is reported along with the other operations.
+		imageChain := chain.FlattenedFor(outputPath)
+		operations := append([]string(nil), steps...)
+		if imageChain != chain && (len(steps) > 0 || opts.Format != "") {
+			operations = append(operations, "flatten")
+		}
+		if opts.Format != "" {
+			operations = append(operations, "convert")
+		}
+		if c.config.Verbose && len(operations) > 0 {
+			fmt.Printf("Postprocessing image: %s (%s)\n", outputPath, strings.Join(operations, ", "))
+		}
+		if err := imageChain.WriteFile(data, outputPath); err != nil {
+			// Keep the raw download so the generation is not lost
+			if writeErr := os.WriteFile(rawPath, data, 0644); writeErr != nil {
+				downloadErrors = append(downloadErrors, fmt.Errorf("failed to process image %d (%s): %w", i+1, filename, err))
+			} else {
+				downloadErrors = append(downloadErrors, fmt.Errorf("failed to process image %d (%s), kept the raw download at %s: %w", i+1, filename, rawPath, err))
 			}
+			continue
 		}
 
-		// Step 2: Downscale if options are set
-		if opts.DownscaleWidth > 0 || opts.DownscaleHeight > 0 {
-			if err := c.applyDownscale(outputPath, opts); err != nil {
-				downloadErrors = append(downloadErrors, fmt.Errorf("failed to downscale image %d (%s): %w", i+1, filename, err))
-				continue
-			}
+		if opts.DownloadedCallback != nil {
+			opts.DownloadedCallback(outputPath)
+		}
+		if len(operations) > 0 && opts.PostprocessedCallback != nil {
+			opts.PostprocessedCallback(outputPath, operations)
 		}
 
 		savedPaths = append(savedPaths, outputPath)
@@ -1332,12 +1467,12 @@ func (c *AssetClient) DownloadImagesWithOptions(ctx context.Context, imagePaths
 	return savedPaths, nil
 }
 
-// downloadFile downloads a file from the given URL and saves it to the specified path
-func (c *AssetClient) downloadFile(ctx context.Context, url, filepath string) error {
+// fetchFile downloads a file from the given URL into memory
+func (c *AssetClient) fetchFile(ctx context.Context, url string) ([]byte, error) {
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add authorization header if API key is set
@@ -1348,37 +1483,21 @@ func (c *AssetClient) downloadFile(ctx context.Context, url, filepath string) er
 	// Execute request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("download request failed: %w", err)
+		return nil, fmt.Errorf("download request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	// Create output file
-	out, err := os.Create(filepath)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer out.Close()
-
-	// Copy response body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	// Mandatory: Strip all PNG metadata from downloaded images
-	// This removes any generation parameters, timestamps, or other sensitive data
-	// that may have been embedded by the generation API
-	if err := processor.StripPNGMetadata(filepath); err != nil {
-		return fmt.Errorf("failed to strip PNG metadata: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-
-	return nil
+	return data, nil
 }
 
 // generateFilename creates a filename from a template with variable substitution.
@@ -1506,97 +1625,133 @@ func ensureDir(dir string) error {
 	return nil
 }
 
-// applyAutoCrop applies local postprocessing auto-crop to an image file.
-// This is called after downloading to remove whitespace borders before downscaling.
-func (c *AssetClient) applyAutoCrop(imagePath string, opts *DownloadOptions) error {
-	threshold := opts.AutoCropThreshold
-	if threshold == 0 {
-		threshold = 250 // default: very light colors are whitespace
-	}
-
-	tolerance := opts.AutoCropTolerance
-	if tolerance == 0 {
-		tolerance = 10 // default tolerance
-	}
-
-	quality := opts.JPEGQuality
-	if quality == 0 {
-		quality = 90 // default JPEG quality
-	}
-
-	if c.config.Verbose {
-		fmt.Printf("Auto-cropping image: %s (threshold: %d, tolerance: %d, preserve aspect: %v)\n",
-			imagePath, threshold, tolerance, opts.AutoCropPreserveAspect)
-	}
-
-	// Build crop options
-	cropOpts := processor.CropOptions{
-		Threshold:           threshold,
-		Tolerance:           tolerance,
-		JPEGQuality:         quality,
-		PreserveAspectRatio: opts.AutoCropPreserveAspect,
-	}
-
-	// Apply auto-crop in-place (replaces the original file)
-	if err := processor.AutoCropInPlace(imagePath, cropOpts); err != nil {
-		return fmt.Errorf("auto-crop operation failed: %w", err)
+// postprocessChain builds the chain that postprocesses downloaded images.
+// Images are decoded once, cropped and downscaled in memory and encoded once.
+// JPEG output is flattened per file with Chain.FlattenedFor, as the format
+// may only follow from the output path.
+func postprocessChain(opts *DownloadOptions) (*processor.Chain, error) {
+	chain := processor.NewChain()
+	chain.JPEGQuality = opts.JPEGQuality
+	if opts.Format != "" {
+		format, err := processor.ParseFormat(opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		chain.Format = format
 	}
 
-	return nil
-}
-
-// applyDownscale applies local postprocessing downscaling to an image file.
-// This is called after downloading to reduce image size using high-quality Lanczos filtering.
-func (c *AssetClient) applyDownscale(imagePath string, opts *DownloadOptions) error {
-	// Map filter name to processor filter type
-	filter := "lanczos" // default
-	if opts.DownscaleFilter != "" {
-		filter = strings.ToLower(opts.DownscaleFilter)
+	// Step 1: Remove the background if enabled, keeping the alpha channel
+	if opts.RemoveBackground {
+		mode, err := processor.ParseBackgroundMode(opts.RemoveBackgroundMode)
+		if err != nil {
+			return nil, err
+		}
+		bgOpts := processor.BackgroundOptions{
+			Mode:      mode,
+			Tolerance: opts.RemoveBackgroundTolerance,
+			Feather:   opts.RemoveBackgroundFeather,
+		}
+		if opts.RemoveBackgroundColor != "" {
+			c, err := processor.ParseColor(opts.RemoveBackgroundColor)
+			if err != nil {
+				return nil, err
+			}
+			bgOpts.Color = &c
+		}
+		switch chain.Format {
+		case "":
+			chain.Format = "png"
+		case "jpeg":
+			return nil, fmt.Errorf("jpeg cannot hold transparency; use another format with background removal")
+		}
+		chain.Steps = append(chain.Steps, processor.RemoveBackgroundStep{Options: bgOpts})
 	}
 
-	// Validate and map filter to processor.ResizeFilter
-	var filterType processor.ResizeFilter
-	switch filter {
-	case "lanczos":
-		filterType = processor.FilterLanczos
-	case "bilinear":
-		filterType = processor.FilterBiLinear
-	case "nearest":
-		filterType = processor.FilterNearestNeighbor
-	default:
-		return fmt.Errorf("invalid downscale filter: %s (valid options: lanczos, bilinear, nearest)", filter)
+	// Step 2: Auto-crop if enabled (removes whitespace before downscaling)
+	if opts.AutoCrop {
+		mode, err := processor.ParseCropMode(opts.AutoCropMode)
+		if err != nil {
+			return nil, err
+		}
+		cropOpts := processor.CropOptions{
+			Mode:                mode,
+			Threshold:           opts.AutoCropThreshold,
+			Tolerance:           opts.AutoCropTolerance,
+			Padding:             opts.AutoCropPadding,
+			PaddingPercent:      opts.AutoCropPaddingPercent,
+			PreserveAspectRatio: opts.AutoCropPreserveAspect,
+		}
+		if opts.AutoCropColor != "" {
+			c, err := processor.ParseColor(opts.AutoCropColor)
+			if err != nil {
+				return nil, err
+			}
+			cropOpts.Color = &c
+			if mode == processor.CropWhite {
+				cropOpts.Mode = processor.CropColor
+			}
+		}
+		chain.Steps = append(chain.Steps, processor.CropStep{Options: cropOpts})
 	}
 
-	quality := opts.JPEGQuality
-	if quality == 0 {
-		quality = 90 // default JPEG quality
+	// Step 3: Pad the canvas if options are set
+	if opts.PadWidth > 0 || opts.PadHeight > 0 || opts.PadAspect != "" || opts.PadMargin > 0 {
+		padOpts := processor.PadOptions{
+			Width:  opts.PadWidth,
+			Height: opts.PadHeight,
+			Margin: opts.PadMargin,
+		}
+		var err error
+		if opts.PadAspect != "" {
+			if padOpts.Aspect, err = processor.ParseAspectRatio(opts.PadAspect); err != nil {
+				return nil, err
+			}
+		}
+		if padOpts.Anchor, err = processor.ParseAnchor(opts.PadAnchor); err != nil {
+			return nil, err
+		}
+		if padOpts.Fill, err = processor.ParsePadFill(opts.PadFill); err != nil {
+			return nil, err
+		}
+		if opts.PadBackground != "" {
+			bg, err := processor.ParseColor(opts.PadBackground)
+			if err != nil {
+				return nil, err
+			}
+			padOpts.Background = bg
+		}
+		chain.Steps = append(chain.Steps, processor.PadStep{Options: padOpts})
 	}
 
-	if c.config.Verbose {
-		if opts.DownscalePercentage > 0 {
-			fmt.Printf("Downscaling image: %s (scale: %.0f%%, filter: %s)\n",
-				imagePath, opts.DownscalePercentage, filter)
-		} else {
-			fmt.Printf("Downscaling image: %s (target: %dx%d, filter: %s)\n",
-				imagePath, opts.DownscaleWidth, opts.DownscaleHeight, filter)
+	// Step 4: Downscale if options are set
+	if opts.DownscaleWidth > 0 || opts.DownscaleHeight > 0 || opts.DownscalePercentage > 0 {
+		filter := "lanczos" // default
+		if opts.DownscaleFilter != "" {
+			filter = strings.ToLower(opts.DownscaleFilter)
 		}
-	}
 
-	// Build downscale options
-	downscaleOpts := processor.DownscaleOptions{
-		Width:       opts.DownscaleWidth,
-		Height:      opts.DownscaleHeight,
-		Percentage:  opts.DownscalePercentage,
-		Filter:      filterType,
-		JPEGQuality: quality,
-	}
+		var filterType processor.ResizeFilter
+		switch filter {
+		case "lanczos":
+			filterType = processor.FilterLanczos
+		case "bilinear":
+			filterType = processor.FilterBiLinear
+		case "nearest":
+			filterType = processor.FilterNearestNeighbor
+		default:
+			return nil, fmt.Errorf("invalid downscale filter: %s (valid options: lanczos, bilinear, nearest)", filter)
+		}
 
-	// Apply downscaling in-place (replaces the original file)
-	if err := processor.DownscaleInPlace(imagePath, downscaleOpts); err != nil {
-		return fmt.Errorf("downscale operation failed: %w", err)
+		chain.Steps = append(chain.Steps, processor.DownscaleStep{Options: processor.DownscaleOptions{
+			Width:      opts.DownscaleWidth,
+			Height:     opts.DownscaleHeight,
+			Percentage: opts.DownscalePercentage,
+			Filter:     filterType,
+			Linear:     opts.DownscaleLinear,
+		}})
 	}
 
-	return nil
+	return chain, nil
 }
 
 // ServerStatus represents the status of the SwarmUI server
@@ -1619,6 +1774,9 @@ type ActiveGeneration struct {
 	SessionID string    `json:"session_id"`
 	Status    string    `json:"status"`
 	Progress  float64   `json:"progress"`
+	Phase     string    `json:"phase,omitempty"`
+	Step      int       `json:"step,omitempty"`
+	Steps     int       `json:"steps,omitempty"`
 	StartTime time.Time `json:"start_time"`
 	Duration  string    `json:"duration"`
 }
@@ -1647,6 +1805,9 @@ func (c *AssetClient) GetActiveGenerations() []ActiveGeneration {
 				SessionID: session.ID,
 				Status:    session.Status,
 				Progress:  session.Progress,
+				Phase:     session.Phase,
+				Step:      session.Step,
+				Steps:     session.Steps,
 				StartTime: session.StartTime,
 				Duration:  formatDuration(duration),
 			})
@@ -2,8 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -144,6 +147,8 @@ func TestGenerateImage(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
+	// Keep the persisted session state out of the source tree
+	client.stateFilePath = filepath.Join(t.TempDir(), stateFileName)
 
 	req := &GenerationRequest{
 		Prompt: "test prompt",
@@ -212,6 +217,8 @@ func TestGenerateImageBatch(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
+	// Keep the persisted session state out of the source tree
+	client.stateFilePath = filepath.Join(t.TempDir(), stateFileName)
 
 	req := &GenerationRequest{
 		Prompt: "test prompt",
@@ -269,6 +276,8 @@ func TestGenerateImageWithContext(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
+	// Keep the persisted session state out of the source tree
+	client.stateFilePath = filepath.Join(t.TempDir(), stateFileName)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
@@ -285,3 +294,64 @@ func TestGenerateImageWithContext(t *testing.T) {
 		t.Error("Expected error due to cancelled context, got nil")
 	}
 }
+
+// readStateSessions returns the sessions saved in a state file, by key
+func readStateSessions(t *testing.T, path string) map[string]*PersistedSession {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read state file: %v", err)
+	}
+	var state persistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to parse state file: %v", err)
+	}
+	return state.Sessions
+}
+
+func TestStateFileSharedByClients(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), stateFileName)
+	newClient := func(url string) *AssetClient {
+		client, err := NewAssetClient(&Config{BaseURL: url})
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		client.stateFilePath = statePath
+		return client
+	}
+	gpu1, gpu2 := newClient("http://gpu1:7801"), newClient("http://gpu2:7801")
+
+	// Concurrent generations of one client share its server session but are
+	// tracked separately
+	first, _ := gpu1.trackGeneration("session-1", "generating")
+	second, _ := gpu1.trackGeneration("session-1", "generating")
+	if first == second {
+		t.Fatalf("generations on one session got the same key %q", first)
+	}
+	other, _ := gpu2.trackGeneration("session-2", "generating")
+
+	// Each client keeps the sessions the other saved
+	sessions := readStateSessions(t, statePath)
+	if len(sessions) != 3 {
+		t.Fatalf("state file holds %d sessions, expected 3: %v", len(sessions), sessions)
+	}
+	if got := sessions[other]; got == nil || got.Server != "http://gpu2:7801" || got.ID != "session-2" {
+		t.Errorf("session %s = %+v, expected session-2 on gpu2", other, got)
+	}
+
+	// Finishing one generation leaves the other on the same session
+	gpu1.removeSessionState(first)
+	sessions = readStateSessions(t, statePath)
+	if _, ok := sessions[first]; ok {
+		t.Errorf("finished generation %s is still in the state file", first)
+	}
+	if sessions[second] == nil || sessions[other] == nil {
+		t.Errorf("state file lost running generations: %v", sessions)
+	}
+
+	gpu2.removeSessionState(other)
+	gpu1.removeSessionState(second)
+	if sessions = readStateSessions(t, statePath); len(sessions) != 0 {
+		t.Errorf("state file holds %d sessions after all finished", len(sessions))
+	}
+}
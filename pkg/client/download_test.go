@@ -1,14 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/opd-ai/asset-generator/pkg/processor"
 )
 
 func TestDownloadImages(t *testing.T) {
@@ -96,7 +101,7 @@ func TestDownloadImages(t *testing.T) {
 	}
 }
 
-func TestDownloadFile(t *testing.T) {
+func TestFetchFile(t *testing.T) {
 	// Create a test HTTP server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -118,11 +123,20 @@ func TestDownloadFile(t *testing.T) {
 
 	// Test download
 	ctx := context.Background()
-	err = client.downloadFile(ctx, server.URL+"/test", outputPath)
+	data, err := client.fetchFile(ctx, server.URL+"/test")
 	if err != nil {
 		t.Fatalf("Failed to download file: %v", err)
 	}
 
+	// Without postprocessing, data that is not an image is written as is
+	chain, err := postprocessChain(&DownloadOptions{})
+	if err != nil {
+		t.Fatalf("Failed to build chain: %v", err)
+	}
+	if err := chain.WriteFile(data, outputPath); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
 	// Verify file exists and has correct content
 	content, err := os.ReadFile(outputPath)
 	if err != nil {
@@ -134,6 +148,95 @@ func TestDownloadFile(t *testing.T) {
 	}
 }
 
+func TestDownloadImagesFlattensJPEG(t *testing.T) {
+	// A fully transparent image would turn black without flattening
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	client, err := NewAssetClient(&Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		template string
+	}{
+		{name: "format", format: "jpg"},
+		{name: "filename template", template: "sprite-{index}.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &DownloadOptions{
+				OutputDir:        t.TempDir(),
+				Format:           tt.format,
+				FilenameTemplate: tt.template,
+			}
+			saved, err := client.DownloadImagesWithOptions(context.Background(), []string{"View/local/raw/a.png"}, opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(saved) != 1 || filepath.Ext(saved[0]) != ".jpg" {
+				t.Fatalf("saved %v, expected one .jpg file", saved)
+			}
+
+			f, err := os.Open(saved[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			img, _, err := image.Decode(f)
+			if err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if r, g, b, _ := img.At(4, 4).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+				t.Errorf("transparent pixel became (%d, %d, %d), expected white", r>>8, g>>8, b>>8)
+			}
+		})
+	}
+}
+
+func TestDownloadImagesKeepsRawOnPostprocessingFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not-an-image"))
+	}))
+	defer server.Close()
+
+	client, err := NewAssetClient(&Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	opts := &DownloadOptions{OutputDir: tmpDir, DownscaleWidth: 32, Format: "webp"}
+	saved, err := client.DownloadImagesWithOptions(context.Background(), []string{"View/local/raw/a.png"}, opts)
+	if err == nil || len(saved) != 0 {
+		t.Fatalf("DownloadImagesWithOptions() = %v, %v; expected a processing error", saved, err)
+	}
+	if !strings.Contains(err.Error(), "kept the raw download") {
+		t.Errorf("error %q does not mention the raw download", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "a.png"))
+	if err != nil {
+		t.Fatalf("raw download was not kept: %v", err)
+	}
+	if string(content) != "not-an-image" {
+		t.Errorf("raw download = %q, expected the downloaded data", content)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "a.webp")); !os.IsNotExist(err) {
+		t.Errorf("expected no postprocessed file, got err = %v", err)
+	}
+}
+
 func TestEnsureDir(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -545,3 +648,100 @@ func TestSanitizeForFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadImagesCallbacks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	client, err := NewAssetClient(&Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		downscaleWidth   int
+		format           string
+		removeBackground bool
+		padAspect        string
+		wantExt          string
+		wantOperations   []string
+	}{
+		{name: "no postprocessing", downscaleWidth: 0, wantExt: ".png", wantOperations: nil},
+		{name: "downscale", downscaleWidth: 32, wantExt: ".png", wantOperations: []string{"downscale"}},
+		{name: "convert", format: "webp", wantExt: ".webp", wantOperations: []string{"convert"}},
+		{name: "downscale and convert", downscaleWidth: 32, format: "jpg", wantExt: ".jpg", wantOperations: []string{"downscale", "flatten", "convert"}},
+		{name: "pad and downscale", padAspect: "2:1", downscaleWidth: 32, wantExt: ".png", wantOperations: []string{"pad", "downscale"}},
+		{name: "remove background", removeBackground: true, wantExt: ".png", wantOperations: []string{"remove_background"}},
+		{name: "remove background and convert", removeBackground: true, format: "webp", wantExt: ".webp", wantOperations: []string{"remove_background", "convert"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var downloaded []string
+			var operations []string
+			postprocessed := 0
+			opts := &DownloadOptions{
+				OutputDir:          t.TempDir(),
+				DownscaleWidth:     tt.downscaleWidth,
+				Format:             tt.format,
+				RemoveBackground:   tt.removeBackground,
+				PadAspect:          tt.padAspect,
+				DownloadedCallback: func(path string) { downloaded = append(downloaded, path) },
+				PostprocessedCallback: func(path string, ops []string) {
+					postprocessed++
+					operations = ops
+				},
+			}
+
+			saved, err := client.DownloadImagesWithOptions(context.Background(), []string{"View/local/raw/a.png", "View/local/raw/b.png"}, opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(downloaded) != 2 || downloaded[0] != saved[0] || downloaded[1] != saved[1] {
+				t.Errorf("DownloadedCallback paths = %v, expected %v", downloaded, saved)
+			}
+			for _, path := range saved {
+				if filepath.Ext(path) != tt.wantExt {
+					t.Errorf("saved %s, expected a %s file", path, tt.wantExt)
+				}
+				if got := detectFormat(t, path); processor.FormatFromPath(path) != got {
+					t.Errorf("%s holds a %s image", path, got)
+				}
+			}
+
+			wantCalls := 0
+			if tt.wantOperations != nil {
+				wantCalls = 2
+			}
+			if postprocessed != wantCalls {
+				t.Errorf("PostprocessedCallback called %d times, expected %d", postprocessed, wantCalls)
+			}
+			if fmt.Sprint(operations) != fmt.Sprint(tt.wantOperations) {
+				t.Errorf("operations = %v, expected %v", operations, tt.wantOperations)
+			}
+		})
+	}
+}
+
+// detectFormat returns the format of an image file
+func detectFormat(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	_, format, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return format
+}
@@ -0,0 +1,371 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// healthCheckTimeout bounds a single server health check
+	healthCheckTimeout = 10 * time.Second
+	// healthRecheckInterval is how often an offline server is checked again
+	healthRecheckInterval = 30 * time.Second
+)
+
+// ErrNoHealthyServers is returned when no server in a pool can take work
+var ErrNoHealthyServers = errors.New("no healthy servers available")
+
+// ServerConfig describes one generation server of a pool
+type ServerConfig struct {
+	URL         string `json:"url" yaml:"url"`
+	Weight      int    `json:"weight" yaml:"weight"`           // Relative share of preferred keys (default 1)
+	Concurrency int    `json:"concurrency" yaml:"concurrency"` // Maximum simultaneous generations (default 1)
+}
+
+// ParseServerSpec parses a server given on the command line as
+// "URL[,weight=N][,concurrency=N]"
+func ParseServerSpec(spec string) (ServerConfig, error) {
+	parts := strings.Split(spec, ",")
+	server := ServerConfig{URL: strings.TrimSpace(parts[0])}
+	if server.URL == "" {
+		return server, fmt.Errorf("server URL is required")
+	}
+
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return server, fmt.Errorf("invalid server option '%s' (expected key=value)", part)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return server, fmt.Errorf("invalid value for %s: '%s'", key, value)
+		}
+		switch key {
+		case "weight":
+			server.Weight = n
+		case "concurrency":
+			server.Concurrency = n
+		default:
+			return server, fmt.Errorf("unknown server option '%s' (expected weight or concurrency)", key)
+		}
+	}
+
+	return server, nil
+}
+
+// ServerPool distributes generations across several servers. Each unit of
+// work has a key; keys are mapped to servers by weighted rendezvous hashing,
+// so the same key prefers the same server across runs. When its preferred
+// servers are busy, work goes to the next server with a free slot, and work
+// on a server that goes offline is retried on another one. Weights only set
+// preference: once every server is busy, work goes to whichever slot frees
+// up first, so under load each server's share follows its concurrency and
+// speed.
+type ServerPool struct {
+	// OnFailover, if set, is called when work is moved off a server that
+	// went offline
+	OnFailover func(server string, err error)
+
+	servers  []*poolServer
+	mu       sync.Mutex
+	released chan struct{} // Closed and replaced whenever a slot frees up or a server comes back
+}
+
+// poolServer is a server of a pool and its runtime state
+type poolServer struct {
+	config    ServerConfig
+	client    *AssetClient
+	active    int
+	healthy   bool
+	checking  bool
+	lastCheck time.Time
+	completed int
+	failed    int
+}
+
+// ServerState is a snapshot of a pool server for reporting
+type ServerState struct {
+	ServerConfig
+	Healthy   bool
+	Active    int
+	Completed int
+	Failed    int
+}
+
+// NewServerPool creates a pool from config.Servers, or a pool with the
+// single server config.BaseURL if no servers are listed. Servers are
+// considered healthy until a check says otherwise.
+func NewServerPool(config *Config) (*ServerPool, error) {
+	servers := config.Servers
+	if len(servers) == 0 {
+		servers = []ServerConfig{{URL: config.BaseURL}}
+	}
+
+	pool := &ServerPool{released: make(chan struct{})}
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		server.URL = strings.TrimRight(server.URL, "/")
+		if seen[server.URL] {
+			return nil, fmt.Errorf("server %s is listed more than once", server.URL)
+		}
+		seen[server.URL] = true
+
+		if server.Weight <= 0 {
+			server.Weight = 1
+		}
+		if server.Concurrency <= 0 {
+			server.Concurrency = 1
+		}
+
+		serverConfig := *config
+		serverConfig.BaseURL = server.URL
+		serverConfig.Servers = nil
+		client, err := NewAssetClient(&serverConfig)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.URL, err)
+		}
+
+		pool.servers = append(pool.servers, &poolServer{config: server, client: client, healthy: true})
+	}
+
+	return pool, nil
+}
+
+// Capacity returns the total number of simultaneous generations of all servers
+func (p *ServerPool) Capacity() int {
+	capacity := 0
+	for _, s := range p.servers {
+		capacity += s.config.Concurrency
+	}
+	return capacity
+}
+
+// Clients returns the client of every server in configuration order
+func (p *ServerPool) Clients() []*AssetClient {
+	clients := make([]*AssetClient, len(p.servers))
+	for i, s := range p.servers {
+		clients[i] = s.client
+	}
+	return clients
+}
+
+// Len returns the number of servers in the pool
+func (p *ServerPool) Len() int {
+	return len(p.servers)
+}
+
+// CheckHealth checks every server concurrently and returns the number of
+// healthy servers. An error is returned if none is healthy.
+func (p *ServerPool) CheckHealth(ctx context.Context) (int, error) {
+	var wg sync.WaitGroup
+	for _, s := range p.servers {
+		wg.Add(1)
+		go func(s *poolServer) {
+			defer wg.Done()
+			p.check(ctx, s)
+		}(s)
+	}
+	wg.Wait()
+
+	healthy := 0
+	for _, state := range p.States() {
+		if state.Healthy {
+			healthy++
+		}
+	}
+	if healthy == 0 {
+		return 0, ErrNoHealthyServers
+	}
+	return healthy, nil
+}
+
+// check tests whether a server responds and records the result
+func (p *ServerPool) check(ctx context.Context, s *poolServer) bool {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	_, err := s.client.GetNewSession(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s.checking = false
+	s.lastCheck = time.Now()
+	wasHealthy := s.healthy
+	s.healthy = err == nil
+	if s.healthy != wasHealthy {
+		p.broadcast()
+	}
+	return s.healthy
+}
+
+// States returns a snapshot of every server in configuration order
+func (p *ServerPool) States() []ServerState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := make([]ServerState, len(p.servers))
+	for i, s := range p.servers {
+		states[i] = ServerState{
+			ServerConfig: s.config,
+			Healthy:      s.healthy,
+			Active:       s.active,
+			Completed:    s.completed,
+			Failed:       s.failed,
+		}
+	}
+	return states
+}
+
+// Do runs fn with the client of a server chosen for key. If fn fails and the
+// server no longer responds, the server is marked offline and fn is retried
+// on another server. Errors from servers that are still healthy, such as a
+// rejected prompt, are returned as is.
+func (p *ServerPool) Do(ctx context.Context, key string, fn func(*AssetClient) error) error {
+	tried := make(map[*poolServer]bool)
+	var lastErr error
+
+	for {
+		s, err := p.acquire(ctx, key, tried)
+		if err != nil {
+			if lastErr != nil && errors.Is(err, ErrNoHealthyServers) {
+				return fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return err
+		}
+
+		err = fn(s.client)
+		p.release(s, err == nil)
+		if err == nil {
+			return nil
+		}
+		// With a single server there is nowhere to fail over to
+		if len(p.servers) == 1 || ctx.Err() != nil || p.check(ctx, s) {
+			return err
+		}
+
+		// The server went offline: fail over to the next one
+		tried[s] = true
+		lastErr = fmt.Errorf("%s: %w", s.config.URL, err)
+		if p.OnFailover != nil {
+			p.OnFailover(s.config.URL, err)
+		}
+	}
+}
+
+// acquire reserves a slot on the best available server for key, waiting for
+// a slot if all suitable servers are busy
+func (p *ServerPool) acquire(ctx context.Context, key string, tried map[*poolServer]bool) (*poolServer, error) {
+	order := p.rank(key)
+
+	p.mu.Lock()
+	for {
+		waiting := false
+		for _, s := range order {
+			if tried[s] {
+				continue
+			}
+			if s.healthy {
+				if s.active < s.config.Concurrency {
+					s.active++
+					p.mu.Unlock()
+					return s, nil
+				}
+				waiting = true
+				continue
+			}
+
+			// Check offline servers again now and then so they can rejoin
+			if s.checking {
+				waiting = true
+			} else if time.Since(s.lastCheck) >= healthRecheckInterval {
+				s.checking = true
+				waiting = true
+				go p.check(context.Background(), s)
+			}
+		}
+		if !waiting {
+			p.mu.Unlock()
+			return nil, ErrNoHealthyServers
+		}
+
+		released := p.released
+		p.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		case <-time.After(healthRecheckInterval):
+		}
+		p.mu.Lock()
+	}
+}
+
+// release frees the slot of a server and wakes up waiting work
+func (p *ServerPool) release(s *poolServer, succeeded bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s.active--
+	if succeeded {
+		s.completed++
+	} else {
+		s.failed++
+	}
+	p.broadcast()
+}
+
+// broadcast wakes up everything waiting in acquire; p.mu must be held
+func (p *ServerPool) broadcast() {
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// rank orders the servers by preference for key using weighted rendezvous
+// hashing: each server scores -weight/ln(h) for a uniform hash h of key and
+// server URL, which assigns keys in proportion to weights and only moves the
+// keys of a server that is added or removed.
+func (p *ServerPool) rank(key string) []*poolServer {
+	type scored struct {
+		server *poolServer
+		score  float64
+	}
+
+	scores := make([]scored, len(p.servers))
+	for i, s := range p.servers {
+		h := fnv.New64a()
+		h.Write([]byte(s.config.URL))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		// Map the hash to (0, 1)
+		u := (float64(mix64(h.Sum64())>>11) + 0.5) / float64(1<<53)
+		scores[i] = scored{s, -float64(s.config.Weight) / math.Log(u)}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	order := make([]*poolServer, len(scores))
+	for i, s := range scores {
+		order[i] = s.server
+	}
+	return order
+}
+
+// mix64 spreads every input bit over the whole hash (the splitmix64
+// finalizer). FNV alone leaves the high bits depending mostly on the start
+// of the input, so keys and URLs that only differ at the end, such as
+// asset_1 and asset_2 or two ports on one host, would rank alike.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
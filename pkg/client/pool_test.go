@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseServerSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ServerConfig
+		wantErr bool
+	}{
+		{"http://gpu1:7801", ServerConfig{URL: "http://gpu1:7801"}, false},
+		{"http://gpu1:7801,weight=3", ServerConfig{URL: "http://gpu1:7801", Weight: 3}, false},
+		{"http://gpu1:7801, weight=2, concurrency=4", ServerConfig{URL: "http://gpu1:7801", Weight: 2, Concurrency: 4}, false},
+		{"", ServerConfig{}, true},
+		{"http://gpu1:7801,weight", ServerConfig{}, true},
+		{"http://gpu1:7801,weight=-1", ServerConfig{}, true},
+		{"http://gpu1:7801,speed=2", ServerConfig{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseServerSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseServerSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseServerSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestPoolServer starts a server that answers session requests
+func newTestPoolServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"session_id": "test-session"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServerPoolRank(t *testing.T) {
+	pool, err := NewServerPool(&Config{Servers: []ServerConfig{
+		{URL: "http://gpu1:7801", Weight: 1},
+		{URL: "http://gpu2:7801/", Weight: 1},
+		{URL: "http://gpu3:7801", Weight: 2},
+	}})
+	if err != nil {
+		t.Fatalf("NewServerPool() error = %v", err)
+	}
+
+	if pool.servers[1].config.URL != "http://gpu2:7801" {
+		t.Errorf("trailing slash not trimmed: %s", pool.servers[1].config.URL)
+	}
+	if pool.Capacity() != 3 {
+		t.Errorf("Capacity() = %d, expected 3 with default concurrency", pool.Capacity())
+	}
+
+	// The same key always prefers the same server
+	first := pool.rank("characters/hero_01")[0]
+	for i := 0; i < 10; i++ {
+		if got := pool.rank("characters/hero_01")[0]; got != first {
+			t.Fatalf("rank is not deterministic: %s then %s", first.config.URL, got.config.URL)
+		}
+	}
+
+	// Keys are shared out in proportion to weights
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		counts[pool.rank(fmt.Sprintf("asset_%d", i))[0].config.URL]++
+	}
+	if n := counts["http://gpu3:7801"]; n < 1700 || n > 2300 {
+		t.Errorf("server with weight 2 got %d of 4000 keys, expected about 2000", n)
+	}
+	if n := counts["http://gpu1:7801"]; n < 700 || n > 1300 {
+		t.Errorf("server with weight 1 got %d of 4000 keys, expected about 1000", n)
+	}
+}
+
+func TestServerPoolDistributionUnderLoad(t *testing.T) {
+	heavy := newTestPoolServer(t)
+	light := newTestPoolServer(t)
+
+	pool, err := NewServerPool(&Config{Servers: []ServerConfig{
+		{URL: heavy.URL, Weight: 9},
+		{URL: light.URL, Weight: 1, Concurrency: 2},
+	}})
+	if err != nil {
+		t.Fatalf("NewServerPool() error = %v", err)
+	}
+
+	// One at a time, keys go to their preferred server by weight
+	for i := 0; i < 200; i++ {
+		if err := pool.Do(context.Background(), fmt.Sprintf("asset_%d", i), func(c *AssetClient) error { return nil }); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+	states := pool.States()
+	if n := states[0].Completed; n < 160 {
+		t.Errorf("server with weight 9 completed %d of 200 sequential keys, expected about 180", n)
+	}
+
+	// Under load every slot stays busy, so shares follow concurrency
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pool.Do(context.Background(), fmt.Sprintf("load_%d", i), func(c *AssetClient) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	after := pool.States()
+	heavyN, lightN := after[0].Completed-states[0].Completed, after[1].Completed-states[1].Completed
+	if heavyN+lightN != 60 {
+		t.Fatalf("completed %d+%d under load, expected 60", heavyN, lightN)
+	}
+	// The light server has two of three slots, so it takes about 40 despite
+	// its weight
+	if lightN < 30 {
+		t.Errorf("server with concurrency 2 completed %d of 60 under load, expected about 40", lightN)
+	}
+	if heavyN < 10 {
+		t.Errorf("server with weight 9 completed %d of 60 under load, expected about 20", heavyN)
+	}
+}
+
+func TestServerPoolDuplicateServer(t *testing.T) {
+	_, err := NewServerPool(&Config{Servers: []ServerConfig{
+		{URL: "http://gpu1:7801"},
+		{URL: "http://gpu1:7801/"},
+	}})
+	if err == nil {
+		t.Error("expected an error for a server listed twice")
+	}
+}
+
+func TestServerPoolFailover(t *testing.T) {
+	online := newTestPoolServer(t)
+	offline := newTestPoolServer(t)
+
+	pool, err := NewServerPool(&Config{Servers: []ServerConfig{{URL: online.URL}, {URL: offline.URL}}})
+	if err != nil {
+		t.Fatalf("NewServerPool() error = %v", err)
+	}
+	if healthy, err := pool.CheckHealth(context.Background()); err != nil || healthy != 2 {
+		t.Fatalf("CheckHealth() = %d, %v; expected 2 healthy servers", healthy, err)
+	}
+
+	// Find a key that prefers the server about to go offline
+	key := ""
+	for i := 0; key == ""; i++ {
+		candidate := fmt.Sprintf("asset_%d", i)
+		if pool.rank(candidate)[0].config.URL == offline.URL {
+			key = candidate
+		}
+	}
+	offline.Close()
+
+	var failedOver string
+	pool.OnFailover = func(server string, err error) { failedOver = server }
+
+	var used []string
+	err = pool.Do(context.Background(), key, func(c *AssetClient) error {
+		used = append(used, c.config.BaseURL)
+		if c.config.BaseURL == offline.URL {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v, expected failover to succeed", err)
+	}
+	if len(used) != 2 || used[0] != offline.URL || used[1] != online.URL {
+		t.Errorf("servers used = %v, expected the offline server then the online one", used)
+	}
+	if failedOver != offline.URL {
+		t.Errorf("OnFailover called with %q, expected %q", failedOver, offline.URL)
+	}
+
+	states := pool.States()
+	if !states[0].Healthy || states[1].Healthy {
+		t.Errorf("health after failover = %v/%v, expected online/offline", states[0].Healthy, states[1].Healthy)
+	}
+
+	// Errors from a healthy server are returned without retrying
+	calls := 0
+	err = pool.Do(context.Background(), key, func(c *AssetClient) error {
+		calls++
+		return errors.New("invalid prompt")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, expected the error after 1 call", err, calls)
+	}
+}
+
+func TestServerPoolConcurrency(t *testing.T) {
+	server := newTestPoolServer(t)
+
+	pool, err := NewServerPool(&Config{Servers: []ServerConfig{{URL: server.URL, Concurrency: 2}}})
+	if err != nil {
+		t.Fatalf("NewServerPool() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pool.Do(context.Background(), fmt.Sprintf("asset_%d", i), func(c *AssetClient) error {
+				mu.Lock()
+				active++
+				peak = max(peak, active)
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, expected 2", peak)
+	}
+	if completed := pool.States()[0].Completed; completed != 6 {
+		t.Errorf("completed = %d, expected 6", completed)
+	}
+}
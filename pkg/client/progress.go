@@ -0,0 +1,343 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Generation phases reported while a generation runs
+const (
+	PhaseQueued       = "queued"
+	PhaseLoadingModel = "loading_model"
+	PhaseGenerating   = "generating"
+)
+
+// progressPollInterval is how often HTTP generations poll the server for progress
+var progressPollInterval = time.Second
+
+// errProgressUnsupported is returned when the server has no status API
+var errProgressUnsupported = errors.New("server does not report progress")
+
+// ProgressUpdate is a progress report from the server during a generation
+type ProgressUpdate struct {
+	Progress   float64 // Overall progress from 0 to 1
+	Step       int     // Current sampling step, 0 if unknown
+	Steps      int     // Total sampling steps, 0 if unknown
+	Phase      string  // PhaseQueued, PhaseLoadingModel or PhaseGenerating
+	BatchIndex int     // Image of the batch the step and preview belong to
+	Preview    string  // Preview image as a data URL, if the server sent one
+}
+
+// DecodePreview decodes the preview data URL, returning the image bytes and
+// the file extension for its type (e.g. ".jpg")
+func (u ProgressUpdate) DecodePreview() ([]byte, string, error) {
+	header, payload, ok := strings.Cut(u.Preview, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return nil, "", fmt.Errorf("preview is not a base64 data URL")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode preview: %w", err)
+	}
+
+	ext := ".img"
+	switch strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64") {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	case "image/webp":
+		ext = ".webp"
+	case "image/gif":
+		ext = ".gif"
+	}
+	return data, ext, nil
+}
+
+// Status formats the update for a ProgressCallback
+func (u ProgressUpdate) Status() string {
+	switch u.Phase {
+	case PhaseQueued:
+		return "Queued..."
+	case PhaseLoadingModel:
+		return "Loading model..."
+	}
+	if u.Steps > 0 {
+		return fmt.Sprintf("Generating... (step %d/%d)", u.Step, u.Steps)
+	}
+	return "Generating..."
+}
+
+// startProgress polls the server for the progress of an HTTP generation on
+// the session sessionID, updating the generation tracked under key, the
+// state file and the request's callbacks. When the
+// server reports only the phase, the percentage is estimated; when it has no
+// status API at all, progress is simulated if a callback wants it.
+// The returned function stops polling and waits for the poller to exit, so
+// no progress is reported after it returns.
+func (c *AssetClient) startProgress(ctx context.Context, key, sessionID string, req *GenerationRequest) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.pollProgress(ctx, key, sessionID, req)
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// pollProgress reports the server's progress of the generation tracked
+// under key until ctx is cancelled
+func (c *AssetClient) pollProgress(ctx context.Context, key, sessionID string, req *GenerationRequest) {
+	ticker := time.NewTicker(progressPollInterval)
+	defer ticker.Stop()
+
+	steps := requestSteps(req)
+
+	var last ProgressUpdate
+	var generatingSince time.Time // When the counts first showed the generation running
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		update, measured, err := c.getCurrentStatus(ctx, sessionID, steps)
+		if errors.Is(err, errProgressUnsupported) {
+			if c.config.Verbose {
+				fmt.Printf("Server does not report progress, simulating it\n")
+			}
+			if req.ProgressCallback != nil {
+				c.simulateProgress(key, req.ProgressCallback, ctx.Done())
+			}
+			return
+		}
+		if err != nil {
+			// Polling is best effort; the generation itself decides success
+			if c.config.Verbose && ctx.Err() == nil {
+				fmt.Printf("Progress poll failed: %v\n", err)
+			}
+			continue
+		}
+
+		// SwarmUI's status API usually sends only the session counts, which
+		// give the phase but not the percentage; estimate that from the time
+		// spent generating, like simulateProgress
+		if !measured && update.Phase == PhaseGenerating {
+			if generatingSince.IsZero() {
+				generatingSince = time.Now()
+			}
+			update.Progress = simulatedProgress(time.Since(generatingSince))
+		}
+
+		if ctx.Err() == nil {
+			c.reportProgress(key, req, update, &last)
+		}
+	}
+}
+
+// reportProgress passes a progress update to the session, the state file and
+// the request's callbacks. last holds the previous update: fields the server
+// left out are kept from it, progress never moves backwards and unchanged
+// updates are not reported.
+func (c *AssetClient) reportProgress(key string, req *GenerationRequest, update ProgressUpdate, last *ProgressUpdate) {
+	if update.Phase == "" {
+		update.Phase = last.Phase
+	}
+	if update.Progress < last.Progress {
+		update.Progress = last.Progress
+	}
+	if update.Steps == 0 {
+		update.Step, update.Steps = last.Step, last.Steps
+	} else if update.Step < last.Step && update.Steps == last.Steps && update.BatchIndex == last.BatchIndex {
+		update.Step = last.Step
+	}
+	if update == *last {
+		return
+	}
+	*last = update
+
+	c.mu.Lock()
+	if session, exists := c.sessions[key]; exists {
+		session.Status = "generating"
+		session.Progress = update.Progress
+		session.Phase = update.Phase
+		session.Step = update.Step
+		session.Steps = update.Steps
+	}
+	c.mu.Unlock()
+	c.saveStateToFile()
+
+	if req.ProgressUpdateCallback != nil {
+		req.ProgressUpdateCallback(update)
+	}
+	if req.ProgressCallback != nil {
+		req.ProgressCallback(update.Progress, update.Status())
+	}
+}
+
+// requestSteps returns the sampling steps of a request
+func requestSteps(req *GenerationRequest) int {
+	if s, ok := req.Parameters["steps"].(int); ok && s > 0 {
+		return s
+	}
+	return 20 // Server default when the request doesn't set steps
+}
+
+// getCurrentStatus queries SwarmUI's GetCurrentStatus API. The session counts
+// give the phase; when the server also sends gen_progress (as over the
+// WebSocket API) it gives the percentage, step and preview, and measured is
+// true. Responses with neither return errProgressUnsupported.
+func (c *AssetClient) getCurrentStatus(ctx context.Context, sessionID string, steps int) (update ProgressUpdate, measured bool, err error) {
+	endpoint := fmt.Sprintf("%s/API/GetCurrentStatus", c.config.BaseURL)
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{"session_id": sessionID})
+	if err != nil {
+		return ProgressUpdate{}, false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return ProgressUpdate{}, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return ProgressUpdate{}, false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return ProgressUpdate{}, false, errProgressUnsupported
+	}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ProgressUpdate{}, false, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ProgressUpdate{}, false, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		progressMessage
+		Error   string `json:"error,omitempty"`
+		ErrorID string `json:"error_id,omitempty"`
+	}
+	if err := json.Unmarshal(bodyBytes, &apiResp); err != nil {
+		return ProgressUpdate{}, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if apiResp.Error != "" {
+		return ProgressUpdate{}, false, fmt.Errorf("SwarmUI error: %s", apiResp.Error)
+	}
+
+	update, ok := apiResp.update(steps)
+	if !ok {
+		return ProgressUpdate{}, false, errProgressUnsupported
+	}
+	return update, apiResp.hasProgress(), nil
+}
+
+// progressMessage holds the progress fields SwarmUI sends in GetCurrentStatus
+// responses and WebSocket messages
+type progressMessage struct {
+	// Status is either the session's counts, as an object, or a status text
+	Status      json.RawMessage `json:"status,omitempty"`
+	GenProgress *struct {
+		BatchIndex     flexInt `json:"batch_index"`
+		OverallPercent float64 `json:"overall_percent"`
+		CurrentPercent float64 `json:"current_percent"`
+		Preview        string  `json:"preview"`
+	} `json:"gen_progress,omitempty"`
+	Progress *float64 `json:"progress,omitempty"` // Plain progress from 0 to 1
+}
+
+// hasProgress reports whether the message carries a percentage, rather than
+// only the session counts
+func (m progressMessage) hasProgress() bool {
+	return m.Progress != nil || m.GenProgress != nil
+}
+
+// update converts the message to a ProgressUpdate; ok is false if the
+// message carries no progress
+func (m progressMessage) update(steps int) (update ProgressUpdate, ok bool) {
+	var counts struct {
+		WaitingGens     int `json:"waiting_gens"`
+		LoadingModels   int `json:"loading_models"`
+		WaitingBackends int `json:"waiting_backends"`
+		LiveGens        int `json:"live_gens"`
+	}
+	if len(m.Status) > 0 && json.Unmarshal(m.Status, &counts) == nil {
+		ok = true
+		switch {
+		case counts.LoadingModels > 0:
+			update.Phase = PhaseLoadingModel
+		case counts.LiveGens > 0:
+			update.Phase = PhaseGenerating
+		case counts.WaitingGens > 0 || counts.WaitingBackends > 0:
+			update.Phase = PhaseQueued
+		}
+	}
+
+	if m.Progress != nil {
+		ok = true
+		update.Phase = PhaseGenerating
+		update.Progress = math.Min(math.Max(*m.Progress, 0), 1)
+	}
+
+	if p := m.GenProgress; p != nil {
+		ok = true
+		update.Phase = PhaseGenerating
+		update.Progress = math.Max(p.OverallPercent, 0)
+		if update.Progress == 0 {
+			update.Progress = p.CurrentPercent
+		}
+		update.Progress = math.Min(update.Progress, 1)
+		update.Steps = steps
+		update.Step = int(math.Round(math.Min(p.CurrentPercent, 1) * float64(steps)))
+		update.BatchIndex = int(p.BatchIndex)
+		update.Preview = p.Preview
+	}
+
+	return update, ok
+}
+
+// flexInt is an integer SwarmUI may send as a number or a string
+type flexInt int
+
+// UnmarshalJSON accepts 3 or "3"
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*n = 0
+		return nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = flexInt(value)
+	return nil
+}
@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newProgressTestClient returns a client whose state file is in a temp directory
+func newProgressTestClient(t *testing.T, url string) *AssetClient {
+	c, err := NewAssetClient(&Config{BaseURL: url})
+	if err != nil {
+		t.Fatalf("NewAssetClient() error = %v", err)
+	}
+	c.stateFilePath = filepath.Join(t.TempDir(), stateFileName)
+	return c
+}
+
+func TestGenerateImagePollsProgress(t *testing.T) {
+	defer func(interval time.Duration) { progressPollInterval = interval }(progressPollInterval)
+	progressPollInterval = 5 * time.Millisecond
+
+	// Each poll returns the next status; generation finishes after the last one
+	statuses := []string{
+		`{"status": {"waiting_gens": 1, "loading_models": 0, "waiting_backends": 0, "live_gens": 0}}`,
+		`{"status": {"waiting_gens": 0, "loading_models": 1, "waiting_backends": 0, "live_gens": 0}}`,
+		`{"status": {"waiting_gens": 0, "loading_models": 0, "waiting_backends": 0, "live_gens": 1}, "gen_progress": {"overall_percent": 0.5, "current_percent": 0.5, "preview": "data:image/jpeg;base64,AAAA"}}`,
+		`{"status": {"live_gens": 1}, "gen_progress": {"overall_percent": 0.25, "current_percent": 0.25}}`,
+		`{"status": {"live_gens": 1}, "gen_progress": {"overall_percent": 0.9, "current_percent": 0.9}}`,
+	}
+	var mu sync.Mutex
+	polls := 0
+	finished := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/API/GetNewSession":
+			w.Write([]byte(`{"session_id": "progress-session"}`))
+		case "/API/GetCurrentStatus":
+			mu.Lock()
+			defer mu.Unlock()
+			if polls < len(statuses) {
+				w.Write([]byte(statuses[polls]))
+			} else {
+				w.Write([]byte(statuses[len(statuses)-1]))
+			}
+			polls++
+			if polls == len(statuses)+1 {
+				close(finished)
+			}
+		case "/API/GenerateText2Image":
+			<-finished
+			w.Write([]byte(`{"images": ["View/local/raw/image.png"]}`))
+		}
+	}))
+	defer server.Close()
+
+	c := newProgressTestClient(t, server.URL)
+
+	var updates []ProgressUpdate
+	var statusTexts []string
+	var session string
+	req := &GenerationRequest{
+		Prompt:          "test prompt",
+		Parameters:      map[string]interface{}{"steps": 30},
+		SessionCallback: func(sessionID string) { session = sessionID },
+		ProgressCallback: func(progress float64, status string) {
+			statusTexts = append(statusTexts, status)
+		},
+		ProgressUpdateCallback: func(update ProgressUpdate) {
+			updates = append(updates, update)
+		},
+	}
+
+	if _, err := c.GenerateImage(context.Background(), req); err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	if session != "progress-session" {
+		t.Errorf("SessionCallback got %q, expected the generation's session", session)
+	}
+
+	want := []ProgressUpdate{
+		{Phase: PhaseQueued},
+		{Phase: PhaseLoadingModel},
+		{Phase: PhaseGenerating, Progress: 0.5, Step: 15, Steps: 30, Preview: "data:image/jpeg;base64,AAAA"},
+		{Phase: PhaseGenerating, Progress: 0.5, Step: 15, Steps: 30}, // Never moves backwards
+		{Phase: PhaseGenerating, Progress: 0.9, Step: 27, Steps: 30},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("got %d updates, want %d: %+v", len(updates), len(want), updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+
+	wantTexts := []string{"Starting generation...", "Queued...", "Loading model...", "Generating... (step 15/30)"}
+	for i, text := range wantTexts {
+		if i >= len(statusTexts) || statusTexts[i] != text {
+			t.Errorf("status %d = %v, want %q", i, statusTexts, text)
+			break
+		}
+	}
+	if last := statusTexts[len(statusTexts)-1]; last != "Generation completed" {
+		t.Errorf("last status = %q, expected completion to be reported last", last)
+	}
+}
+
+func TestGenerateImageSimulatesProgressWithoutStatusAPI(t *testing.T) {
+	defer func(interval time.Duration) { progressPollInterval = interval }(progressPollInterval)
+	progressPollInterval = 5 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/API/GetNewSession":
+			w.Write([]byte(`{"session_id": "simulated-session"}`))
+		case "/API/GenerateText2Image":
+			time.Sleep(700 * time.Millisecond)
+			w.Write([]byte(`{"images": ["View/local/raw/image.png"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := newProgressTestClient(t, server.URL)
+
+	var progresses []float64
+	req := &GenerationRequest{
+		Prompt:     "test prompt",
+		Parameters: map[string]interface{}{},
+		ProgressCallback: func(progress float64, status string) {
+			progresses = append(progresses, progress)
+		},
+	}
+
+	if _, err := c.GenerateImage(context.Background(), req); err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	// Start, at least one simulated tick, then completion
+	if len(progresses) < 3 || progresses[1] <= 0 || progresses[1] >= 1 {
+		t.Errorf("progress = %v, expected simulated progress between start and completion", progresses)
+	}
+}
+
+func TestGenerateImageEstimatesProgressWithCountsOnlyStatus(t *testing.T) {
+	defer func(interval time.Duration) { progressPollInterval = interval }(progressPollInterval)
+	progressPollInterval = 5 * time.Millisecond
+
+	// The status API answers only with the session counts, as SwarmUI does:
+	// queued for the first polls, then generating
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/API/GetNewSession":
+			w.Write([]byte(`{"session_id": "counts-session"}`))
+		case "/API/GetCurrentStatus":
+			mu.Lock()
+			defer mu.Unlock()
+			polls++
+			if polls <= 3 {
+				w.Write([]byte(`{"status": {"waiting_gens": 1, "loading_models": 0, "waiting_backends": 0, "live_gens": 0}}`))
+			} else {
+				w.Write([]byte(`{"status": {"waiting_gens": 0, "loading_models": 0, "waiting_backends": 0, "live_gens": 1}}`))
+			}
+		case "/API/GenerateText2Image":
+			time.Sleep(700 * time.Millisecond)
+			w.Write([]byte(`{"images": ["View/local/raw/image.png"]}`))
+		}
+	}))
+	defer server.Close()
+
+	c := newProgressTestClient(t, server.URL)
+
+	var progresses []float64
+	var updates []ProgressUpdate
+	req := &GenerationRequest{
+		Prompt:     "test prompt",
+		Parameters: map[string]interface{}{},
+		ProgressCallback: func(progress float64, status string) {
+			progresses = append(progresses, progress)
+		},
+		ProgressUpdateCallback: func(update ProgressUpdate) {
+			updates = append(updates, update)
+		},
+	}
+
+	if _, err := c.GenerateImage(context.Background(), req); err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	// The real phases are reported, with an estimated percentage while
+	// generating that rises over time
+	if len(updates) < 3 || updates[0] != (ProgressUpdate{Phase: PhaseQueued}) {
+		t.Fatalf("updates = %+v, expected queued then generating", updates)
+	}
+	for i, update := range updates[1:] {
+		if update.Phase != PhaseGenerating || update.Progress <= updates[i].Progress || update.Progress >= 1 {
+			t.Errorf("update %d = %+v, expected rising estimated progress while generating", i+1, update)
+		}
+	}
+	if len(progresses) < 4 || progresses[len(progresses)-1] != 1 {
+		t.Errorf("progress = %v, expected estimated progress and then completion", progresses)
+	}
+}
+
+func TestGenerateImageWSPreviews(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/API/GetNewSession" {
+			w.Write([]byte(`{"session_id": "ws-session"}`))
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var body map[string]interface{}
+		conn.ReadJSON(&body)
+
+		for _, msg := range []string{
+			`{"status": {"waiting_gens": 0, "loading_models": 1, "waiting_backends": 0, "live_gens": 0}}`,
+			`{"gen_progress": {"batch_index": "0", "overall_percent": 0.2, "current_percent": 0.4, "preview": "data:image/jpeg;base64,AAAA"}}`,
+			`{"gen_progress": {"batch_index": "0", "overall_percent": 0.4, "current_percent": 0.8, "preview": "data:image/jpeg;base64,BBBB"}}`,
+			`{"image": "View/local/raw/one.png", "batch_index": "0"}`,
+			`{"gen_progress": {"batch_index": "1", "overall_percent": 0.6, "current_percent": 0.2}}`,
+			`{"image": "View/local/raw/two.png", "batch_index": "1"}`,
+			`{"discard_indices": []}`,
+		} {
+			conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	c := newProgressTestClient(t, server.URL)
+
+	var updates []ProgressUpdate
+	req := &GenerationRequest{
+		Prompt:                 "test prompt",
+		Parameters:             map[string]interface{}{"steps": 10, "images": 2},
+		ProgressUpdateCallback: func(update ProgressUpdate) { updates = append(updates, update) },
+	}
+
+	result, err := c.GenerateImageWS(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GenerateImageWS() error = %v", err)
+	}
+
+	if len(result.ImagePaths) != 2 || result.ImagePaths[0] != "View/local/raw/one.png" || result.ImagePaths[1] != "View/local/raw/two.png" {
+		t.Errorf("image paths = %v, expected both images", result.ImagePaths)
+	}
+
+	want := []ProgressUpdate{
+		{Phase: PhaseLoadingModel},
+		{Phase: PhaseGenerating, Progress: 0.2, Step: 4, Steps: 10, Preview: "data:image/jpeg;base64,AAAA"},
+		{Phase: PhaseGenerating, Progress: 0.4, Step: 8, Steps: 10, Preview: "data:image/jpeg;base64,BBBB"},
+		{Phase: PhaseGenerating, Progress: 0.6, Step: 2, Steps: 10, BatchIndex: 1},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("got %d updates, want %d: %+v", len(updates), len(want), updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+}
+
+func TestDecodePreview(t *testing.T) {
+	tests := []struct {
+		preview  string
+		wantData string
+		wantExt  string
+		wantErr  bool
+	}{
+		{"data:image/jpeg;base64,aGVsbG8=", "hello", ".jpg", false},
+		{"data:image/png;base64,aGVsbG8=", "hello", ".png", false},
+		{"data:image/webp;base64,aGVsbG8=", "hello", ".webp", false},
+		{"", "", "", true},
+		{"View/local/raw/image.png", "", "", true},
+		{"data:image/png;base64,!!!", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preview, func(t *testing.T) {
+			data, ext, err := ProgressUpdate{Preview: tt.preview}.DecodePreview()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodePreview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(data) != tt.wantData || ext != tt.wantExt {
+				t.Errorf("DecodePreview() = %q, %q; want %q, %q", data, ext, tt.wantData, tt.wantExt)
+			}
+		})
+	}
+}
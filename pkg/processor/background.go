@@ -0,0 +1,244 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// BackgroundMode selects how RemoveBackground finds the background
+type BackgroundMode string
+
+const (
+	// BackgroundFlood clears background-colored pixels connected to the image
+	// borders, keeping enclosed areas of the same color such as white eyes
+	BackgroundFlood BackgroundMode = "flood"
+	// BackgroundKey clears every background-colored pixel (color key)
+	BackgroundKey BackgroundMode = "key"
+)
+
+// ParseBackgroundMode parses a background removal mode name
+func ParseBackgroundMode(s string) (BackgroundMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "flood", "fill":
+		return BackgroundFlood, nil
+	case "key", "colorkey", "color-key":
+		return BackgroundKey, nil
+	default:
+		return "", fmt.Errorf("invalid background mode '%s' (valid options: flood, key)", s)
+	}
+}
+
+// BackgroundOptions configures background removal
+type BackgroundOptions struct {
+	// Mode chooses flood fill from the borders or a color key (default: flood)
+	Mode BackgroundMode
+	// Color of the background (default: sampled from the image corners)
+	Color *color.NRGBA
+	// Tolerance is the largest per-channel difference from Color that is
+	// still background (0-255, default: 10)
+	Tolerance uint8
+	// Feather fades pixels up to this much further from Color to partly
+	// transparent, softening the edges (0-255, 0 = hard edges)
+	Feather uint8
+	// Format of the output file (default: from its extension, else PNG).
+	// JPEG cannot hold transparency and is rejected.
+	Format string
+}
+
+// RemoveBackground makes the background of an image transparent and saves
+// it with an alpha channel, as PNG unless the output extension or
+// opts.Format names another format with transparency.
+//
+// It is a Chain of a single RemoveBackgroundStep.
+func RemoveBackground(inputPath, outputPath string, opts BackgroundOptions) error {
+	format := opts.Format
+	if format == "" {
+		format = FormatFromPath(outputPath)
+	}
+	if format == "" {
+		format = "png"
+	}
+	if format == "jpeg" {
+		return fmt.Errorf("jpeg output cannot hold transparency (use png, webp, gif, bmp or tiff)")
+	}
+
+	chain := NewChain(RemoveBackgroundStep{Options: opts})
+	chain.Format = format
+	return chain.ProcessFile(inputPath, outputPath)
+}
+
+// RemoveBackgroundStep is background removal as a Chain step
+type RemoveBackgroundStep struct {
+	Options BackgroundOptions
+}
+
+// Name implements Step
+func (s RemoveBackgroundStep) Name() string {
+	return "remove_background"
+}
+
+// Apply implements Step. The result is always an *image.NRGBA.
+func (s RemoveBackgroundStep) Apply(srcImg image.Image) (image.Image, error) {
+	opts := s.Options
+	if opts.Tolerance == 0 {
+		opts.Tolerance = 10 // Default: allow some noise in flat backgrounds
+	}
+
+	bounds := srcImg.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			img.SetNRGBA(x, y, color.NRGBAModel.Convert(srcImg.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA))
+		}
+	}
+
+	var bg color.NRGBA
+	if opts.Color != nil {
+		bg = *opts.Color
+	} else {
+		bg = sampleBackground(img, opts.Tolerance)
+	}
+
+	// alphaAt returns how opaque the background test leaves a pixel: 0 for
+	// background, 255 for foreground and in between within the feather
+	edge := int(opts.Tolerance) + int(opts.Feather)
+	alphaAt := func(c color.NRGBA) uint8 {
+		if c.A < 10 {
+			return 0 // Already transparent
+		}
+		d := int(colorDistance(c, bg))
+		switch {
+		case d <= int(opts.Tolerance):
+			return 0
+		case d >= edge:
+			return 255
+		default:
+			return uint8((d - int(opts.Tolerance)) * 255 / int(opts.Feather))
+		}
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	alpha := make([]uint8, w*h)
+	switch opts.Mode {
+	case BackgroundKey:
+		for i := range alpha {
+			alpha[i] = alphaAt(img.NRGBAAt(i%w, i/w))
+		}
+	case BackgroundFlood, "":
+		floodBackground(img, alpha, alphaAt)
+	default:
+		return nil, fmt.Errorf("invalid background mode '%s'", opts.Mode)
+	}
+
+	for i, a := range alpha {
+		if a == 255 {
+			continue
+		}
+		p := img.Pix[i*4 : i*4+4 : i*4+4]
+		if a == 0 {
+			p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+			continue
+		}
+		// Take the background out of blended edge pixels so they don't
+		// keep a fringe of its color: c = a·fg + (1-a)·bg
+		p[0] = unblend(p[0], bg.R, a)
+		p[1] = unblend(p[1], bg.G, a)
+		p[2] = unblend(p[2], bg.B, a)
+		p[3] = uint8(int(p[3]) * int(a) / 255)
+	}
+	return img, nil
+}
+
+// floodBackground clears the background reachable from the image borders.
+// Pixels are cleared through 4-connected background pixels; feathered pixels
+// next to cleared ones become partly transparent but don't spread the fill.
+func floodBackground(img *image.NRGBA, alpha []uint8, alphaAt func(color.NRGBA) uint8) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	for i := range alpha {
+		alpha[i] = 255
+	}
+	visited := make([]bool, w*h)
+	stack := make([]int, 0, 2*(w+h))
+
+	push := func(x, y int) {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return
+		}
+		i := y*w + x
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		a := alphaAt(img.NRGBAAt(x, y))
+		if a == 0 {
+			alpha[i] = 0
+			stack = append(stack, i)
+		} else if a < 255 {
+			alpha[i] = a
+		}
+	}
+
+	for x := 0; x < w; x++ {
+		push(x, 0)
+		push(x, h-1)
+	}
+	for y := 0; y < h; y++ {
+		push(0, y)
+		push(w-1, y)
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%w, i/w
+		push(x-1, y)
+		push(x+1, y)
+		push(x, y-1)
+		push(x, y+1)
+	}
+}
+
+// sampleBackground picks the background color from the four corners: the
+// color most corners agree on within tolerance, preferring the top-left
+func sampleBackground(img image.Image, tolerance uint8) color.NRGBA {
+	r := img.Bounds()
+	corners := []color.NRGBA{
+		color.NRGBAModel.Convert(img.At(r.Min.X, r.Min.Y)).(color.NRGBA),
+		color.NRGBAModel.Convert(img.At(r.Max.X-1, r.Min.Y)).(color.NRGBA),
+		color.NRGBAModel.Convert(img.At(r.Min.X, r.Max.Y-1)).(color.NRGBA),
+		color.NRGBAModel.Convert(img.At(r.Max.X-1, r.Max.Y-1)).(color.NRGBA),
+	}
+
+	best, bestVotes := corners[0], 0
+	for _, c := range corners {
+		votes := 0
+		for _, other := range corners {
+			if colorDistance(c, other) <= tolerance {
+				votes++
+			}
+		}
+		if votes > bestVotes {
+			best, bestVotes = c, votes
+		}
+	}
+	return best
+}
+
+// colorDistance is the largest difference between the RGB channels of two
+// colors, ignoring alpha
+func colorDistance(a, b color.NRGBA) uint8 {
+	d := func(x, y uint8) uint8 {
+		if x > y {
+			return x - y
+		}
+		return y - x
+	}
+	return max(d(a.R, b.R), d(a.G, b.G), d(a.B, b.B))
+}
+
+// unblend recovers a foreground channel from c = a·fg + (1-a)·bg
+func unblend(c, bg, a uint8) uint8 {
+	fg := (int(c)*255 - int(bg)*(255-int(a))) / int(a)
+	return uint8(min(max(fg, 0), 255))
+}
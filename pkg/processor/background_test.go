@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createRingImage creates a white image with a black ring whose inside is
+// white again, as in an icon with a white interior
+func createRingImage(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.NRGBA{255, 255, 255, 255}
+			if x >= size/4 && x < size*3/4 && y >= size/4 && y < size*3/4 {
+				c = color.NRGBA{0, 0, 0, 255}
+				if x > size/4 && x < size*3/4-1 && y > size/4 && y < size*3/4-1 {
+					c = color.NRGBA{255, 255, 255, 255}
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRemoveBackgroundStep(t *testing.T) {
+	img := createRingImage(20)
+
+	tests := []struct {
+		name           string
+		opts           BackgroundOptions
+		wantInteriorA  uint8
+		wantOutsideA   uint8
+		wantRingOpaque bool
+	}{
+		{name: "flood keeps enclosed white", opts: BackgroundOptions{Mode: BackgroundFlood}, wantInteriorA: 255, wantOutsideA: 0, wantRingOpaque: true},
+		{name: "key clears all white", opts: BackgroundOptions{Mode: BackgroundKey}, wantInteriorA: 0, wantOutsideA: 0, wantRingOpaque: true},
+		{name: "explicit color that is absent", opts: BackgroundOptions{Mode: BackgroundKey, Color: &color.NRGBA{0, 255, 0, 255}}, wantInteriorA: 255, wantOutsideA: 255, wantRingOpaque: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := RemoveBackgroundStep{Options: tt.opts}.Apply(img)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			nrgba := out.(*image.NRGBA)
+
+			if a := nrgba.NRGBAAt(10, 10).A; a != tt.wantInteriorA {
+				t.Errorf("interior alpha = %d, want %d", a, tt.wantInteriorA)
+			}
+			if a := nrgba.NRGBAAt(0, 0).A; a != tt.wantOutsideA {
+				t.Errorf("outside alpha = %d, want %d", a, tt.wantOutsideA)
+			}
+			if a := nrgba.NRGBAAt(5, 5).A; (a == 255) != tt.wantRingOpaque {
+				t.Errorf("ring alpha = %d, want opaque %v", a, tt.wantRingOpaque)
+			}
+		})
+	}
+}
+
+func TestRemoveBackgroundFeather(t *testing.T) {
+	// A red pixel half blended into white, between white and solid red
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{255, 128, 128, 255})
+	img.SetNRGBA(2, 0, color.NRGBA{255, 0, 0, 255})
+
+	opts := BackgroundOptions{Mode: BackgroundKey, Color: &color.NRGBA{255, 255, 255, 255}, Tolerance: 1, Feather: 254}
+	out, err := RemoveBackgroundStep{Options: opts}.Apply(img)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	nrgba := out.(*image.NRGBA)
+
+	edge := nrgba.NRGBAAt(1, 0)
+	if edge.A < 100 || edge.A > 155 {
+		t.Errorf("edge alpha = %d, want about half", edge.A)
+	}
+	// The white blended into the edge is taken out again
+	if edge.R < 250 || edge.G > 10 || edge.B > 10 {
+		t.Errorf("edge color = %v, want red without a white fringe", edge)
+	}
+	if c := nrgba.NRGBAAt(2, 0); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("foreground = %v, want unchanged red", c)
+	}
+}
+
+func TestSampleBackground(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	// One corner covered by the subject
+	img.SetNRGBA(0, 0, color.NRGBA{10, 20, 30, 255})
+
+	if got := sampleBackground(img, 10); got != (color.NRGBA{200, 200, 200, 200}) {
+		t.Errorf("sampleBackground() = %v, want the color of the other corners", got)
+	}
+}
+
+func TestRemoveBackground(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "icon.jpg")
+	f, err := os.Create(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(f, createRingImage(20), "jpeg", 100); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	outputPath := filepath.Join(tmpDir, "icon.png")
+	if err := RemoveBackground(inputPath, outputPath, BackgroundOptions{Tolerance: 30}); err != nil {
+		t.Fatalf("RemoveBackground() error = %v", err)
+	}
+	out, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	img, format, err := image.Decode(out)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if format != "png" {
+		t.Errorf("output is %s, want png", format)
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("corner alpha = %d, want transparent", a)
+	}
+
+	if err := RemoveBackground(inputPath, filepath.Join(tmpDir, "out.jpg"), BackgroundOptions{}); err == nil {
+		t.Error("RemoveBackground() to JPEG succeeded, want error")
+	}
+}
+
+func TestParseBackgroundMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    BackgroundMode
+		wantErr bool
+	}{
+		{input: "flood", want: BackgroundFlood},
+		{input: "", want: BackgroundFlood},
+		{input: "KEY", want: BackgroundKey},
+		{input: "color-key", want: BackgroundKey},
+		{input: "magic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBackgroundMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBackgroundMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBackgroundMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// Step is one operation of a Chain, applied to a decoded image in memory
+type Step interface {
+	// Name identifies the step in errors and reports (e.g. "auto_crop")
+	Name() string
+	// Apply returns the processed image, or img itself when there is
+	// nothing to do
+	Apply(img image.Image) (image.Image, error)
+}
+
+// Chain decodes an image once, applies its steps in order and encodes the
+// result once. Encoding never writes metadata, so PNG output is always clean.
+type Chain struct {
+	Steps []Step
+	// Format of the output, one of Formats (empty: the format of the output
+	// file's extension, else the input format)
+	Format string
+	// Quality for JPEG output (1-100, default: 90)
+	JPEGQuality int
+	// flatten composites the result onto white after the steps (see FlattenedFor)
+	flatten bool
+}
+
+// NewChain returns a chain of the given steps that keeps the input format
+func NewChain(steps ...Step) *Chain {
+	return &Chain{Steps: steps}
+}
+
+// Names returns the names of the chain's steps
+func (c *Chain) Names() []string {
+	names := make([]string, len(c.Steps))
+	for i, step := range c.Steps {
+		names[i] = step.Name()
+	}
+	return names
+}
+
+// FlattenedFor returns the chain to use when writing to outputPath: c itself,
+// or a copy that flattens its result onto white when the output is JPEG, so
+// that transparent areas do not turn black. Undecodable data is still written
+// as it is when the chain has no steps.
+func (c *Chain) FlattenedFor(outputPath string) *Chain {
+	format := c.Format
+	if format == "" {
+		format = FormatFromPath(outputPath)
+	}
+	if normalizeFormat(format) != "jpeg" {
+		return c
+	}
+	flattened := *c
+	flattened.flatten = true
+	return &flattened
+}
+
+// Apply runs the steps on an image in memory
+func (c *Chain) Apply(img image.Image) (image.Image, error) {
+	for _, step := range c.Steps {
+		out, err := step.Apply(img)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", step.Name(), err)
+		}
+		img = out
+	}
+	if c.flatten {
+		return FlattenStep{}.Apply(img)
+	}
+	return img, nil
+}
+
+// Process decodes data, applies the steps and writes the encoded result to
+// w, returning the format written. PNGs are always re-encoded, which drops
+// their metadata. Other images the steps leave unchanged, and data that
+// cannot be decoded when there are no steps, are written as they are.
+func (c *Chain) Process(data []byte, w io.Writer) (string, error) {
+	return c.process(data, w, c.Format)
+}
+
+// process is Process with the output format resolved by the caller
+func (c *Chain) process(data []byte, w io.Writer, outFormat string) (string, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		if len(c.Steps) == 0 && c.Format == "" {
+			_, err = w.Write(data)
+			return "", err
+		}
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img, err := c.Apply(src)
+	if err != nil {
+		return "", err
+	}
+
+	if outFormat == "" {
+		outFormat = format
+	}
+	outFormat = normalizeFormat(outFormat)
+	if outFormat == format && format != "png" && sameImage(img, src) {
+		_, err = w.Write(data)
+		return format, err
+	}
+
+	if err := Encode(w, img, outFormat, c.JPEGQuality); err != nil {
+		return "", err
+	}
+	return outFormat, nil
+}
+
+// WriteFile processes data and writes the result to outputPath. Without a
+// Format, the output format follows the extension of outputPath. The file is
+// replaced only once processing succeeded.
+func (c *Chain) WriteFile(data []byte, outputPath string) error {
+	dir := filepath.Dir(outputPath)
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	format := c.Format
+	if format == "" {
+		format = FormatFromPath(outputPath)
+	}
+	if _, err := c.process(data, temp, format); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(temp.Name(), outputPath); err != nil {
+		return fmt.Errorf("failed to replace output file: %w", err)
+	}
+	return nil
+}
+
+// ProcessFile reads an image file, processes it and writes the result to
+// outputPath, which may be the input file
+func (c *Chain) ProcessFile(inputPath, outputPath string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input image: %w", err)
+	}
+	return c.WriteFile(data, outputPath)
+}
+
+// sameImage reports whether a step returned its input unchanged
+func sameImage(a, b image.Image) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
@@ -0,0 +1,249 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countingStep records how often it runs and returns its input unchanged
+type countingStep struct {
+	calls int
+	err   error
+}
+
+func (s *countingStep) Name() string { return "counting" }
+
+func (s *countingStep) Apply(img image.Image) (image.Image, error) {
+	s.calls++
+	return img, s.err
+}
+
+// encodeTestImage encodes img in the given format
+func encodeTestImage(t *testing.T, img image.Image, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format, 0); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withTextChunk inserts a tEXt chunk before the IEND chunk of a PNG
+func withTextChunk(data []byte, text string) []byte {
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(text)))
+	chunk.WriteString("tEXt" + text)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+
+	iend := len(data) - 12
+	out := append([]byte(nil), data[:iend]...)
+	out = append(out, chunk.Bytes()...)
+	return append(out, data[iend:]...)
+}
+
+func TestChainProcess(t *testing.T) {
+	src := createCropTestImage(200, 100, 50, 25, 100, 50)
+	pngData := encodeTestImage(t, src, "png")
+	jpegData := encodeTestImage(t, src, "jpeg")
+
+	tests := []struct {
+		name       string
+		data       []byte
+		chain      *Chain
+		wantFormat string
+		wantWidth  int
+		wantHeight int
+		wantSame   bool // Output is the input data
+		wantErr    bool
+	}{
+		{
+			name:       "crop then downscale",
+			data:       pngData,
+			chain:      NewChain(CropStep{}, DownscaleStep{Options: DownscaleOptions{Percentage: 50}}),
+			wantFormat: "png",
+			wantWidth:  50,
+			wantHeight: 25,
+		},
+		{
+			name:       "unchanged JPEG is passed through",
+			data:       jpegData,
+			chain:      NewChain(&countingStep{}),
+			wantFormat: "jpeg",
+			wantWidth:  200,
+			wantHeight: 100,
+			wantSame:   true,
+		},
+		{
+			name:       "format override",
+			data:       jpegData,
+			chain:      &Chain{Format: "png"},
+			wantFormat: "png",
+			wantWidth:  200,
+			wantHeight: 100,
+		},
+		{
+			name:     "undecodable data without steps is passed through",
+			data:     []byte("not an image"),
+			chain:    NewChain(),
+			wantSame: true,
+		},
+		{
+			name:    "undecodable data with steps",
+			data:    []byte("not an image"),
+			chain:   NewChain(CropStep{}),
+			wantErr: true,
+		},
+		{
+			name:    "step error",
+			data:    pngData,
+			chain:   NewChain(&countingStep{err: errors.New("boom")}),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			data:    pngData,
+			chain:   &Chain{Format: "xyz"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			format, err := tt.chain.Process(tt.data, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if same := bytes.Equal(out.Bytes(), tt.data); same != tt.wantSame {
+				t.Errorf("output equals input: %v, want %v", same, tt.wantSame)
+			}
+			if tt.wantWidth == 0 {
+				return
+			}
+
+			config, decodedFormat, err := image.DecodeConfig(&out)
+			if err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if decodedFormat != tt.wantFormat {
+				t.Errorf("output is %s, want %s", decodedFormat, tt.wantFormat)
+			}
+			if config.Width != tt.wantWidth || config.Height != tt.wantHeight {
+				t.Errorf("output is %dx%d, want %dx%d", config.Width, config.Height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestChainProcessStripsPNGMetadata(t *testing.T) {
+	img := createCropTestImage(20, 20, 5, 5, 10, 10)
+	data := withTextChunk(encodeTestImage(t, img, "png"), "parameters\x00prompt: a secret")
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Test PNG is invalid: %v", err)
+	}
+
+	step := &countingStep{}
+	var out bytes.Buffer
+	if _, err := NewChain(step).Process(data, &out); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if step.calls != 1 {
+		t.Errorf("step ran %d times, want 1", step.calls)
+	}
+	if bytes.Contains(out.Bytes(), []byte("tEXt")) || bytes.Contains(out.Bytes(), []byte("secret")) {
+		t.Error("output still contains the text chunk")
+	}
+}
+
+func TestChainFlattenedFor(t *testing.T) {
+	transparent := encodeTestImage(t, image.NewNRGBA(image.Rect(0, 0, 4, 4)), "png")
+
+	chain := NewChain()
+	if got := chain.FlattenedFor("out.png"); got != chain {
+		t.Error("FlattenedFor(out.png) returned a copy, expected the chain itself")
+	}
+	flattened := chain.FlattenedFor("out.jpg")
+	if flattened == chain || chain.flatten {
+		t.Error("FlattenedFor(out.jpg) did not return a flattening copy")
+	}
+
+	var buf bytes.Buffer
+	if _, err := flattened.process(transparent, &buf, "jpeg"); err != nil {
+		t.Fatalf("process() error = %v", err)
+	}
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if r, g, b, _ := img.At(2, 2).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel became (%d, %d, %d), expected white", r>>8, g>>8, b>>8)
+	}
+
+	// Data that is not an image still passes through a chain without steps
+	buf.Reset()
+	if _, err := flattened.process([]byte("raw"), &buf, "jpeg"); err != nil || buf.String() != "raw" {
+		t.Errorf("process() of undecodable data = %q, %v; expected it written as is", buf.String(), err)
+	}
+
+	chain.Format = "jpeg"
+	if !chain.FlattenedFor("out.png").flatten {
+		t.Error("FlattenedFor with Format jpeg did not flatten")
+	}
+}
+
+func TestChainProcessFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "image.jpg")
+	if err := os.WriteFile(path, encodeTestImage(t, createCropTestImage(200, 100, 50, 25, 100, 50), "jpeg"), 0644); err != nil {
+		t.Fatalf("Failed to write test image: %v", err)
+	}
+
+	// In place, keeping the JPEG format
+	chain := NewChain(CropStep{Options: CropOptions{Tolerance: 40}})
+	chain.JPEGQuality = 80
+	if err := chain.ProcessFile(path, path); err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open output: %v", err)
+	}
+	defer f.Close()
+	if _, err := jpeg.DecodeConfig(f); err != nil {
+		t.Errorf("output is not a JPEG: %v", err)
+	}
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("found %d files, want only the output", len(entries))
+	}
+
+	// A failed step leaves the file untouched
+	before, _ := os.ReadFile(path)
+	if err := NewChain(&countingStep{err: errors.New("boom")}).ProcessFile(path, path); err == nil {
+		t.Error("ProcessFile() succeeded with a failing step")
+	}
+	after, _ := os.ReadFile(path)
+	if !bytes.Equal(before, after) {
+		t.Error("failed ProcessFile() modified the file")
+	}
+}
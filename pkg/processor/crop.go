@@ -4,23 +4,62 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/jpeg"
-	"image/png"
-	"os"
-	"path/filepath"
+	"image/draw"
+	"math"
 	"strings"
 )
 
+// CropMode selects what auto-crop treats as background
+type CropMode string
+
+const (
+	// CropWhite crops near-white borders, as set by Threshold and Tolerance
+	CropWhite CropMode = "white"
+	// CropAuto crops borders of the color sampled from the image corners
+	CropAuto CropMode = "auto"
+	// CropColor crops borders of an explicit Color
+	CropColor CropMode = "color"
+	// CropAlpha crops transparent borders only, whatever their color
+	CropAlpha CropMode = "alpha"
+)
+
+// ParseCropMode parses an auto-crop background mode name
+func ParseCropMode(s string) (CropMode, error) {
+	switch mode := CropMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return CropWhite, nil
+	case CropWhite, CropAuto, CropColor, CropAlpha:
+		return mode, nil
+	case "transparent":
+		return CropAlpha, nil
+	default:
+		return "", fmt.Errorf("invalid crop mode '%s' (valid options: white, auto, color, alpha)", s)
+	}
+}
+
 // CropOptions configures automatic cropping behavior
 type CropOptions struct {
+	// Mode selects the background to crop (default: white)
+	Mode CropMode
+	// Color of the background in color mode
+	Color *color.NRGBA
 	// Threshold for detecting "whitespace" (0-255, default: 250)
 	// Pixels with all RGB values >= this threshold are considered whitespace
 	Threshold uint8
 	// Tolerance for near-white colors (0-255, default: 10)
-	// Allows RGB values within Threshold ± Tolerance to be considered whitespace
+	// Allows RGB values within Threshold ± Tolerance to be considered whitespace.
+	// In auto and color modes it is the largest per-channel difference from
+	// the background color, and in alpha mode the largest background alpha.
 	Tolerance uint8
+	// Padding keeps this many pixels of margin around the content
+	Padding int
+	// PaddingPercent keeps a margin of this percentage of the larger content
+	// side, added to Padding
+	PaddingPercent float64
 	// Quality for JPEG output (1-100, default: 90)
 	JPEGQuality int
+	// Format of the output file (default: from its extension, else the input format)
+	Format string
 	// PreserveAspectRatio ensures the crop maintains the original aspect ratio
 	PreserveAspectRatio bool
 }
@@ -39,50 +78,60 @@ type CropOptions struct {
 // The function:
 //   - Detects whitespace by scanning from edges inward
 //   - Preserves aspect ratio if requested
-//   - Maintains the original image format (PNG/JPEG)
+//   - Writes the format of opts.Format or the output extension, else the input format
 //   - Returns error if entire image would be cropped (no content detected)
+//
+// It is a Chain of a single CropStep.
 func AutoCropImage(inputPath, outputPath string, opts CropOptions) error {
-	// Set defaults
+	chain := NewChain(CropStep{Options: opts})
+	chain.Format, chain.JPEGQuality = opts.Format, opts.JPEGQuality
+	return chain.ProcessFile(inputPath, outputPath)
+}
+
+// CropStep is the auto-crop operation as a Chain step
+type CropStep struct {
+	Options CropOptions
+}
+
+// Name implements Step
+func (s CropStep) Name() string {
+	return "auto_crop"
+}
+
+// Apply removes the whitespace borders of img
+func (s CropStep) Apply(srcImg image.Image) (image.Image, error) {
+	opts := s.Options
 	if opts.Threshold == 0 {
 		opts.Threshold = 250 // Default: very light colors are "whitespace"
 	}
 	if opts.Tolerance == 0 {
 		opts.Tolerance = 10 // Default: allow some variation
 	}
-	if opts.JPEGQuality == 0 {
-		opts.JPEGQuality = 90
-	}
-
-	// Open the source image
-	inputFile, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("failed to open input image: %w", err)
-	}
-	defer inputFile.Close()
 
-	// Decode the image
-	srcImg, format, err := image.Decode(inputFile)
+	isBackground, err := cropBackground(srcImg, opts)
 	if err != nil {
-		return fmt.Errorf("failed to decode image: %w", err)
+		return nil, err
 	}
 
 	// Detect content bounds
-	bounds := detectContentBounds(srcImg, opts.Threshold, opts.Tolerance)
+	bounds := scanContentBounds(srcImg, isBackground)
 
 	// Validate that we found some content
 	if bounds.Empty() {
-		return fmt.Errorf("no content detected in image (entire image appears to be whitespace)")
+		return nil, fmt.Errorf("no content detected in image (entire image appears to be whitespace)")
+	}
+
+	// Keep a margin around the content
+	if opts.Padding > 0 || opts.PaddingPercent > 0 {
+		margin := opts.Padding + int(math.Round(float64(max(bounds.Dx(), bounds.Dy()))*opts.PaddingPercent/100))
+		bounds = bounds.Inset(-margin).Intersect(srcImg.Bounds())
 	}
 
 	// Check if crop would actually change the image
 	srcBounds := srcImg.Bounds()
 	if bounds.Eq(srcBounds) {
 		// No cropping needed - image has no whitespace borders
-		// If input and output are different, copy the file
-		if inputPath != outputPath {
-			return copyFile(inputPath, outputPath)
-		}
-		return nil
+		return srcImg, nil
 	}
 
 	// Apply aspect ratio preservation if requested
@@ -90,94 +139,136 @@ func AutoCropImage(inputPath, outputPath string, opts CropOptions) error {
 		bounds = preserveAspectRatio(srcBounds, bounds)
 	}
 
-	// Create cropped image
-	croppedImg := cropImage(srcImg, bounds)
+	return cropImage(srcImg, bounds), nil
+}
 
-	// Create output file
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outputFile.Close()
-
-	// Encode and save based on format
-	switch strings.ToLower(format) {
-	case "jpeg", "jpg":
-		err = jpeg.Encode(outputFile, croppedImg, &jpeg.Options{Quality: opts.JPEGQuality})
-	case "png":
-		err = png.Encode(outputFile, croppedImg)
+// detectContentBounds scans from each edge inward to find the first non-whitespace pixels
+func detectContentBounds(img image.Image, threshold, tolerance uint8) image.Rectangle {
+	return scanContentBounds(img, whiteBackground(threshold, tolerance))
+}
+
+// cropBackground returns the test for background pixels of a crop mode
+func cropBackground(img image.Image, opts CropOptions) (func(color.NRGBA) bool, error) {
+	switch opts.Mode {
+	case CropWhite, "":
+		return whiteBackground(opts.Threshold, opts.Tolerance), nil
+	case CropAuto:
+		return colorBackground(sampleBackground(img, opts.Tolerance), opts.Tolerance), nil
+	case CropColor:
+		if opts.Color == nil {
+			return nil, fmt.Errorf("color crop mode needs a background color")
+		}
+		return colorBackground(*opts.Color, opts.Tolerance), nil
+	case CropAlpha:
+		return func(c color.NRGBA) bool { return c.A <= opts.Tolerance }, nil
 	default:
-		// Default to PNG for unknown formats
-		err = png.Encode(outputFile, croppedImg)
+		return nil, fmt.Errorf("invalid crop mode '%s'", opts.Mode)
 	}
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to encode output image: %w", err)
+// whiteBackground matches near-white and transparent pixels with isWhitespace.
+// The pixel is passed through one reused variable, so that converting it to
+// a color.Color does not allocate for every pixel; the returned test must not
+// be shared between goroutines.
+func whiteBackground(threshold, tolerance uint8) func(color.NRGBA) bool {
+	var pixel color.NRGBA
+	return func(c color.NRGBA) bool {
+		pixel = c
+		return isWhitespace(&pixel, threshold, tolerance)
 	}
+}
 
-	// Mandatory: Strip all PNG metadata for privacy and security
-	// This removes any generation parameters, timestamps, or other sensitive data
-	if err := StripPNGMetadata(outputPath); err != nil {
-		return fmt.Errorf("failed to strip PNG metadata: %w", err)
+// colorBackground matches pixels within tolerance of bg, and transparent ones
+func colorBackground(bg color.NRGBA, tolerance uint8) func(color.NRGBA) bool {
+	return func(c color.NRGBA) bool {
+		return c.A < 10 || colorDistance(c, bg) <= tolerance
 	}
-
-	return nil
 }
 
-// detectContentBounds scans from each edge inward to find the first non-whitespace pixels
-func detectContentBounds(img image.Image, threshold, tolerance uint8) image.Rectangle {
+// scanContentBounds finds the smallest rectangle holding every pixel that is
+// not background. Rows are scanned from the top and bottom, then only the
+// columns outside the bounds found so far, reading *image.NRGBA and
+// *image.RGBA pixels directly; other images are converted to NRGBA first.
+func scanContentBounds(img image.Image, isBackground func(color.NRGBA) bool) image.Rectangle {
+	at := nrgbaReader(img)
 	bounds := img.Bounds()
-	minX, minY := bounds.Max.X, bounds.Max.Y
-	maxX, maxY := bounds.Min.X, bounds.Min.Y
 
-	// Scan from left
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			if !isWhitespace(img.At(x, y), threshold, tolerance) {
-				minX = x
-				goto foundLeft
+	rowEmpty := func(y, x0, x1 int) bool {
+		for x := x0; x < x1; x++ {
+			if !isBackground(at(x, y)) {
+				return false
 			}
 		}
+		return true
 	}
-foundLeft:
 
-	// Scan from right
-	for x := bounds.Max.X - 1; x >= bounds.Min.X; x-- {
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			if !isWhitespace(img.At(x, y), threshold, tolerance) {
-				maxX = x + 1 // exclusive bound
-				goto foundRight
-			}
-		}
+	minY := bounds.Min.Y
+	for minY < bounds.Max.Y && rowEmpty(minY, bounds.Min.X, bounds.Max.X) {
+		minY++
+	}
+	if minY == bounds.Max.Y {
+		return image.Rectangle{}
+	}
+	maxY := bounds.Max.Y
+	for maxY > minY && rowEmpty(maxY-1, bounds.Min.X, bounds.Max.X) {
+		maxY--
 	}
-foundRight:
 
-	// Scan from top
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			if !isWhitespace(img.At(x, y), threshold, tolerance) {
-				minY = y
-				goto foundTop
+	// Narrow the columns row by row; each row only checks the pixels
+	// outside the content found so far
+	minX, maxX := bounds.Max.X, bounds.Min.X
+	for y := minY; y < maxY; y++ {
+		for x := bounds.Min.X; x < minX; x++ {
+			if !isBackground(at(x, y)) {
+				minX = x
+				break
 			}
 		}
-	}
-foundTop:
-
-	// Scan from bottom
-	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			if !isWhitespace(img.At(x, y), threshold, tolerance) {
-				maxY = y + 1 // exclusive bound
-				goto foundBottom
+		for x := bounds.Max.X - 1; x >= maxX; x-- {
+			if !isBackground(at(x, y)) {
+				maxX = x + 1 // exclusive bound
+				break
 			}
 		}
 	}
-foundBottom:
 
-	// Return the content bounds
 	return image.Rect(minX, minY, maxX, maxY)
 }
 
+// nrgbaReader returns a fast accessor for the non-premultiplied pixels of img
+func nrgbaReader(img image.Image) func(x, y int) color.NRGBA {
+	switch p := img.(type) {
+	case *image.NRGBA:
+		return func(x, y int) color.NRGBA {
+			i := p.PixOffset(x, y)
+			s := p.Pix[i : i+4 : i+4]
+			return color.NRGBA{s[0], s[1], s[2], s[3]}
+		}
+	case *image.RGBA:
+		return func(x, y int) color.NRGBA {
+			i := p.PixOffset(x, y)
+			s := p.Pix[i : i+4 : i+4]
+			a := s[3]
+			switch a {
+			case 0xff:
+				return color.NRGBA{s[0], s[1], s[2], a}
+			case 0:
+				return color.NRGBA{}
+			}
+			return color.NRGBA{
+				uint8(uint32(s[0]) * 0xff / uint32(a)),
+				uint8(uint32(s[1]) * 0xff / uint32(a)),
+				uint8(uint32(s[2]) * 0xff / uint32(a)),
+				a,
+			}
+		}
+	default:
+		dst := image.NewNRGBA(img.Bounds())
+		draw.Draw(dst, dst.Rect, img, dst.Rect.Min, draw.Src)
+		return nrgbaReader(dst)
+	}
+}
+
 // isWhitespace determines if a pixel is considered whitespace based on threshold and tolerance
 func isWhitespace(c color.Color, threshold, tolerance uint8) bool {
 	r, g, b, a := c.RGBA()
@@ -282,42 +373,8 @@ func cropImage(src image.Image, bounds image.Rectangle) image.Image {
 	return dst
 }
 
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
-	}
-	if err := os.WriteFile(dst, data, 0644); err != nil {
-		return fmt.Errorf("failed to write destination file: %w", err)
-	}
-	return nil
-}
-
-// AutoCropInPlace crops an image and replaces the original file.
-// This is a convenience wrapper around AutoCropImage for in-place operations.
+// AutoCropInPlace crops an image and replaces the original file once the
+// cropped image was written.
 func AutoCropInPlace(imagePath string, opts CropOptions) error {
-	// Create a temporary file in the same directory
-	dir := filepath.Dir(imagePath)
-	base := filepath.Base(imagePath)
-	ext := filepath.Ext(imagePath)
-	nameWithoutExt := strings.TrimSuffix(base, ext)
-
-	tempPath := filepath.Join(dir, fmt.Sprintf(".%s_crop_tmp%s", nameWithoutExt, ext))
-
-	// Crop to temporary file
-	if err := AutoCropImage(imagePath, tempPath, opts); err != nil {
-		// Clean up temp file if it was created
-		os.Remove(tempPath)
-		return err
-	}
-
-	// Replace original with cropped version
-	if err := os.Rename(tempPath, imagePath); err != nil {
-		// Clean up temp file
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to replace original file: %w", err)
-	}
-
-	return nil
+	return AutoCropImage(imagePath, imagePath, opts)
 }
@@ -453,3 +453,149 @@ func TestCropImage(t *testing.T) {
 		t.Errorf("Expected dark pixel in content area, got RGB(%d,%d,%d)", r>>8, g>>8, b>>8)
 	}
 }
+
+// createFilledImage creates an NRGBA image of one color with a rectangle of
+// another
+func createFilledImage(width, height int, bg, fg color.NRGBA, content image.Rectangle) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (image.Point{x, y}).In(content) {
+				img.SetNRGBA(x, y, fg)
+			} else {
+				img.SetNRGBA(x, y, bg)
+			}
+		}
+	}
+	return img
+}
+
+func TestCropStepModes(t *testing.T) {
+	content := image.Rect(20, 10, 60, 30)
+	dark := color.NRGBA{20, 22, 30, 255}
+	red := color.NRGBA{200, 30, 30, 255}
+	green := color.NRGBA{0, 255, 0, 255}
+
+	tests := []struct {
+		name       string
+		img        image.Image
+		opts       CropOptions
+		wantBounds image.Rectangle
+		wantErr    bool
+	}{
+		{
+			name:       "auto on dark background",
+			img:        createFilledImage(80, 40, dark, red, content),
+			opts:       CropOptions{Mode: CropAuto},
+			wantBounds: content,
+		},
+		{
+			name:       "white mode keeps dark background",
+			img:        createFilledImage(80, 40, dark, red, content),
+			opts:       CropOptions{Mode: CropWhite},
+			wantBounds: image.Rect(0, 0, 80, 40),
+		},
+		{
+			name:       "explicit color",
+			img:        createFilledImage(80, 40, green, red, content),
+			opts:       CropOptions{Mode: CropColor, Color: &green},
+			wantBounds: content,
+		},
+		{
+			name:    "color mode without color",
+			img:     createFilledImage(80, 40, green, red, content),
+			opts:    CropOptions{Mode: CropColor},
+			wantErr: true,
+		},
+		{
+			name:       "alpha ignores color",
+			img:        createFilledImage(80, 40, color.NRGBA{255, 0, 255, 0}, color.NRGBA{255, 255, 255, 255}, content),
+			opts:       CropOptions{Mode: CropAlpha},
+			wantBounds: content,
+		},
+		{
+			name:       "padding",
+			img:        createFilledImage(80, 40, dark, red, content),
+			opts:       CropOptions{Mode: CropAuto, Padding: 5},
+			wantBounds: image.Rect(15, 5, 65, 35),
+		},
+		{
+			name:       "padding percent of the larger side",
+			img:        createFilledImage(80, 40, dark, red, content),
+			opts:       CropOptions{Mode: CropAuto, PaddingPercent: 10},
+			wantBounds: image.Rect(16, 6, 64, 34),
+		},
+		{
+			name:       "padding is clamped to the image",
+			img:        createFilledImage(80, 40, dark, red, content),
+			opts:       CropOptions{Mode: CropAuto, Padding: 15},
+			wantBounds: image.Rect(5, 0, 75, 40),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := CropStep{Options: tt.opts}.Apply(tt.img)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if out.Bounds() != tt.wantBounds {
+				t.Errorf("bounds = %v, want %v", out.Bounds(), tt.wantBounds)
+			}
+		})
+	}
+}
+
+func TestScanContentBoundsImageTypes(t *testing.T) {
+	nrgba := createFilledImage(64, 48, color.NRGBA{255, 255, 255, 255}, color.NRGBA{0, 0, 0, 255}, image.Rect(10, 5, 30, 40))
+	want := image.Rect(10, 5, 30, 40)
+
+	rgba := image.NewRGBA(nrgba.Bounds())
+	gray := image.NewGray(nrgba.Bounds())
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			rgba.Set(x, y, nrgba.At(x, y))
+			gray.Set(x, y, nrgba.At(x, y))
+		}
+	}
+	// A sub-image whose bounds don't start at the origin
+	sub := nrgba.SubImage(image.Rect(5, 2, 60, 45))
+
+	for name, img := range map[string]image.Image{"nrgba": nrgba, "rgba": rgba, "gray": gray, "sub-image": sub} {
+		t.Run(name, func(t *testing.T) {
+			if got := detectContentBounds(img, 250, 10); got != want {
+				t.Errorf("detectContentBounds() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseCropMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    CropMode
+		wantErr bool
+	}{
+		{input: "", want: CropWhite},
+		{input: "white", want: CropWhite},
+		{input: "Auto", want: CropAuto},
+		{input: "color", want: CropColor},
+		{input: "transparent", want: CropAlpha},
+		{input: "black", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCropMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCropMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCropMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
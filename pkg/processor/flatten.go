@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+// namedColors are the color names ParseColor accepts besides hex values
+var namedColors = map[string]color.NRGBA{
+	"white":       {255, 255, 255, 255},
+	"black":       {0, 0, 0, 255},
+	"gray":        {128, 128, 128, 255},
+	"grey":        {128, 128, 128, 255},
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"transparent": {0, 0, 0, 0},
+}
+
+// ParseColor parses a color name ("white", "black", ...) or a hex value in
+// the form #rgb, #rrggbb or #rrggbbaa (the # is optional)
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q (use a name like white or a hex value like #ffffff)", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q (use a name like white or a hex value like #ffffff)", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// FlattenStep composites an image over a solid background, removing its
+// transparency. Formats without alpha such as JPEG need it, as transparent
+// pixels would otherwise turn black.
+type FlattenStep struct {
+	Background color.Color
+}
+
+// Name implements Step
+func (s FlattenStep) Name() string {
+	return "flatten"
+}
+
+// Apply implements Step. Opaque images are returned unchanged.
+func (s FlattenStep) Apply(img image.Image) (image.Image, error) {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img, nil
+	}
+
+	background := s.Background
+	if background == nil {
+		background = color.White
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst, nil
+}
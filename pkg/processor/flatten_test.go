@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{input: "white", want: color.NRGBA{255, 255, 255, 255}},
+		{input: "Black", want: color.NRGBA{0, 0, 0, 255}},
+		{input: "#1e1e1e", want: color.NRGBA{30, 30, 30, 255}},
+		{input: "ff8000", want: color.NRGBA{255, 128, 0, 255}},
+		{input: "#f80", want: color.NRGBA{255, 136, 0, 255}},
+		{input: "#00000080", want: color.NRGBA{0, 0, 0, 128}},
+		{input: "#12345", wantErr: true},
+		{input: "#gggggg", wantErr: true},
+		{input: "chartreuse", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseColor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColor(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColor(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenStep(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{0, 0, 0, 0})     // Transparent
+	img.Set(1, 0, color.NRGBA{255, 0, 0, 128}) // Half-transparent red
+
+	out, err := FlattenStep{Background: color.NRGBA{0, 0, 255, 255}}.Apply(img)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if got := color.NRGBAModel.Convert(out.At(0, 0)); got != (color.NRGBA{0, 0, 255, 255}) {
+		t.Errorf("transparent pixel = %v, want the background", got)
+	}
+	got := color.NRGBAModel.Convert(out.At(1, 0)).(color.NRGBA)
+	if got.A != 255 || got.R < 120 || got.R > 136 || got.B < 120 || got.B > 136 {
+		t.Errorf("half-transparent pixel = %v, want red blended with blue", got)
+	}
+
+	// Opaque images are left alone
+	opaque := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 255
+	}
+	if out, _ := (FlattenStep{}).Apply(opaque); out != image.Image(opaque) {
+		t.Error("opaque image was copied")
+	}
+}
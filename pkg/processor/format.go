@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+
+	// Register the decoders image.Decode uses for the formats read here
+	_ "golang.org/x/image/webp"
+)
+
+// Formats are the image formats the processor reads and writes
+var Formats = []string{"png", "jpeg", "webp", "gif", "bmp", "tiff"}
+
+// formatExtensions maps file extensions to formats
+var formatExtensions = map[string]string{
+	".png":  "png",
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".webp": "webp",
+	".gif":  "gif",
+	".bmp":  "bmp",
+	".tif":  "tiff",
+	".tiff": "tiff",
+}
+
+// ParseFormat validates a format name such as "jpg" or "WebP" and returns
+// its canonical name
+func ParseFormat(format string) (string, error) {
+	normalized := normalizeFormat(format)
+	for _, f := range Formats {
+		if f == normalized {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported image format: %s (supported: png, jpeg, webp, gif, bmp, tiff)", format)
+}
+
+// FormatFromPath returns the format a file extension stands for, or "" when
+// the extension is not an image format
+func FormatFromPath(path string) string {
+	return formatExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Extension returns the file extension for a format, including the dot
+func Extension(format string) string {
+	switch format = normalizeFormat(format); format {
+	case "jpeg":
+		return ".jpg"
+	case "tiff":
+		return ".tiff"
+	default:
+		return "." + format
+	}
+}
+
+// ReplaceExtension changes the extension of path to the one for format,
+// keeping extensions that already stand for it (e.g. ".jpeg")
+func ReplaceExtension(path, format string) string {
+	format = normalizeFormat(format)
+	if FormatFromPath(path) == format {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + Extension(format)
+}
+
+// Encode writes img in one of the supported formats. No metadata is written;
+// JPEG uses jpegQuality (default: 90) and WebP is lossless.
+func Encode(w io.Writer, img image.Image, format string, jpegQuality int) error {
+	if jpegQuality == 0 {
+		jpegQuality = 90
+	}
+
+	var err error
+	switch normalizeFormat(format) {
+	case "png":
+		err = png.Encode(w, img)
+	case "jpeg":
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case "webp":
+		err = nativewebp.Encode(w, img, nil)
+	case "gif":
+		err = gif.Encode(w, img, nil)
+	case "bmp":
+		err = bmp.Encode(w, img)
+	case "tiff":
+		err = tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode output image: %w", err)
+	}
+	return nil
+}
+
+// normalizeFormat maps format names and extensions to the names used by the
+// image package
+func normalizeFormat(format string) string {
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	switch format {
+	case "jpg":
+		return "jpeg"
+	case "tif":
+		return "tiff"
+	}
+	return format
+}
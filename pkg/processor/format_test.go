@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeFormats(t *testing.T) {
+	img := createCropTestImage(40, 30, 10, 10, 20, 10)
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, img, format, 0); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			decoded, decodedFormat, err := image.Decode(&buf)
+			if err != nil {
+				t.Fatalf("Failed to decode %s output: %v", format, err)
+			}
+			if decodedFormat != format {
+				t.Errorf("decoded as %s, want %s", decodedFormat, format)
+			}
+			if decoded.Bounds().Dx() != 40 || decoded.Bounds().Dy() != 30 {
+				t.Errorf("decoded image is %v, want 40x30", decoded.Bounds())
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "png", want: "png"},
+		{input: "JPG", want: "jpeg"},
+		{input: "jpeg", want: "jpeg"},
+		{input: ".webp", want: "webp"},
+		{input: "tif", want: "tiff"},
+		{input: "gif", want: "gif"},
+		{input: "bmp", want: "bmp"},
+		{input: "avif", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceExtension(t *testing.T) {
+	tests := []struct {
+		path   string
+		format string
+		want   string
+	}{
+		{path: "out/hero.png", format: "webp", want: "out/hero.webp"},
+		{path: "hero.png", format: "jpeg", want: "hero.jpg"},
+		{path: "hero.jpeg", format: "jpg", want: "hero.jpeg"},
+		{path: "hero.TIF", format: "tiff", want: "hero.TIF"},
+		{path: "hero", format: "gif", want: "hero.gif"},
+		{path: "hero.v2.png", format: "bmp", want: "hero.v2.bmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ReplaceExtension(tt.path, tt.format); got != tt.want {
+				t.Errorf("ReplaceExtension(%q, %q) = %q, want %q", tt.path, tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainWriteFileFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := encodeTestImage(t, createCropTestImage(40, 30, 10, 10, 20, 10), "png")
+
+	tests := []struct {
+		name       string
+		chain      *Chain
+		outputPath string
+		wantFormat string
+	}{
+		{name: "from extension", chain: NewChain(), outputPath: "image.webp", wantFormat: "webp"},
+		{name: "tiff extension", chain: NewChain(), outputPath: "image.tif", wantFormat: "tiff"},
+		{name: "unknown extension keeps input format", chain: NewChain(), outputPath: "image.out", wantFormat: "png"},
+		{name: "format overrides extension", chain: &Chain{Format: "bmp"}, outputPath: "image.png", wantFormat: "bmp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(tmpDir, tt.outputPath)
+			if err := tt.chain.WriteFile(data, outputPath); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			f, err := os.Open(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to open output: %v", err)
+			}
+			defer f.Close()
+			_, format, err := image.DecodeConfig(f)
+			if err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("output is %s, want %s", format, tt.wantFormat)
+			}
+		})
+	}
+}
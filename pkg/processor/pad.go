@@ -0,0 +1,209 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PadFill selects how the area added around an image is filled
+type PadFill string
+
+const (
+	// PadFillColor fills with the Background color, transparent by default
+	PadFillColor PadFill = "color"
+	// PadFillEdge repeats the outermost pixels of the image
+	PadFillEdge PadFill = "edge"
+	// PadFillMirror reflects the image at its edges
+	PadFillMirror PadFill = "mirror"
+)
+
+// ParsePadFill parses a fill name such as "color" or "mirror"
+func ParsePadFill(name string) (PadFill, error) {
+	switch fill := PadFill(strings.ToLower(strings.TrimSpace(name))); fill {
+	case "", "transparent", "background":
+		return PadFillColor, nil
+	case PadFillColor, PadFillEdge, PadFillMirror:
+		return fill, nil
+	case "replicate", "extend":
+		return PadFillEdge, nil
+	case "reflect":
+		return PadFillMirror, nil
+	default:
+		return "", fmt.Errorf("invalid fill '%s' (valid options: color, edge, mirror)", name)
+	}
+}
+
+// ParseAspectRatio parses an aspect ratio as width:height ("1:1", "16:9")
+// or as a number ("1.5"), returning width divided by height
+func ParseAspectRatio(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	w, h, found := strings.Cut(s, ":")
+	if !found {
+		w, h, found = strings.Cut(s, "/")
+	}
+	if !found {
+		h = "1"
+	}
+	wv, errW := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	hv, errH := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if errW != nil || errH != nil || wv <= 0 || hv <= 0 || math.IsInf(wv/hv, 0) {
+		return 0, fmt.Errorf("invalid aspect ratio '%s' (use width:height, such as 1:1 or 16:9)", s)
+	}
+	return wv / hv, nil
+}
+
+// PadOptions configures extending the canvas around an image. The canvas
+// only ever grows: the margin is added first, then the canvas is widened or
+// heightened to the aspect ratio, then grown to at least Width×Height.
+type PadOptions struct {
+	// Minimum canvas width and height in pixels (0 = no minimum)
+	Width  int
+	Height int
+	// Aspect ratio of the canvas, as width divided by height (0 = keep)
+	Aspect float64
+	// Margin in pixels added on every side of the image
+	Margin int
+	// Where the image is placed on the canvas (default: center)
+	Anchor Anchor
+	// How the added area is filled (default: color)
+	Fill PadFill
+	// Color of the added area for the color fill (default: transparent)
+	Background color.Color
+	// Quality for JPEG output (1-100, default: 90)
+	JPEGQuality int
+	// Format of the output file (default: from its extension, else the input format)
+	Format string
+}
+
+// Enabled reports whether the options add anything to an image
+func (opts PadOptions) Enabled() bool {
+	return opts.Width > 0 || opts.Height > 0 || opts.Aspect > 0 || opts.Margin > 0
+}
+
+// Pad extends the canvas around an image, for example to make it square
+// with a fixed margin around the subject.
+//
+// It is a Chain of a single PadStep, flattened onto white for JPEG output.
+func Pad(inputPath, outputPath string, opts PadOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	chain := NewChain(PadStep{Options: opts})
+	chain.Format, chain.JPEGQuality = opts.Format, opts.JPEGQuality
+	return chain.FlattenedFor(outputPath).ProcessFile(inputPath, outputPath)
+}
+
+// validate checks the options before any image is read
+func (opts PadOptions) validate() error {
+	if opts.Width < 0 || opts.Height < 0 || opts.Margin < 0 {
+		return fmt.Errorf("dimensions and margin cannot be negative")
+	}
+	if opts.Aspect < 0 || math.IsNaN(opts.Aspect) || math.IsInf(opts.Aspect, 0) {
+		return fmt.Errorf("invalid aspect ratio %v", opts.Aspect)
+	}
+	if !opts.Enabled() {
+		return fmt.Errorf("a size, aspect ratio or margin must be specified")
+	}
+	if _, err := ParsePadFill(string(opts.Fill)); err != nil {
+		return err
+	}
+	_, err := ParseAnchor(string(opts.Anchor))
+	return err
+}
+
+// canvasSize returns the size of the padded canvas for an image
+func (opts PadOptions) canvasSize(width, height int) (int, int) {
+	width += 2 * opts.Margin
+	height += 2 * opts.Margin
+	if opts.Aspect > 0 {
+		if float64(width)/float64(height) < opts.Aspect {
+			width = int(math.Round(float64(height) * opts.Aspect))
+		} else {
+			height = int(math.Round(float64(width) / opts.Aspect))
+		}
+	}
+	return max(width, opts.Width), max(height, opts.Height)
+}
+
+// PadStep is the pad operation as a Chain step
+type PadStep struct {
+	Options PadOptions
+}
+
+// Name implements Step
+func (s PadStep) Name() string {
+	return "pad"
+}
+
+// Apply places img on a larger canvas. Images that already fill the canvas
+// are returned unchanged.
+func (s PadStep) Apply(srcImg image.Image) (image.Image, error) {
+	opts := s.Options
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	fill, _ := ParsePadFill(string(opts.Fill))
+	anchor, _ := ParseAnchor(string(opts.Anchor))
+
+	srcBounds := srcImg.Bounds()
+	srcWidth, srcHeight := srcBounds.Dx(), srcBounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+	width, height := opts.canvasSize(srcWidth, srcHeight)
+	if width == srcWidth && height == srcHeight {
+		return srcImg, nil
+	}
+
+	// Where the image goes on the canvas
+	x := anchor.offset(width-2*opts.Margin, srcWidth, false) + opts.Margin
+	y := anchor.offset(height-2*opts.Margin, srcHeight, true) + opts.Margin
+	content := image.Rect(x, y, x+srcWidth, y+srcHeight)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	switch fill {
+	case PadFillColor:
+		if opts.Background != nil {
+			draw.Draw(dst, dst.Rect, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		}
+		draw.Draw(dst, content, srcImg, srcBounds.Min, draw.Src)
+	default:
+		// Every canvas pixel maps to a source pixel: itself inside the
+		// content, else the nearest edge pixel or its reflection
+		at := nrgbaReader(srcImg)
+		for dy := 0; dy < height; dy++ {
+			sy := srcBounds.Min.Y + padSource(dy-y, srcHeight, fill)
+			for dx := 0; dx < width; dx++ {
+				sx := srcBounds.Min.X + padSource(dx-x, srcWidth, fill)
+				dst.SetNRGBA(dx, dy, at(sx, sy))
+			}
+		}
+	}
+	return dst, nil
+}
+
+// padSource maps a position relative to the start of a span of size n onto
+// the span, clamping for the edge fill and reflecting for the mirror fill
+func padSource(i, n int, fill PadFill) int {
+	if i >= 0 && i < n {
+		return i
+	}
+	if fill == PadFillEdge {
+		return min(max(i, 0), n-1)
+	}
+	// Reflect about the edges, repeating the edge pixel: ... 1 0 | 0 1 ... n-1 | n-1 n-2 ...
+	period := 2 * n
+	i %= period
+	if i < 0 {
+		i += period
+	}
+	if i >= n {
+		i = period - 1 - i
+	}
+	return i
+}
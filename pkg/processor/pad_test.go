@@ -0,0 +1,186 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPadStep(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	white := color.NRGBA{255, 255, 255, 255}
+
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		opts        PadOptions
+		wantSize    image.Point
+		wantContent image.Point // top-left corner of the image on the canvas
+	}{
+		{name: "square", width: 40, height: 20, opts: PadOptions{Aspect: 1}, wantSize: image.Pt(40, 40), wantContent: image.Pt(0, 10)},
+		{name: "square with margin", width: 40, height: 20, opts: PadOptions{Aspect: 1, Margin: 5}, wantSize: image.Pt(50, 50), wantContent: image.Pt(5, 15)},
+		{name: "wide aspect", width: 40, height: 20, opts: PadOptions{Aspect: 16.0 / 4}, wantSize: image.Pt(80, 20), wantContent: image.Pt(20, 0)},
+		{name: "minimum size", width: 40, height: 20, opts: PadOptions{Width: 64, Height: 64}, wantSize: image.Pt(64, 64), wantContent: image.Pt(12, 22)},
+		{name: "never shrinks", width: 40, height: 20, opts: PadOptions{Width: 10, Height: 30}, wantSize: image.Pt(40, 30), wantContent: image.Pt(0, 5)},
+		{name: "top-left anchor", width: 40, height: 20, opts: PadOptions{Width: 64, Height: 64, Margin: 2, Anchor: AnchorTopLeft}, wantSize: image.Pt(64, 64), wantContent: image.Pt(2, 2)},
+		{name: "bottom-right anchor", width: 40, height: 20, opts: PadOptions{Width: 64, Height: 64, Anchor: AnchorBottomRight}, wantSize: image.Pt(64, 64), wantContent: image.Pt(24, 44)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := createFilledImage(tt.width, tt.height, red, red, image.Rectangle{})
+			tt.opts.Background = white
+			out, err := PadStep{Options: tt.opts}.Apply(src)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if out.Bounds().Size() != tt.wantSize {
+				t.Fatalf("size = %v, want %v", out.Bounds().Size(), tt.wantSize)
+			}
+
+			// The corner of the content is red, the pixels before it white
+			c := tt.wantContent
+			if got := color.NRGBAModel.Convert(out.At(c.X, c.Y)); got != red {
+				t.Errorf("content corner %v = %v, want red", c, got)
+			}
+			if c.X > 0 {
+				if got := color.NRGBAModel.Convert(out.At(c.X-1, c.Y)); got != white {
+					t.Errorf("pixel left of the content = %v, want white", got)
+				}
+			}
+			if c.Y > 0 {
+				if got := color.NRGBAModel.Convert(out.At(c.X, c.Y-1)); got != white {
+					t.Errorf("pixel above the content = %v, want white", got)
+				}
+			}
+		})
+	}
+}
+
+func TestPadStepFills(t *testing.T) {
+	// Three columns: red, green, blue
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	red, green, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 255, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	src.SetNRGBA(0, 0, red)
+	src.SetNRGBA(1, 0, green)
+	src.SetNRGBA(2, 0, blue)
+
+	tests := []struct {
+		fill PadFill
+		want []color.NRGBA
+	}{
+		{fill: PadFillColor, want: []color.NRGBA{{}, {}, red, green, blue, {}, {}}},
+		{fill: PadFillEdge, want: []color.NRGBA{red, red, red, green, blue, blue, blue}},
+		{fill: PadFillMirror, want: []color.NRGBA{green, red, red, green, blue, blue, green}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.fill), func(t *testing.T) {
+			out, err := PadStep{Options: PadOptions{Width: 7, Fill: tt.fill}}.Apply(src)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			for x, want := range tt.want {
+				if got := color.NRGBAModel.Convert(out.At(x, 0)); got != want {
+					t.Errorf("pixel %d = %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPadSourceMirrorWide(t *testing.T) {
+	// Padding wider than the image keeps reflecting
+	want := []int{0, 1, 1, 0, 0, 1, 1, 0, 0, 1}
+	for i, w := range want {
+		if got := padSource(i-4, 2, PadFillMirror); got != w {
+			t.Errorf("padSource(%d) = %d, want %d", i-4, got, w)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "logo.png")
+	if err := saveTestImage(createCropTestImage(40, 20, 5, 5, 10, 10), inputPath); err != nil {
+		t.Fatal(err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "logo_padded.png")
+	if err := Pad(inputPath, outputPath, PadOptions{Aspect: 1, Margin: 4}); err != nil {
+		t.Fatalf("Pad() error = %v", err)
+	}
+	width, height, err := GetImageDimensions(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 48 || height != 48 {
+		t.Errorf("padded image is %dx%d, want 48x48", width, height)
+	}
+
+	if err := Pad(inputPath, outputPath, PadOptions{}); err == nil {
+		t.Error("Pad() without a size succeeded, want error")
+	}
+	if _, err := os.Stat(inputPath); err != nil {
+		t.Errorf("input was removed: %v", err)
+	}
+}
+
+func TestParseAspectRatio(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{input: "1:1", want: 1},
+		{input: "16:9", want: 16.0 / 9},
+		{input: "4/3", want: 4.0 / 3},
+		{input: "1.5", want: 1.5},
+		{input: "0:1", wantErr: true},
+		{input: "1:0", wantErr: true},
+		{input: "square", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAspectRatio(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAspectRatio(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAspectRatio(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePadFill(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    PadFill
+		wantErr bool
+	}{
+		{input: "", want: PadFillColor},
+		{input: "transparent", want: PadFillColor},
+		{input: "edge", want: PadFillEdge},
+		{input: "replicate", want: PadFillEdge},
+		{input: "Mirror", want: PadFillMirror},
+		{input: "blur", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePadFill(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePadFill(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePadFill(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
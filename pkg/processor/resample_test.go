@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLanczos3Kernel(t *testing.T) {
+	tests := []struct {
+		t    float64
+		want float64
+	}{
+		{t: 0, want: 1},
+		{t: 1, want: 0},
+		{t: 2, want: 0},
+		{t: 3, want: 0},
+		{t: 4, want: 0},
+		{t: 0.5, want: 0.6079},
+		{t: 1.5, want: -0.1351},
+	}
+
+	for _, tt := range tests {
+		if got := Lanczos3.At(tt.t); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("Lanczos3.At(%v) = %.4f, want %.4f", tt.t, got, tt.want)
+		}
+	}
+}
+
+// createStripeImage creates an image of alternating black and white columns
+func createStripeImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+func TestScaleImageLinear(t *testing.T) {
+	src := createStripeImage(8, 8)
+
+	tests := []struct {
+		name   string
+		linear bool
+		want   uint8
+	}{
+		// Averaging white and black gives half the light, which is 188 in sRGB
+		{name: "linear", linear: true, want: 188},
+		{name: "srgb", linear: false, want: 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, filter := range []ResizeFilter{FilterLanczos, FilterBiLinear} {
+				dst := image.NewRGBA(image.Rect(0, 0, 1, 1))
+				scaleImage(dst, dst.Bounds(), src, src.Bounds(), filter, tt.linear)
+
+				got := dst.RGBAAt(0, 0)
+				if diff := int(got.R) - int(tt.want); diff < -3 || diff > 3 {
+					t.Errorf("filter %v: gray = %d, want %d", filter, got.R, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScaleImageNoDarkFringe(t *testing.T) {
+	// Red sprite on the left, fully transparent black on the right
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	for _, linear := range []bool{false, true} {
+		step := ResizeStep{Options: ResizeOptions{Width: 5, Height: 5, Mode: ResizeExact, Linear: linear}}
+		out, err := step.Apply(src)
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+
+		// Every visible pixel must stay pure red, however transparent
+		b := out.Bounds()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(out.At(x, 2)).(color.NRGBA)
+			if c.A > 16 && c.R < 240 {
+				t.Errorf("linear=%v: pixel %d is %v, want red without a dark fringe", linear, x, c)
+			}
+		}
+	}
+}
@@ -3,10 +3,9 @@ package processor
 import (
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
+	"image/color"
+	"math"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"golang.org/x/image/draw"
@@ -16,7 +15,7 @@ import (
 type ResizeFilter int
 
 const (
-	// FilterLanczos uses Lanczos resampling - highest quality, best for downscaling
+	// FilterLanczos uses Lanczos3 resampling - highest quality, best for downscaling
 	FilterLanczos ResizeFilter = iota
 	// FilterBiLinear uses bilinear interpolation - good balance of speed and quality
 	FilterBiLinear
@@ -35,8 +34,13 @@ type DownscaleOptions struct {
 	Percentage float64
 	// Scaling algorithm to use (default: Lanczos)
 	Filter ResizeFilter
+	// Linear blends pixels in linear light instead of sRGB, which keeps
+	// thin bright and dark details at their true brightness
+	Linear bool
 	// Quality for JPEG output (1-100, default: 90)
 	JPEGQuality int
+	// Format of the output file (default: from its extension, else the input format)
+	Format string
 }
 
 // DownscaleImage resizes an image using high-quality Lanczos filtering.
@@ -53,10 +57,21 @@ type DownscaleOptions struct {
 // The function automatically:
 //   - Maintains aspect ratio if only width or height is specified
 //   - Skips downscaling if target dimensions are larger than source
-//   - Preserves the image format (PNG/JPEG)
+//   - Writes the format of opts.Format or the output extension, else the input format
 //   - Uses Lanczos3 resampling for optimal quality
+//
+// It is a Chain of a single DownscaleStep.
 func DownscaleImage(inputPath, outputPath string, opts DownscaleOptions) error {
-	// Validate options
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	chain := NewChain(DownscaleStep{Options: opts})
+	chain.Format, chain.JPEGQuality = opts.Format, opts.JPEGQuality
+	return chain.FlattenedFor(outputPath).ProcessFile(inputPath, outputPath)
+}
+
+// validate checks the options before any image is read
+func (opts DownscaleOptions) validate() error {
 	if opts.Percentage == 0 && opts.Width == 0 && opts.Height == 0 {
 		return fmt.Errorf("either percentage or at least one dimension (width or height) must be specified")
 	}
@@ -68,23 +83,24 @@ func DownscaleImage(inputPath, outputPath string, opts DownscaleOptions) error {
 	if opts.Width < 0 || opts.Height < 0 {
 		return fmt.Errorf("dimensions cannot be negative")
 	}
+	return nil
+}
 
-	// Set default JPEG quality if not specified
-	if opts.JPEGQuality == 0 {
-		opts.JPEGQuality = 90
-	}
+// DownscaleStep is the downscale operation as a Chain step
+type DownscaleStep struct {
+	Options DownscaleOptions
+}
 
-	// Open the source image
-	inputFile, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("failed to open input image: %w", err)
-	}
-	defer inputFile.Close()
+// Name implements Step
+func (s DownscaleStep) Name() string {
+	return "downscale"
+}
 
-	// Decode the image (supports PNG and JPEG automatically)
-	srcImg, format, err := image.Decode(inputFile)
-	if err != nil {
-		return fmt.Errorf("failed to decode image: %w", err)
+// Apply scales img down to the target dimensions
+func (s DownscaleStep) Apply(srcImg image.Image) (image.Image, error) {
+	opts := s.Options
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
 
 	// Get source dimensions
@@ -115,58 +131,27 @@ func DownscaleImage(inputPath, outputPath string, opts DownscaleOptions) error {
 
 	// Validate that we're actually downscaling (not upscaling)
 	if targetWidth >= srcWidth && targetHeight >= srcHeight {
-		return fmt.Errorf("target dimensions (%dx%d) are not smaller than source (%dx%d) - downscaling only",
+		return nil, fmt.Errorf("target dimensions (%dx%d) are not smaller than source (%dx%d) - downscaling only",
 			targetWidth, targetHeight, srcWidth, srcHeight)
 	}
 
-	// Create destination image
+	// Create destination image and perform the downscaling
 	dstImg := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	scaleImage(dstImg, dstImg.Bounds(), srcImg, srcBounds, opts.Filter, opts.Linear)
+	return dstImg, nil
+}
 
-	// Select the scaling algorithm
-	var scaler draw.Scaler
-	switch opts.Filter {
-	case FilterLanczos:
-		scaler = draw.CatmullRom // Lanczos3 equivalent in x/image/draw
-	case FilterBiLinear:
-		scaler = draw.BiLinear
-	case FilterNearestNeighbor:
-		scaler = draw.NearestNeighbor
-	default:
-		scaler = draw.CatmullRom // Default to highest quality
-	}
-
-	// Perform the downscaling
-	scaler.Scale(dstImg, dstImg.Bounds(), srcImg, srcBounds, draw.Over, nil)
-
-	// Create output file
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outputFile.Close()
-
-	// Encode and save based on format
-	switch strings.ToLower(format) {
-	case "jpeg", "jpg":
-		err = jpeg.Encode(outputFile, dstImg, &jpeg.Options{Quality: opts.JPEGQuality})
-	case "png":
-		err = png.Encode(outputFile, dstImg)
-	default:
-		// Default to PNG for unknown formats
-		err = png.Encode(outputFile, dstImg)
-	}
-
-	if err != nil {
-		return fmt.Errorf("failed to encode output image: %w", err)
-	}
-
-	// Mandatory: Strip all PNG metadata for privacy and security
-	// This removes any generation parameters, timestamps, or other sensitive data
-	if err := StripPNGMetadata(outputPath); err != nil {
-		return fmt.Errorf("failed to strip PNG metadata: %w", err)
+// ParseResizeFilter parses a filter name: lanczos, bilinear or nearest
+func ParseResizeFilter(name string) (ResizeFilter, error) {
+	switch strings.ToLower(name) {
+	case "lanczos", "":
+		return FilterLanczos, nil
+	case "bilinear":
+		return FilterBiLinear, nil
+	case "nearest":
+		return FilterNearestNeighbor, nil
 	}
-
-	return nil
+	return 0, fmt.Errorf("invalid filter '%s' (valid options: lanczos, bilinear, nearest)", name)
 }
 
 // GetImageDimensions returns the width and height of an image file without fully decoding it.
@@ -186,30 +171,218 @@ func GetImageDimensions(imagePath string) (width, height int, err error) {
 	return config.Width, config.Height, nil
 }
 
-// DownscaleInPlace downscales an image and replaces the original file.
-// This is a convenience wrapper around DownscaleImage for in-place operations.
+// DownscaleInPlace downscales an image and replaces the original file once
+// the downscaled image was written.
 func DownscaleInPlace(imagePath string, opts DownscaleOptions) error {
-	// Create a temporary file in the same directory
-	dir := filepath.Dir(imagePath)
-	base := filepath.Base(imagePath)
-	ext := filepath.Ext(imagePath)
-	nameWithoutExt := strings.TrimSuffix(base, ext)
-
-	tempPath := filepath.Join(dir, fmt.Sprintf(".%s_tmp%s", nameWithoutExt, ext))
-
-	// Downscale to temporary file
-	if err := DownscaleImage(imagePath, tempPath, opts); err != nil {
-		// Clean up temp file if it was created
-		os.Remove(tempPath)
+	return DownscaleImage(imagePath, imagePath, opts)
+}
+
+// ResizeMode selects how an image is fitted to a target box
+type ResizeMode string
+
+const (
+	// ResizeFit scales the image to fit inside the box and pads the rest
+	// (letterboxing)
+	ResizeFit ResizeMode = "fit"
+	// ResizeFill scales the image to cover the box and crops the overflow at
+	// the anchor
+	ResizeFill ResizeMode = "fill"
+	// ResizeExact stretches the image to the box, ignoring its aspect ratio
+	ResizeExact ResizeMode = "exact"
+)
+
+// ParseResizeMode parses a mode name: fit, fill (or cover) or exact
+func ParseResizeMode(name string) (ResizeMode, error) {
+	switch strings.ToLower(name) {
+	case "fit", "":
+		return ResizeFit, nil
+	case "fill", "cover":
+		return ResizeFill, nil
+	case "exact", "stretch":
+		return ResizeExact, nil
+	}
+	return "", fmt.Errorf("invalid resize mode '%s' (valid options: fit, fill, cover, exact)", name)
+}
+
+// Anchor is the point an image is aligned to when it is cropped or padded
+type Anchor string
+
+const (
+	AnchorCenter      Anchor = "center"
+	AnchorTop         Anchor = "top"
+	AnchorBottom      Anchor = "bottom"
+	AnchorLeft        Anchor = "left"
+	AnchorRight       Anchor = "right"
+	AnchorTopLeft     Anchor = "top-left"
+	AnchorTopRight    Anchor = "top-right"
+	AnchorBottomLeft  Anchor = "bottom-left"
+	AnchorBottomRight Anchor = "bottom-right"
+)
+
+// ParseAnchor parses an anchor name such as "center" or "top-left"
+func ParseAnchor(name string) (Anchor, error) {
+	anchor := Anchor(strings.ReplaceAll(strings.ToLower(name), "_", "-"))
+	switch anchor {
+	case "", "centre":
+		return AnchorCenter, nil
+	case AnchorCenter, AnchorTop, AnchorBottom, AnchorLeft, AnchorRight,
+		AnchorTopLeft, AnchorTopRight, AnchorBottomLeft, AnchorBottomRight:
+		return anchor, nil
+	}
+	return "", fmt.Errorf("invalid anchor '%s' (valid options: center, top, bottom, left, right, top-left, top-right, bottom-left, bottom-right)", name)
+}
+
+// offset returns where a span of size inner is placed in a span of size
+// outer, horizontally (vertical false) or vertically
+func (a Anchor) offset(outer, inner int, vertical bool) int {
+	side := string(a)
+	start, end := "left", "right"
+	if vertical {
+		start, end = "top", "bottom"
+	}
+	switch {
+	case strings.Contains(side, start):
+		return 0
+	case strings.Contains(side, end):
+		return outer - inner
+	default:
+		return (outer - inner) / 2
+	}
+}
+
+// ResizeOptions configures resizing to a target box
+type ResizeOptions struct {
+	// Target width and height in pixels. When one is 0 it is calculated from
+	// the aspect ratio, and the mode makes no difference.
+	Width  int
+	Height int
+	// How the image is fitted to the box (default: fit)
+	Mode ResizeMode
+	// Where the image is aligned when cropped or padded (default: center)
+	Anchor Anchor
+	// Color of the padding around fitted images (default: transparent)
+	Background color.Color
+	// Never scale the image up; smaller images are padded instead
+	NoUpscale bool
+	// Scaling algorithm to use (default: Lanczos)
+	Filter ResizeFilter
+	// Linear blends pixels in linear light instead of sRGB
+	Linear bool
+	// Quality for JPEG output (1-100, default: 90)
+	JPEGQuality int
+	// Format of the output file (default: from its extension, else the input format)
+	Format string
+}
+
+// ResizeImage resizes an image to a target box, scaling it up or down. Unlike
+// DownscaleImage it keeps the exact target dimensions in every mode, so it
+// suits fixed-size slots such as 256x256 icons.
+//
+// It is a Chain of a single ResizeStep, flattened onto white for JPEG output.
+func ResizeImage(inputPath, outputPath string, opts ResizeOptions) error {
+	if err := opts.validate(); err != nil {
 		return err
 	}
+	chain := NewChain(ResizeStep{Options: opts})
+	chain.Format, chain.JPEGQuality = opts.Format, opts.JPEGQuality
+	return chain.FlattenedFor(outputPath).ProcessFile(inputPath, outputPath)
+}
 
-	// Replace original with downscaled version
-	if err := os.Rename(tempPath, imagePath); err != nil {
-		// Clean up temp file
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to replace original file: %w", err)
+// validate checks the options before any image is read
+func (opts ResizeOptions) validate() error {
+	if opts.Width < 0 || opts.Height < 0 {
+		return fmt.Errorf("dimensions cannot be negative")
+	}
+	if opts.Width == 0 && opts.Height == 0 {
+		return fmt.Errorf("at least one dimension (width or height) must be specified")
 	}
+	if _, err := ParseResizeMode(string(opts.Mode)); err != nil {
+		return err
+	}
+	_, err := ParseAnchor(string(opts.Anchor))
+	return err
+}
 
-	return nil
+// ResizeStep is the resize operation as a Chain step
+type ResizeStep struct {
+	Options ResizeOptions
+}
+
+// Name implements Step
+func (s ResizeStep) Name() string {
+	return "resize"
+}
+
+// Apply resizes img to the target box
+func (s ResizeStep) Apply(srcImg image.Image) (image.Image, error) {
+	opts := s.Options
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	mode, _ := ParseResizeMode(string(opts.Mode))
+	anchor, _ := ParseAnchor(string(opts.Anchor))
+
+	srcBounds := srcImg.Bounds()
+	srcWidth, srcHeight := srcBounds.Dx(), srcBounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	// Calculate a missing dimension from the aspect ratio
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = max(1, int(math.Round(float64(height)*float64(srcWidth)/float64(srcHeight))))
+	} else if height == 0 {
+		height = max(1, int(math.Round(float64(width)*float64(srcHeight)/float64(srcWidth))))
+	}
+
+	scaleX := float64(width) / float64(srcWidth)
+	scaleY := float64(height) / float64(srcHeight)
+
+	// The part of the source to use, and its size in the output
+	src := srcBounds
+	var dstWidth, dstHeight int
+	switch mode {
+	case ResizeExact:
+		if opts.NoUpscale {
+			scaleX, scaleY = math.Min(scaleX, 1), math.Min(scaleY, 1)
+		}
+		dstWidth, dstHeight = scaledSize(srcWidth, scaleX), scaledSize(srcHeight, scaleY)
+	case ResizeFit:
+		scale := math.Min(scaleX, scaleY)
+		if opts.NoUpscale {
+			scale = math.Min(scale, 1)
+		}
+		dstWidth, dstHeight = min(width, scaledSize(srcWidth, scale)), min(height, scaledSize(srcHeight, scale))
+	case ResizeFill:
+		scale := math.Max(scaleX, scaleY)
+		if opts.NoUpscale {
+			scale = math.Min(scale, 1)
+		}
+		cropWidth := min(srcWidth, max(1, int(math.Round(float64(width)/scale))))
+		cropHeight := min(srcHeight, max(1, int(math.Round(float64(height)/scale))))
+		x := srcBounds.Min.X + anchor.offset(srcWidth, cropWidth, false)
+		y := srcBounds.Min.Y + anchor.offset(srcHeight, cropHeight, true)
+		src = image.Rect(x, y, x+cropWidth, y+cropHeight)
+		// The crop is scaled onto the whole canvas; only a source smaller
+		// than the canvas under NoUpscale leaves padding. Deriving the size
+		// from the rounded crop would leave gaps when upscaling.
+		dstWidth, dstHeight = min(width, scaledSize(srcWidth, scale)), min(height, scaledSize(srcHeight, scale))
+	}
+
+	// Place the scaled image on the canvas at the anchor, padding the rest
+	dstImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	if (dstWidth < width || dstHeight < height) && opts.Background != nil {
+		draw.Draw(dstImg, dstImg.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+	x := anchor.offset(width, dstWidth, false)
+	y := anchor.offset(height, dstHeight, true)
+	dst := image.Rect(x, y, x+dstWidth, y+dstHeight)
+	scaleImage(dstImg, dst, srcImg, src, opts.Filter, opts.Linear)
+	return dstImg, nil
+}
+
+// scaledSize returns a size after scaling, at least one pixel
+func scaledSize(size int, scale float64) int {
+	return max(1, int(math.Round(float64(size)*scale)))
 }
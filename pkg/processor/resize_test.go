@@ -496,3 +496,212 @@ func hasSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// createSplitImage returns an image whose left half is red and right half blue
+func createSplitImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestResizeStep(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+	transparent := color.NRGBA{}
+
+	type pixel struct {
+		x, y int
+		want color.NRGBA
+	}
+
+	tests := []struct {
+		name         string
+		srcWidth     int
+		srcHeight    int
+		opts         ResizeOptions
+		expectWidth  int
+		expectHeight int
+		pixels       []pixel
+	}{
+		{
+			name:     "fit letterboxes",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Width: 256, Height: 256},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{128, 10, transparent}, {10, 128, red}, {245, 128, blue}, {128, 245, transparent}},
+		},
+		{
+			name:     "fit pads with background at anchor",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Width: 256, Height: 256, Anchor: AnchorTop, Background: black},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{10, 10, red}, {10, 245, black}},
+		},
+		{
+			name:     "fill crops the center",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Width: 256, Height: 256, Mode: ResizeFill},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{10, 128, red}, {245, 128, blue}},
+		},
+		{
+			name:     "fill crops at anchor",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Width: 256, Height: 256, Mode: ResizeFill, Anchor: AnchorLeft},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{10, 128, red}, {245, 128, red}},
+		},
+		{
+			name:     "exact stretches",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Width: 256, Height: 256, Mode: ResizeExact},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{10, 10, red}, {245, 245, blue}},
+		},
+		{
+			name:     "upscales",
+			srcWidth: 100, srcHeight: 50,
+			opts:        ResizeOptions{Width: 400},
+			expectWidth: 400, expectHeight: 200,
+		},
+		{
+			name:     "no upscale pads instead",
+			srcWidth: 100, srcHeight: 50,
+			opts:        ResizeOptions{Width: 256, Height: 256, NoUpscale: true, Mode: ResizeFill},
+			expectWidth: 256, expectHeight: 256,
+			pixels: []pixel{{100, 128, red}, {150, 128, blue}, {10, 10, transparent}},
+		},
+		{
+			name:     "height only keeps aspect ratio",
+			srcWidth: 400, srcHeight: 200,
+			opts:        ResizeOptions{Height: 100, Mode: ResizeExact},
+			expectWidth: 200, expectHeight: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := ResizeStep{Options: tt.opts}.Apply(createSplitImage(tt.srcWidth, tt.srcHeight))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if img.Bounds().Dx() != tt.expectWidth || img.Bounds().Dy() != tt.expectHeight {
+				t.Fatalf("Expected %dx%d, got %dx%d", tt.expectWidth, tt.expectHeight, img.Bounds().Dx(), img.Bounds().Dy())
+			}
+			for _, p := range tt.pixels {
+				if got := color.NRGBAModel.Convert(img.At(p.x, p.y)); got != p.want {
+					t.Errorf("pixel (%d,%d) = %v, expected %v", p.x, p.y, got, p.want)
+				}
+			}
+		})
+	}
+}
+
+func TestResizeStepFillUpscaleCoversCanvas(t *testing.T) {
+	tests := []struct {
+		srcWidth, srcHeight int
+		width, height       int
+	}{
+		{60, 60, 256, 100},
+		{60, 60, 100, 256},
+		{33, 17, 512, 511},
+		{7, 13, 300, 299},
+		{100, 50, 301, 97},
+	}
+
+	for _, tt := range tests {
+		opts := ResizeOptions{Width: tt.width, Height: tt.height, Mode: ResizeFill}
+		img, err := ResizeStep{Options: opts}.Apply(createSplitImage(tt.srcWidth, tt.srcHeight))
+		if err != nil {
+			t.Fatalf("%dx%d to %dx%d: Apply() error = %v", tt.srcWidth, tt.srcHeight, tt.width, tt.height, err)
+		}
+		if img.Bounds().Dx() != tt.width || img.Bounds().Dy() != tt.height {
+			t.Fatalf("Expected %dx%d, got %dx%d", tt.width, tt.height, img.Bounds().Dx(), img.Bounds().Dy())
+		}
+		// Every pixel must come from the opaque source
+		for y := 0; y < tt.height; y++ {
+			for x := 0; x < tt.width; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+					t.Fatalf("%dx%d to %dx%d: pixel (%d,%d) alpha = %d, expected opaque", tt.srcWidth, tt.srcHeight, tt.width, tt.height, x, y, a>>8)
+				}
+			}
+		}
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.png")
+	createTestImage(t, inputPath, 300, 200)
+
+	outputPath := filepath.Join(tmpDir, "icon.png")
+	opts := ResizeOptions{Width: 256, Height: 256, Mode: ResizeFill}
+	if err := ResizeImage(inputPath, outputPath, opts); err != nil {
+		t.Fatalf("ResizeImage() error = %v", err)
+	}
+	width, height, err := GetImageDimensions(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to get output dimensions: %v", err)
+	}
+	if width != 256 || height != 256 {
+		t.Errorf("Expected 256x256, got %dx%d", width, height)
+	}
+
+	// Transparent letterbox bars of JPEG output are flattened onto white
+	jpegPath := filepath.Join(tmpDir, "icon.jpg")
+	opts = ResizeOptions{Width: 256, Height: 256, Background: color.NRGBA{}}
+	if err := ResizeImage(inputPath, jpegPath, opts); err != nil {
+		t.Fatalf("ResizeImage() to JPEG error = %v", err)
+	}
+	f, err := os.Open(jpegPath)
+	if err != nil {
+		t.Fatalf("Failed to open JPEG output: %v", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("Failed to decode JPEG output: %v", err)
+	}
+	if r, g, b, _ := img.At(128, 2).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("Letterbox pixel = (%d, %d, %d), expected white", r>>8, g>>8, b>>8)
+	}
+
+	invalid := []ResizeOptions{
+		{},
+		{Width: -1, Height: 10},
+		{Width: 10, Mode: "squash"},
+		{Width: 10, Anchor: "middle"},
+	}
+	for _, opts := range invalid {
+		if err := ResizeImage(inputPath, outputPath, opts); err == nil {
+			t.Errorf("ResizeImage(%+v) succeeded, expected an error", opts)
+		}
+	}
+}
+
+func TestParseResizeOptions(t *testing.T) {
+	modes := map[string]ResizeMode{"": ResizeFit, "FIT": ResizeFit, "cover": ResizeFill, "fill": ResizeFill, "exact": ResizeExact}
+	for name, want := range modes {
+		if got, err := ParseResizeMode(name); err != nil || got != want {
+			t.Errorf("ParseResizeMode(%q) = %q, %v, expected %q", name, got, err, want)
+		}
+	}
+	anchors := map[string]Anchor{"": AnchorCenter, "centre": AnchorCenter, "Top_Left": AnchorTopLeft, "bottom": AnchorBottom}
+	for name, want := range anchors {
+		if got, err := ParseAnchor(name); err != nil || got != want {
+			t.Errorf("ParseAnchor(%q) = %q, %v, expected %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseResizeFilter("bicubic"); err == nil {
+		t.Error("ParseResizeFilter accepted an unknown filter")
+	}
+}